@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/manifest"
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// newApplyCommand returns the command rewriting the memory quantities of a local manifests directory
+func newApplyCommand() *command {
+	var input, cluster, helmTemplate string
+	var dryRun bool
+	return &command{
+		name:       "apply",
+		args:       "<directory>",
+		summary:    "Rewrite the memory requests and limits of the manifests and Helm values files in a local directory",
+		withConfig: true,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.StringVar(&input, "input", "", "JSON or xlsx report written by 'recommend' to apply instead of querying Prometheus")
+			fs.StringVar(&cluster, "cluster", "", "`cluster` whose recommendations are applied, required when they cover several")
+			fs.StringVar(&helmTemplate, "helm-key-path", exporter.DefaultHelmKeyPath,
+				"`template` of the key of each memory quantity in the values.yaml and values-*.yaml files, as for 'recommend -output helm'; only existing keys are rewritten, empty to leave the values files out")
+			fs.BoolVar(&dryRun, "dry-run", false, "print the diff without writing the files")
+		},
+		run: func(ctx context.Context, cfg *config.Config, args []string, out io.Writer) int {
+			if len(args) != 1 {
+				log.Print("apply expects the manifests directory as its only argument")
+				return exitConfigError
+			}
+
+			var helmKeyPath exporter.HelmKeyPath
+			if helmTemplate != "" {
+				var err error
+				if helmKeyPath, err = exporter.ParseHelmKeyPath(helmTemplate); err != nil {
+					log.Print(err)
+					return exitConfigError
+				}
+			}
+
+			var recommendations []types.RecommendationResult
+			if input != "" {
+				var err error
+				if recommendations, err = readReport(input); err != nil {
+					log.Print(err)
+					return exitConfigError
+				}
+			} else {
+				var err error
+				if recommendations, err = generateRecommendations(ctx, cfg); err != nil {
+					log.Print(err)
+					return recommendExitCode(err)
+				}
+			}
+
+			if cluster != "" {
+				recommendations = clusterRecommendations(recommendations, cluster)
+			} else if clusters := manifest.Clusters(recommendations); len(clusters) > 1 {
+				log.Printf("apply rewrites the manifests of one cluster, select one of %s with -cluster", strings.Join(clusters, ", "))
+				return exitConfigError
+			}
+
+			result, err := manifest.Rewrite(args[0], recommendations, helmKeyPath, !dryRun)
+			if err != nil {
+				log.Print(err)
+				return exitRecommendFailed
+			}
+			for _, file := range result.Files {
+				fmt.Fprint(out, file.Diff())
+			}
+			printApplySummary(out, result, dryRun)
+			return exitOK
+		},
+	}
+}
+
+// clusterRecommendations returns the recommendations of cluster
+func clusterRecommendations(recommendations []types.RecommendationResult, cluster string) []types.RecommendationResult {
+	var selected []types.RecommendationResult
+	for _, rec := range recommendations {
+		if rec.Cluster == cluster {
+			selected = append(selected, rec)
+		}
+	}
+	return selected
+}
+
+// printApplySummary writes the files changed, the files skipped and the
+// recommendations no manifest matched
+func printApplySummary(w io.Writer, result *manifest.Result, dryRun bool) {
+	quantities := 0
+	for _, file := range result.Files {
+		quantities += len(file.Changes)
+	}
+
+	verb := "Updated"
+	if dryRun {
+		verb = "Would update"
+	}
+	if len(result.Files) > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%s %d files, %d quantities\n", verb, len(result.Files), quantities)
+	for _, file := range result.Files {
+		fmt.Fprintf(w, "  %s\n", file.Path)
+		for _, change := range file.Changes {
+			fmt.Fprintf(w, "    %s %s/%s container %s: %s %s -> %s\n",
+				change.Kind, change.Namespace, change.Name, change.Container, change.Field, change.Old, change.New)
+		}
+	}
+
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(w, "Skipped %d files that could not be rewritten:\n", len(result.Skipped))
+		for _, skipped := range result.Skipped {
+			fmt.Fprintf(w, "  %s: %s\n", skipped.Path, skipped.Reason)
+		}
+	}
+	if len(result.Unmatched) > 0 {
+		fmt.Fprintf(w, "%d recommendations matched no manifest:\n", len(result.Unmatched))
+		for _, rec := range result.Unmatched {
+			fmt.Fprintf(w, "  %s/%s container %s\n", rec.Namespace, rec.Deployment, rec.Container)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const applyManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-app
+  namespace: production
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          resources:
+            requests:
+              memory: 512Mi # before
+            limits:
+              memory: 1Gi
+`
+
+const applyReport = `[
+  {"namespace": "production", "deployment": "web-app", "container": "nginx", "recommended_request_mb": 256, "recommended_limit_mb": 384},
+  {"namespace": "production", "deployment": "api", "container": "app", "recommended_request_mb": 128, "recommended_limit_mb": 192}
+]`
+
+// writeApplyFixtures writes a manifests directory and a JSON report, returning their paths
+func writeApplyFixtures(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	manifests := filepath.Join(dir, "manifests")
+	if err := os.Mkdir(manifests, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(manifests, "web.yaml"), []byte(applyManifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(dir, "report.json")
+	if err := os.WriteFile(report, []byte(applyReport), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return manifests, report
+}
+
+func TestApply_DryRun(t *testing.T) {
+	manifests, report := writeApplyFixtures(t)
+
+	code, stdout, stderr := runCLI(t, "apply", "-input", report, "-dry-run", manifests)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+
+	for _, expected := range []string{
+		"--- a/web.yaml",
+		"-              memory: 512Mi # before",
+		"+              memory: 256Mi # before",
+		"+              memory: 384Mi",
+		"Would update 1 files, 2 quantities",
+		"Deployment production/web-app container nginx: requests.memory 512Mi -> 256Mi",
+		"1 recommendations matched no manifest:\n  production/api container app",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output containing '%s', got:\n%s", expected, stdout)
+		}
+	}
+
+	content, _ := os.ReadFile(filepath.Join(manifests, "web.yaml"))
+	if string(content) != applyManifest {
+		t.Error("Expected a dry run to leave the manifest unchanged")
+	}
+}
+
+func TestApply_Write(t *testing.T) {
+	manifests, report := writeApplyFixtures(t)
+
+	code, stdout, stderr := runCLI(t, "apply", "-input", report, manifests)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "Updated 1 files, 2 quantities") {
+		t.Errorf("Unexpected summary:\n%s", stdout)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(manifests, "web.yaml"))
+	if !strings.Contains(string(content), "memory: 256Mi # before") || !strings.Contains(string(content), "memory: 384Mi") {
+		t.Errorf("Expected the manifest to be rewritten, got:\n%s", content)
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	manifests, _ := writeApplyFixtures(t)
+
+	if code, _, _ := runCLI(t, "apply", "-input", "report.json"); code != exitConfigError {
+		t.Errorf("Expected exit code %d without a directory, got %d", exitConfigError, code)
+	}
+	if code, _, _ := runCLI(t, "apply", "-input", filepath.Join(manifests, "missing.json"), manifests); code != exitConfigError {
+		t.Errorf("Expected exit code %d for a missing report, got %d", exitConfigError, code)
+	}
+}
+
+func TestApply_Clusters(t *testing.T) {
+	manifests, _ := writeApplyFixtures(t)
+	report := filepath.Join(t.TempDir(), "clusters.json")
+	content := `[
+  {"cluster": "prod-eu", "namespace": "production", "deployment": "web-app", "container": "nginx", "recommended_request_mb": 256, "recommended_limit_mb": 384},
+  {"cluster": "prod-us", "namespace": "production", "deployment": "web-app", "container": "nginx", "recommended_request_mb": 768, "recommended_limit_mb": 1024}
+]`
+	if err := os.WriteFile(report, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The manifests of one cluster cannot take the numbers of two
+	if code, _, _ := runCLI(t, "apply", "-dry-run", "-input", report, manifests); code != exitConfigError {
+		t.Errorf("Expected exit code %d for a report of several clusters, got %d", exitConfigError, code)
+	}
+
+	code, stdout, stderr := runCLI(t, "apply", "-dry-run", "-cluster", "prod-us", "-input", report, manifests)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "requests.memory 512Mi -> 768Mi") {
+		t.Errorf("Expected the prod-us request to be applied, got:\n%s", stdout)
+	}
+}
+
+func TestApply_HelmValues(t *testing.T) {
+	manifests, report := writeApplyFixtures(t)
+	values := "resources:\n  nginx:\n    requests:\n      memory: 512Mi\n"
+	if err := os.WriteFile(filepath.Join(manifests, "values-prod.yaml"), []byte(values), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCLI(t, "apply", "-input", report, manifests)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "HelmValues production/web-app container nginx: requests.memory 512Mi -> 256Mi") {
+		t.Errorf("Expected the values file change in the summary, got:\n%s", stdout)
+	}
+	content, _ := os.ReadFile(filepath.Join(manifests, "values-prod.yaml"))
+	if string(content) != "resources:\n  nginx:\n    requests:\n      memory: 256Mi\n" {
+		t.Errorf("Expected the values file to be rewritten, got:\n%s", content)
+	}
+
+	if code, _, _ := runCLI(t, "apply", "-input", report, "-helm-key-path", "resources.memory", manifests); code != exitConfigError {
+		t.Errorf("Expected exit code %d for an invalid key path, got %d", exitConfigError, code)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/binpack"
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// Output formats of the binpack command
+const (
+	binpackFormatText = "text"
+	binpackFormatJSON = "json"
+)
+
+// bytesPerGiB converts the packed bytes to the GiB the table shows
+const bytesPerGiB = 1024 * 1024 * 1024
+
+// newBinpackCommand returns the command simulating the nodes the recommendations free per node pool
+func newBinpackCommand() *command {
+	var input, format string
+	return &command{
+		name:       "binpack",
+		summary:    "Simulate how many nodes per node pool the recommended requests could drain",
+		withConfig: true,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.StringVar(&input, "input", "", "JSON or xlsx `report` written by recommend to simulate instead of generating the recommendations")
+			fs.StringVar(&format, "format", binpackFormatText, "output `format`: text or json")
+		},
+		run: func(ctx context.Context, cfg *config.Config, args []string, out io.Writer) int {
+			if len(args) > 0 {
+				log.Print("binpack takes no arguments")
+				return exitConfigError
+			}
+			if format != binpackFormatText && format != binpackFormatJSON {
+				log.Printf("unknown binpack format %q (supported: text, json)", format)
+				return exitConfigError
+			}
+
+			var recommendations []types.RecommendationResult
+			if input != "" {
+				var err error
+				if recommendations, err = readReport(input); err != nil {
+					log.Print(err)
+					return exitConfigError
+				}
+			} else {
+				var err error
+				if recommendations, err = generateRecommendations(ctx, cfg); err != nil {
+					log.Print(err)
+					return recommendExitCode(err)
+				}
+			}
+			return runBinpack(ctx, cfg, recommendations, format, out)
+		},
+	}
+}
+
+// runBinpack simulates every cluster of recommendations, or the selected
+// clusters when there are none, and writes the node pools in format
+func runBinpack(ctx context.Context, cfg *config.Config, recommendations []types.RecommendationResult, format string, out io.Writer) int {
+	profile, err := prometheus.LoadProfile(cfg.MetricsProfile)
+	if err != nil {
+		log.Print(err)
+		return exitConfigError
+	}
+	promClient := prometheus.NewClient(cfg.PrometheusURL, cfg.HTTPTimeout)
+	promClient.SetReplicaLabel(cfg.ReplicaLabel)
+
+	clusters := reportClusters(recommendations)
+	if len(clusters) == 0 {
+		if clusters, err = resolveClusters(ctx, cfg, promClient, profile); err != nil {
+			log.Print(err)
+			return exitRecommendFailed
+		}
+	}
+
+	var pools []binpack.PoolResult
+	for _, cluster := range clusters {
+		if cluster != "" {
+			log.Printf("Simulating the nodes of cluster %s", cluster)
+		}
+		simulator := binpack.New(promClient, profile)
+		simulator.SetCluster(cfg.ClusterLabel, cluster)
+		simulator.SetNodePoolLabel(cfg.NodePoolLabel)
+		results, err := simulator.Run(ctx, recommendations)
+		if err != nil {
+			log.Printf("Failed to simulate the nodes: %v", err)
+			return exitRecommendFailed
+		}
+		if len(results) == 0 {
+			log.Printf("No node found with %s", profile.NodeAllocatable)
+		}
+		pools = append(pools, results...)
+	}
+
+	if format == binpackFormatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(pools)
+	} else {
+		err = printPools(out, pools)
+	}
+	if err != nil {
+		log.Print(err)
+		return exitRecommendFailed
+	}
+	return exitOK
+}
+
+// reportClusters returns the distinct clusters of recommendations, sorted
+func reportClusters(recommendations []types.RecommendationResult) []string {
+	seen := make(map[string]bool)
+	var clusters []string
+	for _, rec := range recommendations {
+		if !seen[rec.Cluster] {
+			seen[rec.Cluster] = true
+			clusters = append(clusters, rec.Cluster)
+		}
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+// printPools writes a table of the node pools, then the nodes that could be
+// drained in total
+func printPools(w io.Writer, pools []binpack.PoolResult) error {
+	withCluster := false
+	for _, pool := range pools {
+		withCluster = withCluster || pool.Cluster != ""
+	}
+
+	var b strings.Builder
+	if withCluster {
+		fmt.Fprintf(&b, "%-16s ", "CLUSTER")
+	}
+	fmt.Fprintf(&b, "%-16s %6s %6s %12s %14s %16s %10s %12s %10s %14s\n", "POOL", "NODES", "PODS",
+		"ALLOC (GiB)", "REQ NOW (GiB)", "REQ AFTER (GiB)", "NODES NOW", "NODES AFTER", "DRAINABLE", "UNSCHEDULABLE")
+	drainable, nodes := 0, 0
+	for _, pool := range pools {
+		name := pool.Pool
+		if name == "" {
+			name = "-"
+		}
+		if withCluster {
+			fmt.Fprintf(&b, "%-16s ", pool.Cluster)
+		}
+		fmt.Fprintf(&b, "%-16s %6d %6d %12.2f %14.2f %16.2f %10d %12d %10d %14d\n", name, pool.Nodes, pool.Pods,
+			pool.AllocatableBytes/bytesPerGiB, pool.CurrentRequestBytes/bytesPerGiB, pool.RecommendedRequestBytes/bytesPerGiB,
+			pool.CurrentNodes, pool.RecommendedNodes, pool.Drainable, pool.Unschedulable)
+		drainable += pool.Drainable
+		nodes += pool.Nodes
+	}
+	fmt.Fprintf(&b, "\n%d of %d nodes could be drained with the recommended requests\n", drainable, nodes)
+	b.WriteString("Memory requests only: CPU, affinities, taints and disruption budgets are not simulated\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
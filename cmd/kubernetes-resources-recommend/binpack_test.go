@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/binpack"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// newNodesServer serves two 2 GiB nodes, each running a pod of web requesting 1.5 GiB
+func newNodesServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		var response string
+		switch {
+		case strings.Contains(query, "kube_node_status_allocatable"):
+			response = `{"data": {"result": [
+				{"metric": {"node": "node-1"}, "value": [1234567890, "2147483648"]},
+				{"metric": {"node": "node-2"}, "value": [1234567890, "2147483648"]}]}}`
+		case strings.Contains(query, "kube_pod_info"):
+			response = `{"data": {"result": [
+				{"metric": {"namespace": "shop", "pod": "web-12345-abcde", "node": "node-1"}, "value": [1234567890, "1"]},
+				{"metric": {"namespace": "shop", "pod": "web-12345-fghij", "node": "node-2"}, "value": [1234567890, "1"]}]}}`
+		case strings.Contains(query, "resource_requests"):
+			response = `{"data": {"result": [
+				{"metric": {"namespace": "shop", "pod": "web-12345-abcde", "container": "app"}, "value": [1234567890, "1610612736"]},
+				{"metric": {"namespace": "shop", "pod": "web-12345-fghij", "container": "app"}, "value": [1234567890, "1610612736"]}]}}`
+		default:
+			response = `{"data": {"result": []}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeBinpackReport writes a report recommending 512 MiB for shop/web/app
+func writeBinpackReport(t *testing.T) string {
+	t.Helper()
+	report := filepath.Join(t.TempDir(), "report.json")
+	content, err := json.Marshal([]types.RecommendationResult{{
+		Namespace: "shop", Deployment: "web", Container: "app",
+		RecommendedRequestMB: 537, RecommendedRequestBytes: 512 * 1024 * 1024,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(report, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestBinpack_Text(t *testing.T) {
+	server := newNodesServer(t)
+
+	code, stdout, stderr := runCLI(t, "binpack", "-prometheusUrl", server.URL, "-input", writeBinpackReport(t))
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	for _, expected := range []string{
+		"POOL              NODES   PODS  ALLOC (GiB)  REQ NOW (GiB)  REQ AFTER (GiB)  NODES NOW  NODES AFTER  DRAINABLE  UNSCHEDULABLE\n",
+		"-                     2      2         4.00           3.00             1.00          2            1          1              0\n",
+		"1 of 2 nodes could be drained with the recommended requests\n",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestBinpack_JSON(t *testing.T) {
+	server := newNodesServer(t)
+
+	code, stdout, stderr := runCLI(t, "binpack", "-prometheusUrl", server.URL, "-input", writeBinpackReport(t), "-format", "json")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	var pools []binpack.PoolResult
+	if err := json.Unmarshal([]byte(stdout), &pools); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(pools) != 1 || pools[0].Nodes != 2 || pools[0].CurrentNodes != 2 || pools[0].RecommendedNodes != 1 || pools[0].Drainable != 1 {
+		t.Errorf("Unexpected pools: %+v", pools)
+	}
+}
+
+func TestBinpack_InvalidFormat(t *testing.T) {
+	if code, _, _ := runCLI(t, "binpack", "-format", "yaml"); code != exitConfigError {
+		t.Errorf("Expected exit code %d, got %d", exitConfigError, code)
+	}
+}
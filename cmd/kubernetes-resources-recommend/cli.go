@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// programName is the name of the binary, used in help output and completion scripts
+const programName = "kubernetes-resources-recommend"
+
+// defaultCommand runs when the first argument is a flag or missing, keeping
+// the flag-only invocation of earlier releases working
+const defaultCommand = "recommend"
+
+// Exit codes of every command, each value used once so scripts can tell the
+// failures apart. The check command returns one per failure class.
+const (
+	exitOK                    = 0
+	exitConfigError           = 1 // invalid flags, arguments or configuration
+	exitPrometheusUnreachable = 2
+	exitMissingMetrics        = 3
+	exitLabelSchema           = 4
+	exitInsufficientRetention = 5
+	exitRecommendFailed       = 6
+	exitServeFailed           = 7
+	exitVerifyRegression      = 8
+)
+
+// exitCodes describes the exit codes listed by the help
+var exitCodes = []struct {
+	code    int
+	meaning string
+}{
+	{exitOK, "success"},
+	{exitConfigError, "invalid flags, arguments or configuration"},
+	{exitPrometheusUnreachable, "Prometheus is unreachable (check)"},
+	{exitMissingMetrics, "required metrics are missing (check)"},
+	{exitLabelSchema, "the metric labels do not match the profile (check)"},
+	{exitInsufficientRetention, "Prometheus retains less history than analysed (check)"},
+	{exitRecommendFailed, "recommendations cannot be generated or exported"},
+	{exitServeFailed, "the server cannot listen or stops with an error (serve)"},
+	{exitVerifyRegression, "a verified container regressed after the change (verify)"},
+}
+
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	aliases []string
+	args    string // synopsis of the positional arguments
+	summary string
+
+	// withConfig registers the shared configuration flags; the configuration
+	// is loaded and validated before run
+	withConfig bool
+
+	// setFlags registers the flags specific to the command, may be nil
+	setFlags func(fs *flag.FlagSet)
+
+	// completeArgs returns the values completing the positional arguments, may be nil
+	completeArgs func() []string
+
+	// run executes the command, writing its results to out, and returns the process exit code
+	run func(ctx context.Context, cfg *config.Config, args []string, out io.Writer) int
+}
+
+// commands returns every subcommand, in help order
+func commands() []*command {
+	return []*command{
+		newRecommendCommand(),
+		newCheckCommand(),
+		newApplyCommand(),
+		newServeCommand(),
+		newDaemonCommand(),
+		newHistoryCommand(),
+		newDiffCommand(),
+		newExplainCommand(),
+		newVerifyCommand(),
+		newBinpackCommand(),
+		newHelpCommand(),
+		newCompletionCommand(),
+	}
+}
+
+// findCommand returns the command registered under name or one of its aliases, nil if none
+func findCommand(cmds []*command, name string) *command {
+	for _, cmd := range cmds {
+		if cmd.name == name {
+			return cmd
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// run dispatches args to a subcommand and returns the process exit code
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	cmds := commands()
+
+	if len(args) > 0 && isHelpFlag(args[0]) {
+		printUsage(stdout, cmds)
+		return exitOK
+	}
+
+	name := defaultCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	cmd := findCommand(cmds, name)
+	if cmd == nil {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		printUsage(stderr, cmds)
+		return exitConfigError
+	}
+	return execute(ctx, cmd, args, stdout, stderr)
+}
+
+// isHelpFlag reports whether arg asks for help
+func isHelpFlag(arg string) bool {
+	switch arg {
+	case "-h", "-help", "--help":
+		return true
+	}
+	return false
+}
+
+// execute parses the flags of a command and runs it
+func execute(ctx context.Context, cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs, cfg := commandFlags(cmd)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { printCommandUsage(fs.Output(), cmd, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitConfigError
+	}
+
+	if cfg != nil {
+		if err := cfg.Load(fs); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitConfigError
+		}
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitConfigError
+		}
+	}
+
+	return cmd.run(ctx, cfg, fs.Args(), stdout)
+}
+
+// commandFlags returns an unparsed flag set with every flag of cmd registered,
+// and the configuration bound to it if the command uses one
+func commandFlags(cmd *command) (*flag.FlagSet, *config.Config) {
+	fs := flag.NewFlagSet(programName+" "+cmd.name, flag.ContinueOnError)
+
+	var cfg *config.Config
+	if cmd.withConfig {
+		cfg = config.RegisterFlags(fs)
+	}
+	if cmd.setFlags != nil {
+		cmd.setFlags(fs)
+	}
+	return fs, cfg
+}
+
+// printUsage writes the list of commands
+func printUsage(w io.Writer, cmds []*command) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [arguments]\n\n", programName)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range cmds {
+		summary := cmd.summary
+		if cmd.name == defaultCommand {
+			summary += " (default)"
+		}
+		if len(cmd.aliases) > 0 {
+			summary += fmt.Sprintf(" (alias: %s)", strings.Join(cmd.aliases, ", "))
+		}
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, summary)
+	}
+	fmt.Fprintln(w, "\nExit codes:")
+	for _, exit := range exitCodes {
+		fmt.Fprintf(w, "  %-3d %s\n", exit.code, exit.meaning)
+	}
+	fmt.Fprintf(w, "\nRun '%s help <command>' for the flags of a command.\n", programName)
+}
+
+// printCommandUsage writes the synopsis of cmd followed by its flags, grouped
+// like the configuration flags of every other command
+func printCommandUsage(w io.Writer, cmd *command, fs *flag.FlagSet) {
+	synopsis := programName + " " + cmd.name + " [flags]"
+	if cmd.args != "" {
+		synopsis += " " + cmd.args
+	}
+	fmt.Fprintf(w, "Usage: %s\n\n%s\n", synopsis, cmd.summary)
+
+	grouped := make(map[string]bool)
+	var groups []config.FlagGroup
+	if cmd.withConfig {
+		groups = config.FlagGroups()
+		for _, group := range groups {
+			for _, name := range group.Flags {
+				grouped[name] = true
+			}
+		}
+	}
+
+	// Flags of the command itself come first
+	var own []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if !grouped[f.Name] {
+			own = append(own, f.Name)
+		}
+	})
+	if len(own) > 0 {
+		groups = append([]config.FlagGroup{{Title: "Command", Flags: own}}, groups...)
+	}
+
+	for _, group := range groups {
+		fmt.Fprintf(w, "\n%s flags:\n", group.Title)
+		for _, name := range group.Flags {
+			if f := fs.Lookup(name); f != nil {
+				printFlag(w, f)
+			}
+		}
+	}
+	if cmd.withConfig {
+		fmt.Fprintf(w, "\nConfiguration flags can also be set by environment variables prefixed with %s, such as %sCOUNT_DAYS for -countDays.\n",
+			config.EnvPrefix, config.EnvPrefix)
+	}
+}
+
+// printFlag writes a flag in the layout of flag.PrintDefaults
+func printFlag(w io.Writer, f *flag.Flag) {
+	name, usage := flag.UnquoteUsage(f)
+	line := "  -" + f.Name
+	if name != "" {
+		line += " " + name
+	}
+	line += "\n    \t" + strings.ReplaceAll(usage, "\n", "\n    \t")
+
+	switch f.DefValue {
+	case "", "0", "false", "0s":
+	default:
+		if name == "string" {
+			line += fmt.Sprintf(" (default %q)", f.DefValue)
+		} else {
+			line += fmt.Sprintf(" (default %v)", f.DefValue)
+		}
+	}
+	fmt.Fprintln(w, line)
+}
+
+// newHelpCommand returns the command printing the help of the CLI or of one command
+func newHelpCommand() *command {
+	return &command{
+		name:    "help",
+		args:    "[command]",
+		summary: "Show the commands, or the flags of one command",
+		completeArgs: func() []string {
+			return commandNames(commands())
+		},
+		run: func(_ context.Context, _ *config.Config, args []string, w io.Writer) int {
+			cmds := commands()
+			if len(args) == 0 {
+				printUsage(w, cmds)
+				return exitOK
+			}
+
+			cmd := findCommand(cmds, args[0])
+			if cmd == nil {
+				log.Printf("unknown command %q", args[0])
+				printUsage(w, cmds)
+				return exitConfigError
+			}
+			fs, _ := commandFlags(cmd)
+			printCommandUsage(w, cmd, fs)
+			return exitOK
+		},
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// runCLI runs the CLI with args and returns the exit code, stdout and stderr
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestFindCommand(t *testing.T) {
+	cmds := commands()
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"recommend", "recommend"},
+		{"check", "check"},
+		{"doctor", "check"},
+		{"server", "serve"},
+		{"completion", "completion"},
+	}
+
+	for _, tt := range tests {
+		cmd := findCommand(cmds, tt.name)
+		if cmd == nil || cmd.name != tt.expected {
+			t.Errorf("Expected %s to resolve to %s, got %v", tt.name, tt.expected, cmd)
+		}
+	}
+	if findCommand(cmds, "unknown") != nil {
+		t.Error("Expected no command for an unknown name")
+	}
+}
+
+func TestRun_Help(t *testing.T) {
+	for _, args := range [][]string{{"help"}, {"-h"}, {"--help"}} {
+		code, stdout, _ := runCLI(t, args...)
+		if code != exitOK {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitOK, code)
+		}
+		for _, name := range []string{"recommend", "check", "help", "completion"} {
+			if !strings.Contains(stdout, "  "+name+" ") {
+				t.Errorf("%v: expected command %s in usage, got:\n%s", args, name, stdout)
+			}
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	seen := make(map[int]string)
+	for _, exit := range exitCodes {
+		if other, ok := seen[exit.code]; ok {
+			t.Errorf("Exit code %d means both %q and %q", exit.code, other, exit.meaning)
+		}
+		seen[exit.code] = exit.meaning
+	}
+
+	_, stdout, _ := runCLI(t, "help")
+	if !strings.Contains(stdout, "Exit codes:") || !strings.Contains(stdout, fmt.Sprintf("  %-3d %s", exitVerifyRegression, seen[exitVerifyRegression])) {
+		t.Errorf("Expected the exit codes in usage, got:\n%s", stdout)
+	}
+}
+
+func TestRun_CommandHelpGroupsFlags(t *testing.T) {
+	code, stdout, _ := runCLI(t, "help", "check")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d", exitOK, code)
+	}
+
+	// Groups are printed in order, each listing its flags
+	expected := []string{"Usage: kubernetes-resources-recommend check [flags]", "Prometheus flags:", "-prometheusUrl",
+		"Analysis flags:", "-countDays", "Configuration file flags:", "-config", "RESOURCES_RECOMMEND_COUNT_DAYS"}
+	position := 0
+	for _, s := range expected {
+		index := strings.Index(stdout[position:], s)
+		if index < 0 {
+			t.Fatalf("Expected %q after position %d in:\n%s", s, position, stdout)
+		}
+		position += index
+	}
+
+	// -h on the command prints the same help to stderr
+	code, _, stderr := runCLI(t, "check", "-h")
+	if code != exitOK || !strings.Contains(stderr, "Prometheus flags:") {
+		t.Errorf("Expected grouped help on stderr, got exit code %d and:\n%s", code, stderr)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"Unknown command", []string{"bogus"}, `unknown command "bogus"`},
+		{"Unknown flag", []string{"check", "-bogus"}, "flag provided but not defined: -bogus"},
+		{"Invalid configuration", []string{"check", "-countDays", "0"}, "invalid CountDays 0"},
+		{"Default command validates flags", []string{"-prometheusUrl", "ftp://prometheus"}, "scheme must be http or https"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runCLI(t, tt.args...)
+			if code != exitConfigError {
+				t.Errorf("Expected exit code %d, got %d", exitConfigError, code)
+			}
+			if !strings.Contains(stderr, tt.expected) {
+				t.Errorf("Expected stderr containing '%s', got '%s'", tt.expected, stderr)
+			}
+		})
+	}
+}
+
+func TestRun_Completion(t *testing.T) {
+	tests := []struct {
+		shell    string
+		expected []string
+	}{
+		{"bash", []string{"complete -F _kubernetes_resources_recommend kubernetes-resources-recommend", "check|doctor)", "-countDays"}},
+		{"zsh", []string{"bashcompinit", "complete -F _kubernetes_resources_recommend"}},
+		{"fish", []string{"-a check -d", "'__fish_seen_subcommand_from check doctor' -o countDays"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			code, stdout, _ := runCLI(t, "completion", tt.shell)
+			if code != exitOK {
+				t.Fatalf("Expected exit code %d, got %d", exitOK, code)
+			}
+			for _, s := range tt.expected {
+				if !strings.Contains(stdout, s) {
+					t.Errorf("Expected %q in the %s script, got:\n%s", s, tt.shell, stdout)
+				}
+			}
+		})
+	}
+
+	if code, _, _ := runCLI(t, "completion", "powershell"); code != exitConfigError {
+		t.Errorf("Expected exit code %d for an unsupported shell, got %d", exitConfigError, code)
+	}
+}
+
+func TestFishQuote(t *testing.T) {
+	if got := fishQuote(`it's a \ test`); got != `'it\'s a \\ test'` {
+		t.Errorf("Unexpected quoting: %s", got)
+	}
+}
+
+func TestOutputList_Set(t *testing.T) {
+	var outputs outputList
+	if err := outputs.Set("json=-, markdown"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := outputs.Set("csv=report.csv"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := outputList{
+		{format: "json", destination: "-"},
+		{format: "md"},
+		{format: "csv", destination: "report.csv"},
+	}
+	if len(outputs) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, outputs)
+	}
+	for i := range expected {
+		if outputs[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], outputs[i])
+		}
+	}
+	if outputs.String() != "json=-,md,csv=report.csv" {
+		t.Errorf("Unexpected string form: %s", outputs.String())
+	}
+
+	if err := outputs.Set("pdf"); err == nil {
+		t.Error("Expected error for an unknown format")
+	}
+}
+
+func TestOutput_Filename(t *testing.T) {
+	if name := (output{format: "md"}).filename("shop"); name != "shop-resource-recommend.md" {
+		t.Errorf("Unexpected default filename: %s", name)
+	}
+	if name := (output{format: "patch"}).filename("shop"); name != "shop-resource-recommend.patch.yaml" {
+		t.Errorf("Unexpected default patch filename: %s", name)
+	}
+	if name := (output{format: "helm"}).filename("shop"); name != "shop-resource-recommend.values.yaml" {
+		t.Errorf("Unexpected default helm filename: %s", name)
+	}
+	if name := (output{format: "json", destination: "-"}).filename("shop"); name != "-" {
+		t.Errorf("Unexpected stdout filename: %s", name)
+	}
+}
+
+func TestRecommend_InvalidHelmKeyPath(t *testing.T) {
+	code, _, _ := runCLI(t, "recommend", "-output", "helm", "-helm-key-path", "resources.{container}.memory")
+	if code != exitConfigError {
+		t.Errorf("Expected exit code %d, got %d", exitConfigError, code)
+	}
+}
+
+func TestRecommend_InvalidVPAUpdateMode(t *testing.T) {
+	code, _, _ := runCLI(t, "recommend", "-output", "vpa", "-vpa-update-mode", "sometimes")
+	if code != exitConfigError {
+		t.Errorf("Expected exit code %d, got %d", exitConfigError, code)
+	}
+}
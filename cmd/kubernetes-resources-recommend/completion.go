@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// completionShells lists the shells a completion script can be generated for
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// newCompletionCommand returns the command printing a shell completion script
+func newCompletionCommand() *command {
+	return &command{
+		name:    "completion",
+		args:    "bash|zsh|fish",
+		summary: "Print a shell completion script for the commands and their flags",
+		completeArgs: func() []string {
+			return completionShells
+		},
+		run: func(_ context.Context, _ *config.Config, args []string, out io.Writer) int {
+			if len(args) != 1 {
+				log.Printf("expected one shell: %s", strings.Join(completionShells, ", "))
+				return exitConfigError
+			}
+
+			cmds := commands()
+			switch args[0] {
+			case "bash":
+				writeBashCompletion(out, cmds)
+			case "zsh":
+				// zsh loads bash completion functions through bashcompinit
+				fmt.Fprintln(out, "autoload -U +X bashcompinit && bashcompinit")
+				writeBashCompletion(out, cmds)
+			case "fish":
+				writeFishCompletion(out, cmds)
+			default:
+				log.Printf("unsupported shell %q, expected one of: %s", args[0], strings.Join(completionShells, ", "))
+				return exitConfigError
+			}
+			return exitOK
+		},
+	}
+}
+
+// completionWords returns the words completing the arguments of cmd: its
+// positional argument values followed by its dash-prefixed flags
+func completionWords(cmd *command) []string {
+	var words []string
+	if cmd.completeArgs != nil {
+		words = append(words, cmd.completeArgs()...)
+	}
+	fs, _ := commandFlags(cmd)
+	fs.VisitAll(func(f *flag.Flag) { words = append(words, "-"+f.Name) })
+	return words
+}
+
+// commandNames returns the names and aliases of every command
+func commandNames(cmds []*command) []string {
+	var names []string
+	for _, cmd := range cmds {
+		names = append(names, cmd.name)
+		names = append(names, cmd.aliases...)
+	}
+	return names
+}
+
+// writeBashCompletion writes a bash completion function completing commands,
+// then the flags of the selected command
+func writeBashCompletion(w io.Writer, cmds []*command) {
+	function := "_" + strings.ReplaceAll(programName, "-", "_")
+
+	fmt.Fprintf(w, "%s() {\n", function)
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(w, `    if [[ ${COMP_CWORD} -eq 1 && ${cur} != -* ]]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"${cur}\"))\n", strings.Join(commandNames(cmds), " "))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, `    local command="${COMP_WORDS[1]}"`)
+	fmt.Fprintf(w, "    [[ ${command} == -* ]] && command=%s\n", defaultCommand)
+	fmt.Fprintln(w, `    case "${command}" in`)
+	for _, cmd := range cmds {
+		words := completionWords(cmd)
+		patterns := append([]string{cmd.name}, cmd.aliases...)
+		fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -W %q -- \"${cur}\")) ;;\n", strings.Join(patterns, "|"), strings.Join(words, " "))
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "complete -F %s %s\n", function, programName)
+}
+
+// writeFishCompletion writes fish completions for the commands and their flags
+func writeFishCompletion(w io.Writer, cmds []*command) {
+	names := strings.Join(commandNames(cmds), " ")
+	fmt.Fprintf(w, "complete -c %s -f\n", programName)
+
+	for _, cmd := range cmds {
+		fmt.Fprintf(w, "complete -c %s -n 'not __fish_seen_subcommand_from %s' -a %s -d %s\n",
+			programName, names, cmd.name, fishQuote(cmd.summary))
+	}
+
+	for _, cmd := range cmds {
+		condition := "__fish_seen_subcommand_from " + strings.Join(append([]string{cmd.name}, cmd.aliases...), " ")
+		if cmd.completeArgs != nil {
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s\n", programName, fishQuote(condition), fishQuote(strings.Join(cmd.completeArgs(), " ")))
+		}
+
+		fs, _ := commandFlags(cmd)
+		fs.VisitAll(func(f *flag.Flag) {
+			_, usage := flag.UnquoteUsage(f)
+			fmt.Fprintf(w, "complete -c %s -n %s -o %s -d %s\n", programName, fishQuote(condition), f.Name, fishQuote(usage))
+		})
+	}
+}
+
+// fishQuote quotes s as a single-quoted fish string
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
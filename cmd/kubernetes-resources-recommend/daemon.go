@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"kubernetes-resources-recommend/internal/daemon"
+	"kubernetes-resources-recommend/internal/history"
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// daemonOptions are the flags of the daemon command
+type daemonOptions struct {
+	interval   time.Duration
+	jitter     time.Duration
+	namespaces string
+	historyDir string
+	keep       int
+	threshold  string
+}
+
+// newDaemonCommand returns the command re-running the recommendations on a schedule
+func newDaemonCommand() *command {
+	var options daemonOptions
+	var historyFlags historyOptions
+	return &command{
+		name:       "daemon",
+		summary:    "Re-run the recommendations on a schedule and log what changed since the previous run",
+		withConfig: true,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.DurationVar(&options.interval, "interval", daemon.DefaultInterval, "`duration` between the starts of two cycles over the namespaces")
+			fs.DurationVar(&options.jitter, "jitter", daemon.DefaultJitter, "maximum random `duration` waited before the run of each namespace")
+			fs.StringVar(&options.namespaces, "namespaces", "", "comma-separated `namespaces` to analyse in turn (default the checkNamespace)")
+			fs.StringVar(&options.historyDir, "history-dir", "", "`directory` keeping the last runs across restarts, in memory only when empty")
+			fs.IntVar(&options.keep, "keep", 10, "`number` of runs kept per namespace")
+			fs.StringVar(&options.threshold, "change-threshold", "", "move above which a recommendation is reported as changed, in MB such as 64 or in percent such as 10% (default any move)")
+			historyFlags.register(fs)
+		},
+		run: func(ctx context.Context, cfg *config.Config, _ []string, _ io.Writer) int {
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			store, err := historyFlags.store()
+			if err != nil {
+				log.Print(err)
+				return exitConfigError
+			}
+			return runDaemon(ctx, cfg, options, store, daemon.GenerateFunc(recommendationGenerator(cfg)))
+		},
+	}
+}
+
+// runDaemon runs the recommendations made by generate on a schedule until ctx
+// is done, saving every run to store when set, and returns the process exit code
+func runDaemon(ctx context.Context, cfg *config.Config, options daemonOptions, store *history.Store, generate daemon.GenerateFunc) int {
+	if options.interval <= 0 || options.jitter < 0 {
+		log.Printf("invalid schedule: the interval must be positive and the jitter not negative")
+		return exitConfigError
+	}
+	if options.keep < 1 {
+		log.Printf("invalid keep %d, must be at least 1", options.keep)
+		return exitConfigError
+	}
+	namespaces, err := namespaceList(options.namespaces, cfg)
+	if err != nil {
+		log.Print(err)
+		return exitConfigError
+	}
+
+	threshold, err := history.ParseThreshold(options.threshold)
+	if err != nil {
+		log.Print(err)
+		return exitConfigError
+	}
+
+	ring := history.NewRing(options.keep)
+	if options.historyDir != "" {
+		if err := ring.SetDirectory(options.historyDir); err != nil {
+			log.Print(err)
+			return exitConfigError
+		}
+	}
+
+	d := daemon.New(generate, ring, namespaces)
+	d.SetInterval(options.interval)
+	d.SetJitter(options.jitter)
+	d.SetThreshold(threshold)
+	if store != nil {
+		d.SetStore(store, func(namespace string) *types.RecommendationConfig { return runConfig(cfg, namespace) })
+	}
+
+	log.Printf("Running recommendations of %s every %v", strings.Join(namespaces, ", "), options.interval)
+	d.Run(ctx)
+	log.Println("Shutting down")
+	return exitOK
+}
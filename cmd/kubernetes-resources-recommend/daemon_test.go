@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+func TestDaemon_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"Invalid namespace", []string{"daemon", "-namespaces", "Shop"}},
+		{"Zero interval", []string{"daemon", "-interval", "0s"}},
+		{"Negative jitter", []string{"daemon", "-jitter", "-1m"}},
+		{"Nothing kept", []string{"daemon", "-keep", "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _, _ := runCLI(t, tt.args...); code != exitConfigError {
+				t.Errorf("Expected exit code %d, got %d", exitConfigError, code)
+			}
+		})
+	}
+}
+
+func TestRunDaemon_History(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := 0
+	generate := func(_ context.Context, namespace string) ([]types.RecommendationResult, error) {
+		runs++
+		if runs == 3 {
+			cancel()
+		}
+		return []types.RecommendationResult{{Namespace: namespace, Deployment: "web", Container: "app", RecommendedRequestMB: int64(runs * 100)}}, nil
+	}
+
+	options := daemonOptions{interval: time.Millisecond, namespaces: "shop", historyDir: dir, keep: 2}
+	if code := runDaemon(ctx, &config.Config{CheckNamespace: "default"}, options, nil, generate); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d", exitOK, code)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "shop"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected the last 2 runs on disk, got %d", len(entries))
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/history"
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// Output formats of the diff command
+const (
+	diffFormatMarkdown = "markdown"
+	diffFormatJSON     = "json"
+)
+
+// newDiffCommand returns the command comparing the recommendations of two reports
+func newDiffCommand() *command {
+	var threshold, format string
+	return &command{
+		name:    "diff",
+		args:    "<old-report> <new-report>",
+		summary: "Compare two JSON or xlsx reports: added and removed containers and moved recommendations",
+		setFlags: func(fs *flag.FlagSet) {
+			fs.StringVar(&threshold, "threshold", "", "move above which a recommendation is reported as changed, in MB such as 64 or in percent such as 10% (default any move)")
+			fs.StringVar(&format, "format", diffFormatMarkdown, "output `format`: markdown or json")
+		},
+		run: func(_ context.Context, _ *config.Config, args []string, out io.Writer) int {
+			if len(args) != 2 {
+				log.Print("diff expects the old and the new report as its arguments")
+				return exitConfigError
+			}
+			if format != diffFormatMarkdown && format != diffFormatJSON {
+				log.Printf("unknown diff format %q (supported: markdown, json)", format)
+				return exitConfigError
+			}
+			limit, err := history.ParseThreshold(threshold)
+			if err != nil {
+				log.Print(err)
+				return exitConfigError
+			}
+
+			reports := make([][]types.RecommendationResult, len(args))
+			for i, path := range args {
+				if reports[i], err = readReport(path); err != nil {
+					log.Print(err)
+					return exitConfigError
+				}
+			}
+
+			changes := history.Compare(reports[0], reports[1], limit)
+			if format == diffFormatJSON {
+				encoder := json.NewEncoder(out)
+				encoder.SetIndent("", "  ")
+				err = encoder.Encode(changes)
+			} else {
+				_, err = io.WriteString(out, diffMarkdown(changes, limit))
+			}
+			if err != nil {
+				log.Print(err)
+				return exitRecommendFailed
+			}
+			return exitOK
+		},
+	}
+}
+
+// diffMarkdown renders changes as Markdown: a summary line, then a table each
+// for the changed, added and removed containers
+func diffMarkdown(changes history.Changes, threshold history.Threshold) string {
+	var b strings.Builder
+	b.WriteString("## Recommendation Changes\n\n")
+	fmt.Fprintf(&b, "%s (threshold %s).\n", changes.Summary(), threshold)
+
+	withCluster := false
+	for _, change := range changes.Changed {
+		withCluster = withCluster || change.Cluster != ""
+	}
+	for _, recs := range [][]types.RecommendationResult{changes.Added, changes.Removed} {
+		for _, rec := range recs {
+			withCluster = withCluster || rec.Cluster != ""
+		}
+	}
+	keyCells := func(key history.ContainerKey) []string {
+		cells := []string{key.Namespace, key.Deployment, key.Container}
+		if withCluster {
+			cells = append([]string{key.Cluster}, cells...)
+		}
+		return cells
+	}
+	keyHeaders := keyCells(history.ContainerKey{Cluster: "Cluster", Namespace: "Namespace", Deployment: "Deployment", Container: "Container"})
+	separators := func(headers []string, numeric int) []string {
+		cells := make([]string, len(headers))
+		for i := range cells {
+			cells[i] = "---"
+			if i >= len(headers)-numeric {
+				cells[i] = "---:"
+			}
+		}
+		return cells
+	}
+
+	if len(changes.Changed) > 0 {
+		b.WriteString("\n### Changed\n\n")
+		headers := append(append([]string{}, keyHeaders...),
+			"Request (MB)", "Request Delta (MB)", "Request Delta (%)", "Limit (MB)", "Limit Delta (MB)", "Limit Delta (%)")
+		exporter.WriteMarkdownRow(&b, headers)
+		exporter.WriteMarkdownRow(&b, separators(headers, 6))
+		for _, change := range changes.Changed {
+			exporter.WriteMarkdownRow(&b, append(keyCells(change.ContainerKey),
+				fmt.Sprintf("%d → %d", change.PreviousRequestMB, change.RequestMB),
+				fmt.Sprintf("%+d", change.RequestDeltaMB()), fmt.Sprintf("%+.1f%%", change.RequestDeltaPct()),
+				fmt.Sprintf("%d → %d", change.PreviousLimitMB, change.LimitMB),
+				fmt.Sprintf("%+d", change.LimitDeltaMB()), fmt.Sprintf("%+.1f%%", change.LimitDeltaPct())))
+		}
+	}
+
+	for _, section := range []struct {
+		title string
+		recs  []types.RecommendationResult
+	}{
+		{"Added", changes.Added},
+		{"Removed", changes.Removed},
+	} {
+		if len(section.recs) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section.title)
+		headers := append(append([]string{}, keyHeaders...), "Recommended Request (MB)", "Recommended Limit (MB)")
+		exporter.WriteMarkdownRow(&b, headers)
+		exporter.WriteMarkdownRow(&b, separators(headers, 2))
+		for _, rec := range section.recs {
+			exporter.WriteMarkdownRow(&b, append(keyCells(history.KeyOf(rec)), fmt.Sprint(rec.RecommendedRequestMB), fmt.Sprint(rec.RecommendedLimitMB)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/history"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// writeDiffFixtures writes an old xlsx report and a new JSON report, returning their paths
+func writeDiffFixtures(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.xlsx")
+	err := exporter.NewExcelExporter(old).Export([]types.RecommendationResult{
+		{Namespace: "production", Deployment: "web-app", Container: "nginx", RecommendedRequestMB: 256, RecommendedLimitMB: 384},
+		{Namespace: "production", Deployment: "api", Container: "app", RecommendedRequestMB: 500, RecommendedLimitMB: 750},
+		{Namespace: "production", Deployment: "legacy", Container: "app", RecommendedRequestMB: 128, RecommendedLimitMB: 192},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := filepath.Join(dir, "new.json")
+	report := `[
+  {"namespace": "production", "deployment": "web-app", "container": "nginx", "recommended_request_mb": 260, "recommended_limit_mb": 390},
+  {"namespace": "production", "deployment": "api", "container": "app", "recommended_request_mb": 400, "recommended_limit_mb": 600},
+  {"namespace": "production", "deployment": "worker", "container": "app", "recommended_request_mb": 64, "recommended_limit_mb": 96}
+]`
+	if err := os.WriteFile(current, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return old, current
+}
+
+func TestDiff_Markdown(t *testing.T) {
+	old, current := writeDiffFixtures(t)
+
+	code, stdout, stderr := runCLI(t, "diff", "-threshold", "5%", old, current)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	for _, expected := range []string{
+		"1 added, 1 removed, 1 changed (threshold 5%).",
+		"| Namespace | Deployment | Container | Request (MB) |",
+		"| production | api | app | 500 → 400 | -100 | -20.0% | 750 → 600 | -150 | -20.0% |",
+		"### Added\n\n| Namespace | Deployment | Container | Recommended Request (MB) | Recommended Limit (MB) |\n| --- | --- | --- | ---: | ---: |\n| production | worker | app | 64 | 96 |",
+		"### Removed\n\n",
+		"| production | legacy | app | 128 | 192 |",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout)
+		}
+	}
+	// web-app moved by less than 2%
+	if strings.Contains(stdout, "web-app") {
+		t.Errorf("Expected web-app below the threshold, got:\n%s", stdout)
+	}
+}
+
+func TestDiff_JSON(t *testing.T) {
+	old, current := writeDiffFixtures(t)
+
+	code, stdout, stderr := runCLI(t, "diff", "-format", "json", old, current)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	var changes history.Changes
+	if err := json.Unmarshal([]byte(stdout), &changes); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	// Without a threshold every move counts
+	if len(changes.Added) != 1 || len(changes.Removed) != 1 || len(changes.Changed) != 2 {
+		t.Fatalf("Unexpected changes: %+v", changes)
+	}
+	if changes.Changed[1].Deployment != "web-app" || changes.Changed[1].PreviousRequestMB != 256 || changes.Changed[1].RequestMB != 260 {
+		t.Errorf("Unexpected change: %+v", changes.Changed[1])
+	}
+}
+
+func TestDiff_Errors(t *testing.T) {
+	old, current := writeDiffFixtures(t)
+
+	for _, args := range [][]string{
+		{"diff", old},
+		{"diff", "-format", "yaml", old, current},
+		{"diff", "-threshold", "big", old, current},
+		{"diff", old, filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		if code, _, _ := runCLI(t, args...); code != exitConfigError {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitConfigError, code)
+		}
+	}
+}
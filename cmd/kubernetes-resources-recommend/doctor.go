@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// newCheckCommand returns the command diagnosing the Prometheus data backing the recommender
+func newCheckCommand() *command {
+	return &command{
+		name:       "check",
+		aliases:    []string{"doctor"},
+		summary:    "Check the metrics, label schema and retention of Prometheus",
+		withConfig: true,
+		run:        runDoctor,
+	}
+}
+
+// runDoctor diagnoses the Prometheus data backing the recommender and returns the process exit code
+func runDoctor(ctx context.Context, cfg *config.Config, _ []string, out io.Writer) int {
+	profile, err := prometheus.LoadProfile(cfg.MetricsProfile)
+	if err != nil {
+		log.Print(err)
+		return exitConfigError
+	}
+
+	promClient := prometheus.NewClient(cfg.PrometheusURL, cfg.HTTPTimeout)
+	promClient.SetReplicaLabel(cfg.ReplicaLabel)
+
+	clusters, err := resolveClusters(ctx, cfg, promClient, profile)
+	if err != nil {
+		log.Print(err)
+		return exitPrometheusUnreachable
+	}
+
+	// Report every cluster, exiting with the code of the first failure found
+	exitCode := exitOK
+	for i, cluster := range clusters {
+		metricsChecker := prometheus.NewMetricsChecker(promClient, cfg.CheckNamespace)
+		metricsChecker.SetProfile(profile)
+		metricsChecker.SetCluster(cfg.ClusterLabel, cluster)
+		report := metricsChecker.Diagnose(ctx, cfg.ForNamespace(cfg.CheckNamespace).CountDays)
+
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		printDiagnosticReport(out, report)
+
+		if failures := report.Failures(); len(failures) > 0 && exitCode == exitOK {
+			exitCode = doctorExitCode(failures[0])
+		}
+	}
+	return exitCode
+}
+
+// doctorExitCode maps a failure class to its process exit code
+func doctorExitCode(failure prometheus.FailureClass) int {
+	switch failure {
+	case prometheus.FailureUnreachable:
+		return exitPrometheusUnreachable
+	case prometheus.FailureMissingMetrics:
+		return exitMissingMetrics
+	case prometheus.FailureLabelSchema:
+		return exitLabelSchema
+	case prometheus.FailureRetention:
+		return exitInsufficientRetention
+	default:
+		return exitOK
+	}
+}
+
+// printDiagnosticReport writes a human readable diagnostic report
+func printDiagnosticReport(w io.Writer, report *prometheus.DiagnosticReport) {
+	if report.Cluster != "" {
+		fmt.Fprintf(w, "Cluster: %s\n", report.Cluster)
+	}
+	fmt.Fprintf(w, "Namespace: %s\n", report.Namespace)
+	fmt.Fprintf(w, "Metrics profile: %s\n", report.Profile)
+	if !report.Reachable {
+		fmt.Fprintf(w, "Prometheus: unreachable (%s)\n", report.Error)
+		return
+	}
+
+	fmt.Fprintln(w, "\nRequired metrics:")
+	for _, metric := range report.Metrics {
+		status := "ok"
+		if metric.SeriesCount == 0 {
+			status = "MISSING"
+		}
+		fmt.Fprintf(w, "  %-40s %8d series  %s\n", metric.Name, metric.SeriesCount, status)
+	}
+
+	fmt.Fprintf(w, "\nkube-state-metrics: %s\n", report.KubeStateMetrics)
+	fmt.Fprintf(w, "cadvisor labels:    %s\n", report.CadvisorLabels)
+
+	age := report.OldestSampleAge.String()
+	if report.LookbackExhausted {
+		age = ">= " + age
+	}
+	fmt.Fprintf(w, "oldest sample age:  %s (required %s)\n", age, report.RequiredRetention)
+
+	failures := report.Failures()
+	if len(failures) == 0 {
+		fmt.Fprintln(w, "\nAll checks passed")
+		return
+	}
+
+	names := make([]string, len(failures))
+	for i, failure := range failures {
+		names[i] = failure.String()
+	}
+	fmt.Fprintf(w, "\nFailed checks: %s\n", strings.Join(names, ", "))
+	if missing := report.MissingMetrics(); len(missing) > 0 {
+		fmt.Fprintf(w, "Missing metrics: %s\n", strings.Join(missing, ", "))
+	}
+	if report.SuggestedProfile != "" && report.SuggestedProfile != report.Profile {
+		fmt.Fprintf(w, "Detected schema matches the %q profile, try -metricsProfile=%s\n",
+			report.SuggestedProfile, report.SuggestedProfile)
+	}
+}
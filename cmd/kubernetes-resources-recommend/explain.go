@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/recommender"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// Output formats of the explain command
+const (
+	explainFormatText = "text"
+	explainFormatJSON = "json"
+)
+
+// explainTimeFormat renders the times of a trace
+const explainTimeFormat = "2006-01-02 15:04 MST"
+
+// newExplainCommand returns the command tracing the derivation of the recommendations of one deployment
+func newExplainCommand() *command {
+	var cluster, format string
+	return &command{
+		name:       "explain",
+		args:       "<deployment>",
+		summary:    "Trace every query and step behind the recommendations of one deployment",
+		withConfig: true,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.StringVar(&cluster, "cluster", "", "`cluster` of the deployment, required when -clusterLabel selects several")
+			fs.StringVar(&format, "format", explainFormatText, "output `format`: text or json")
+		},
+		run: func(ctx context.Context, cfg *config.Config, args []string, out io.Writer) int {
+			if len(args) != 1 {
+				log.Print("explain expects a deployment as its only argument")
+				return exitConfigError
+			}
+			if format != explainFormatText && format != explainFormatJSON {
+				log.Printf("unknown explain format %q (supported: text, json)", format)
+				return exitConfigError
+			}
+			return runExplain(ctx, cfg, args[0], cluster, format, out)
+		},
+	}
+}
+
+// runExplain traces the recommendations of deployment in the checked
+// namespace and writes the trace in format
+func runExplain(ctx context.Context, cfg *config.Config, deployment, cluster, format string, out io.Writer) int {
+	profile, err := prometheus.LoadProfile(cfg.MetricsProfile)
+	if err != nil {
+		log.Print(err)
+		return exitConfigError
+	}
+	promClient := prometheus.NewClient(cfg.PrometheusURL, cfg.HTTPTimeout)
+	promClient.SetReplicaLabel(cfg.ReplicaLabel)
+
+	if cluster == "" {
+		clusters, err := resolveClusters(ctx, cfg, promClient, profile)
+		if err != nil {
+			log.Print(err)
+			return exitRecommendFailed
+		}
+		if len(clusters) > 1 {
+			log.Printf("explain analyses one cluster, select one of %s with -cluster", strings.Join(clusters, ", "))
+			return exitConfigError
+		}
+		cluster = clusters[0]
+	}
+
+	log.Printf("Explaining the recommendations of %s/%s", cfg.CheckNamespace, deployment)
+	trace, err := recommender.NewRecommender(promClient, recommendationConfig(cfg, profile, cluster)).Explain(ctx, deployment)
+	if err != nil {
+		log.Print(err)
+		return exitRecommendFailed
+	}
+
+	if format == explainFormatJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(trace)
+	} else {
+		err = printTrace(out, trace)
+	}
+	if err != nil {
+		log.Print(err)
+		return exitRecommendFailed
+	}
+	return exitOK
+}
+
+// printTrace writes a readable trace: the settings, every query, the pods of
+// each hour, then the days and final steps of each container
+func printTrace(w io.Writer, trace *recommender.Trace) error {
+	var b strings.Builder
+	name := trace.Namespace + "/" + trace.Deployment
+	if trace.Cluster != "" {
+		name = trace.Cluster + "/" + name
+	}
+	fmt.Fprintf(&b, "Deployment: %s\n", name)
+	fmt.Fprintf(&b, "Window: %d days up to %s, P%g of each day\n", trace.CountDays, trace.Now.Format(explainTimeFormat), trace.Percentile)
+	fmt.Fprintf(&b, "Request bounds: %s to %s, limit multiplier %g\n",
+		boundMB(trace.MinRequestBytes), boundMB(trace.MaxRequestBytes), trace.LimitMultiplier)
+	if trace.Eligible {
+		b.WriteString("Eligible: yes\n")
+	} else {
+		b.WriteString("Eligible: no, a full run skips deployments younger than the window or without replicas\n")
+	}
+
+	fmt.Fprintf(&b, "\nQueries (%d):\n", len(trace.Queries))
+	for _, query := range trace.Queries {
+		fmt.Fprintf(&b, "  %s %s\n", queryTiming(query), query.PromQL)
+		if query.Error != "" {
+			fmt.Fprintf(&b, "    -> error: %s\n", query.Error)
+		} else {
+			fmt.Fprintf(&b, "    -> %d series\n", query.Series)
+		}
+	}
+
+	b.WriteString("\nHours:\n")
+	for _, hour := range trace.Hours {
+		fmt.Fprintf(&b, "  %s - %s  replicasets: %s  pods: %s", hour.Start.Format(explainTimeFormat), hour.End.Format("15:04"),
+			listOrNone(hour.ReplicaSets), listOrNone(hour.Pods))
+		if hour.Error != "" {
+			fmt.Fprintf(&b, "  (skipped: %s)", hour.Error)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(trace.Containers) == 0 {
+		b.WriteString("\nNo memory usage found, no recommendation\n")
+	}
+	for _, c := range trace.Containers {
+		rec := c.Recommendation
+		fmt.Fprintf(&b, "\nContainer %s\n", c.Container)
+		fmt.Fprintf(&b, "  %-4s %-20s %8s %6s %12s %8s %14s\n", "DAY", "END", "SAMPLES", "INDEX", "P (MB)", "WEIGHT", "WEIGHTED (MB)")
+		for _, day := range c.Days {
+			fmt.Fprintf(&b, "  %-4d %-20s %8d %6d %12.2f %8.4f %14.2f\n", day.Day, day.End.Format(explainTimeFormat),
+				day.Samples, day.PercentileIndex, toMB(day.PercentileBytes), day.Weight, toMB(day.WeightedBytes))
+		}
+		fmt.Fprintf(&b, "  Weighted sum:  %.2f MB (confidence %.0f%%)\n", toMB(c.WeightedSumBytes), rec.Confidence*100)
+		switch c.Clamp {
+		case "min":
+			fmt.Fprintf(&b, "  Clamped:       %.2f MB, raised to the minimum request\n", toMB(c.ClampedBytes))
+		case "max":
+			fmt.Fprintf(&b, "  Clamped:       %.2f MB, lowered to the maximum request\n", toMB(c.ClampedBytes))
+		default:
+			fmt.Fprintf(&b, "  Clamped:       %.2f MB, within the bounds\n", toMB(c.ClampedBytes))
+		}
+		fmt.Fprintf(&b, "  Request:       %d MB, rounded down from %.2f MB\n", rec.RecommendedRequestMB, toMB(rec.RecommendedRequestBytes))
+		fmt.Fprintf(&b, "  Limit:         %d MB, rounded down from %.2f MB x %g = %.2f MB\n", rec.RecommendedLimitMB,
+			toMB(rec.RecommendedRequestBytes), rec.MemoryLimitMultiplier, toMB(rec.RecommendedLimitBytes))
+		fmt.Fprintf(&b, "  Current:       request %d MB, limit %d MB\n", rec.CurrentRequestMB, rec.CurrentLimitMB)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// queryTiming describes the evaluation time or range of a query
+func queryTiming(query prometheus.QueryRecord) string {
+	switch {
+	case query.Kind == prometheus.QueryKindRange:
+		return fmt.Sprintf("[range %s - %s step %ds]", time.Unix(query.Start, 0).UTC().Format(explainTimeFormat),
+			time.Unix(query.End, 0).UTC().Format("15:04"), query.Step)
+	case query.Time != 0:
+		return fmt.Sprintf("[at %s]", time.Unix(query.Time, 0).UTC().Format(explainTimeFormat))
+	default:
+		return "[now]"
+	}
+}
+
+// boundMB renders a request bound, open when 0
+func boundMB(bytes float64) string {
+	if bytes == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%.0f MB", toMB(bytes))
+}
+
+// listOrNone joins names, none when empty
+func listOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// toMB converts bytes to MB
+func toMB(bytes float64) float64 {
+	return bytes / 1024 / 1024
+}
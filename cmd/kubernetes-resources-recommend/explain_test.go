@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/recommender"
+)
+
+func TestExplain_Text(t *testing.T) {
+	server := newPrometheusServer(t)
+
+	code, stdout, stderr := runCLI(t, "explain", "-prometheusUrl", server.URL, "-checkNamespace", "shop", "-countDays", "1", "web")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	for _, expected := range []string{
+		"Deployment: shop/web\n",
+		"Eligible: yes\n",
+		"Queries (75):\n",
+		`owner_name="web"`,
+		"replicasets: web-12345  pods: web-12345-abcde\n",
+		"Container app\n",
+		"  Weighted sum:  50.00 MB (confidence 100%)\n",
+		"  Clamped:       50.00 MB, within the bounds\n",
+		"  Request:       50 MB, rounded down from 50.00 MB\n",
+		"  Limit:         75 MB, rounded down from 50.00 MB x 1.5 = 75.00 MB\n",
+		"  Current:       request 256 MB, limit 512 MB\n",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestExplain_JSON(t *testing.T) {
+	server := newPrometheusServer(t)
+
+	code, stdout, stderr := runCLI(t, "explain", "-prometheusUrl", server.URL, "-countDays", "1", "-minRequestMB", "64", "-format", "json", "web")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	var trace recommender.Trace
+	if err := json.Unmarshal([]byte(stdout), &trace); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(trace.Hours) != 24 || len(trace.Containers) != 1 {
+		t.Fatalf("Unexpected trace: %+v", trace)
+	}
+	c := trace.Containers[0]
+	if c.Clamp != "min" || c.Recommendation.RecommendedRequestMB != 64 || c.Recommendation.RecommendedLimitMB != 96 {
+		t.Errorf("Expected the request raised to 64 MB, got %+v", c)
+	}
+}
+
+func TestExplain_Errors(t *testing.T) {
+	for _, args := range [][]string{
+		{"explain"},
+		{"explain", "web", "api"},
+		{"explain", "-format", "yaml", "web"},
+	} {
+		if code, _, _ := runCLI(t, args...); code != exitConfigError {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitConfigError, code)
+		}
+	}
+}
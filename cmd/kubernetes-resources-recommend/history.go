@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"kubernetes-resources-recommend/internal/history"
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// historyOptions are the flags saving runs to the history store
+type historyOptions struct {
+	db        string
+	retention string
+}
+
+// register adds the history flags to fs
+func (o *historyOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.db, "history-db", "", "database `file` saving every run, for the history command")
+	fs.StringVar(&o.retention, "history-retention", "", "`age` beyond which runs are deleted from the history, such as 90d or 720h (default keep every run)")
+}
+
+// store returns the history store of the flags, nil when runs are not saved
+func (o *historyOptions) store() (*history.Store, error) {
+	if o.db == "" {
+		return nil, nil
+	}
+	store := history.NewStore(o.db)
+	if o.retention != "" {
+		retention, err := parseAge(o.retention)
+		if err != nil {
+			return nil, fmt.Errorf("invalid history retention: %w", err)
+		}
+		store.SetRetention(retention)
+	}
+	return store, nil
+}
+
+// runConfig returns the recommender configuration of namespace saved with its runs
+func runConfig(cfg *config.Config, namespace string) *types.RecommendationConfig {
+	return recommendationConfig(cfg.ForNamespace(namespace), nil, "")
+}
+
+// parseAge parses a duration, accepting a number of days such as 90d
+func parseAge(value string) (time.Duration, error) {
+	var age time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if age, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("invalid age %q, must be positive", value)
+	}
+	return age, nil
+}
+
+// Output formats of the history command
+const (
+	historyFormatText = "text"
+	historyFormatJSON = "json"
+	historyFormatCSV  = "csv"
+)
+
+// newHistoryCommand returns the command querying the recommendations saved in the history store
+func newHistoryCommand() *command {
+	var db, since, prune, cluster, format string
+	return &command{
+		name:    "history",
+		args:    "<namespace>[/<deployment>[/<container>]]",
+		summary: "Show how the recommendations of containers moved across the saved runs",
+		setFlags: func(fs *flag.FlagSet) {
+			fs.StringVar(&db, "history-db", "", "database `file` written by 'recommend -history-db' or 'daemon -history-db'")
+			fs.StringVar(&since, "since", "", "show the runs of the last `age` only, such as 90d or 720h")
+			fs.StringVar(&prune, "prune", "", "delete the runs older than `age` before the query")
+			fs.StringVar(&cluster, "cluster", "", "show the containers of `cluster` only")
+			fs.StringVar(&format, "format", historyFormatText, "output `format`: text, json or csv")
+		},
+		run: func(_ context.Context, _ *config.Config, args []string, out io.Writer) int {
+			if db == "" {
+				log.Print("history requires -history-db")
+				return exitConfigError
+			}
+			if len(args) > 1 || (len(args) == 0 && prune == "") {
+				log.Print("history expects a namespace, deployment or container as its only argument")
+				return exitConfigError
+			}
+			if format != historyFormatText && format != historyFormatJSON && format != historyFormatCSV {
+				log.Printf("unknown history format %q (supported: text, json, csv)", format)
+				return exitConfigError
+			}
+			store := history.NewStore(db)
+
+			if prune != "" {
+				age, err := parseAge(prune)
+				if err != nil {
+					log.Print(err)
+					return exitConfigError
+				}
+				deleted, err := store.Prune(time.Now().Add(-age))
+				if err != nil {
+					log.Print(err)
+					return exitRecommendFailed
+				}
+				log.Printf("Deleted %d runs older than %s", deleted, prune)
+				if len(args) == 0 {
+					return exitOK
+				}
+			}
+
+			query, err := seriesQuery(args[0])
+			if err != nil {
+				log.Print(err)
+				return exitConfigError
+			}
+			query.Cluster = cluster
+			if since != "" {
+				age, err := parseAge(since)
+				if err != nil {
+					log.Print(err)
+					return exitConfigError
+				}
+				query.Since = time.Now().Add(-age)
+			}
+
+			series, err := store.Series(query)
+			if err != nil {
+				log.Print(err)
+				return exitRecommendFailed
+			}
+			if err := printHistory(out, series, format); err != nil {
+				log.Print(err)
+				return exitRecommendFailed
+			}
+			return exitOK
+		},
+	}
+}
+
+// seriesQuery parses namespace[/deployment[/container]]
+func seriesQuery(selector string) (history.SeriesQuery, error) {
+	parts := strings.Split(selector, "/")
+	if len(parts) > 3 || !config.ValidNamespace(parts[0]) {
+		return history.SeriesQuery{}, fmt.Errorf("invalid selector %q, expected <namespace>[/<deployment>[/<container>]]", selector)
+	}
+	query := history.SeriesQuery{Namespace: parts[0]}
+	if len(parts) > 1 {
+		query.Deployment = parts[1]
+	}
+	if len(parts) > 2 {
+		query.Container = parts[2]
+	}
+	return query, nil
+}
+
+// printHistory writes the series in format
+func printHistory(w io.Writer, series []history.Point, format string) error {
+	switch format {
+	case historyFormatJSON:
+		if series == nil {
+			series = []history.Point{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(series)
+	case historyFormatCSV:
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"Started", "Cluster", "Namespace", "Deployment", "Container",
+			"Current Request (MB)", "Recommended Request (MB)", "Current Limit (MB)", "Recommended Limit (MB)"})
+		for _, point := range series {
+			rec := point.Recommendation
+			writer.Write([]string{point.StartedAt.Format(time.RFC3339), rec.Cluster, rec.Namespace, rec.Deployment, rec.Container,
+				strconv.FormatInt(rec.CurrentRequestMB, 10), strconv.FormatInt(rec.RecommendedRequestMB, 10),
+				strconv.FormatInt(rec.CurrentLimitMB, 10), strconv.FormatInt(rec.RecommendedLimitMB, 10)})
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	if len(series) == 0 {
+		fmt.Fprintln(w, "No saved recommendations")
+		return nil
+	}
+	var current history.ContainerKey
+	for i, point := range series {
+		rec := point.Recommendation
+		if key := history.KeyOf(rec); i == 0 || key != current {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			current = key
+			fmt.Fprintln(w, key)
+			fmt.Fprintf(w, "  %-20s %12s %12s %12s %12s\n", "STARTED", "REQUEST", "RECOMMENDED", "LIMIT", "RECOMMENDED")
+		}
+		fmt.Fprintf(w, "  %-20s %9d MB %9d MB %9d MB %9d MB\n", point.StartedAt.Format("2006-01-02 15:04 MST"),
+			rec.CurrentRequestMB, rec.RecommendedRequestMB, rec.CurrentLimitMB, rec.RecommendedLimitMB)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/history"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// writeHistoryFixture saves three daily runs of checkout and returns the store file
+func writeHistoryFixture(t *testing.T) string {
+	t.Helper()
+	db := filepath.Join(t.TempDir(), "history.db")
+	store := history.NewStore(db)
+	for day := 0; day < 3; day++ {
+		started := time.Now().UTC().Add(-time.Duration(2-day) * 24 * time.Hour).Add(-time.Hour)
+		run := history.Run{Namespace: "checkout", StartedAt: started, FinishedAt: started, Recommendations: []types.RecommendationResult{
+			{Namespace: "checkout", Deployment: "api", Container: "app", CurrentRequestMB: 512, RecommendedRequestMB: int64(300 + day*10), CurrentLimitMB: 1024, RecommendedLimitMB: int64(450 + day*15)},
+			{Namespace: "checkout", Deployment: "worker", Container: "app", CurrentRequestMB: 256, RecommendedRequestMB: 128},
+		}}
+		if _, err := store.Save(run, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func TestHistory_Text(t *testing.T) {
+	db := writeHistoryFixture(t)
+
+	code, stdout, _ := runCLI(t, "history", "-history-db", db, "checkout/api")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d", exitOK, code)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 5 || lines[0] != "checkout/api/app" {
+		t.Fatalf("Unexpected output:\n%s", stdout)
+	}
+	if !strings.HasSuffix(lines[4], "512 MB       320 MB      1024 MB       480 MB") {
+		t.Errorf("Unexpected last run: %q", lines[4])
+	}
+}
+
+func TestHistory_JSONSince(t *testing.T) {
+	db := writeHistoryFixture(t)
+
+	code, stdout, _ := runCLI(t, "history", "-history-db", db, "-since", "2d", "-format", "json", "checkout")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d", exitOK, code)
+	}
+	var series []history.Point
+	if err := json.Unmarshal([]byte(stdout), &series); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	// The runs of the last 2 days, for both deployments
+	if len(series) != 4 || series[0].Recommendation.Deployment != "api" || series[2].Recommendation.Deployment != "worker" {
+		t.Errorf("Unexpected series: %+v", series)
+	}
+}
+
+func TestHistory_Prune(t *testing.T) {
+	db := writeHistoryFixture(t)
+
+	if code, _, _ := runCLI(t, "history", "-history-db", db, "-prune", "36h"); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d", exitOK, code)
+	}
+	runs, err := history.NewStore(db).Runs("")
+	if err != nil || len(runs) != 2 {
+		t.Errorf("Expected 2 runs left, got %d, %v", len(runs), err)
+	}
+}
+
+func TestHistory_Errors(t *testing.T) {
+	db := writeHistoryFixture(t)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"Missing database", []string{"history", "checkout"}, exitConfigError},
+		{"Missing selector", []string{"history", "-history-db", db}, exitConfigError},
+		{"Invalid selector", []string{"history", "-history-db", db, "Checkout/api"}, exitConfigError},
+		{"Invalid age", []string{"history", "-history-db", db, "-since", "a week", "checkout"}, exitConfigError},
+		{"Unknown format", []string{"history", "-history-db", db, "-format", "xml", "checkout"}, exitConfigError},
+		{"Missing store", []string{"history", "-history-db", db + ".missing", "checkout"}, exitRecommendFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _, _ := runCLI(t, tt.args...); code != tt.expected {
+				t.Errorf("Expected exit code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"90d", 90 * 24 * time.Hour, false},
+		{"720h", 720 * time.Hour, false},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		age, err := parseAge(tt.value)
+		if (err != nil) != tt.wantErr || age != tt.expected {
+			t.Errorf("parseAge(%q) = %v, %v", tt.value, age, err)
+		}
+	}
+}
@@ -4,71 +4,34 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"time"
+	"os"
 
-	"kubernetes-resources-recommend/internal/exporter"
 	"kubernetes-resources-recommend/internal/prometheus"
-	"kubernetes-resources-recommend/internal/recommender"
 	"kubernetes-resources-recommend/internal/types"
 	"kubernetes-resources-recommend/pkg/config"
 )
 
 func main() {
-	// Load configuration
-	cfg := config.LoadFromFlags()
-	if err := cfg.Validate(); err != nil {
-		log.Fatal(err)
-	}
-
-	start := time.Now()
-	log.Println("Starting Kubernetes resource recommendation")
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Initialize Prometheus client
-	promClient := prometheus.NewClient(cfg.PrometheusURL, cfg.HTTPTimeout)
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	// Check if required metrics are available
-	metricsChecker := prometheus.NewMetricsChecker(promClient, cfg.CheckNamespace)
-	if !metricsChecker.CheckRequiredMetrics(ctx) {
-		log.Fatal("Required metrics check failed")
+// resolveClusters returns the clusters to analyse. Without a cluster label
+// the whole Prometheus is a single, unnamed cluster.
+func resolveClusters(ctx context.Context, cfg *config.Config, client *prometheus.Client, profile *types.MetricsProfile) ([]string, error) {
+	if cfg.ClusterLabel == "" {
+		return []string{""}, nil
 	}
-
-	// Create recommendation configuration
-	recConfig := &types.RecommendationConfig{
-		Namespace:             cfg.CheckNamespace,
-		PrometheusURL:         cfg.PrometheusURL,
-		MemoryLimitMultiplier: cfg.MemoryLimitMultiplier,
-		CountDays:             cfg.CountDays,
-		WorkerCount:           cfg.WorkerCount,
+	if clusters := cfg.ClusterList(); len(clusters) > 0 {
+		return clusters, nil
 	}
 
-	// Initialize recommender
-	rec := recommender.NewRecommender(promClient, recConfig)
-
-	// Generate recommendations
-	log.Println("Generating memory recommendations...")
-	recommendations, err := rec.GenerateRecommendations(ctx)
+	clusters, err := prometheus.ListClusters(ctx, client, profile, cfg.ClusterLabel, cfg.CheckNamespace)
 	if err != nil {
-		log.Fatalf("Failed to generate recommendations: %v", err)
+		return nil, err
 	}
-
-	if len(recommendations) == 0 {
-		log.Println("No recommendations generated")
-		return
-	}
-
-	log.Printf("Generated %d recommendations", len(recommendations))
-
-	// Export to Excel
-	filename := fmt.Sprintf("%s-resource-recommend.xlsx", cfg.CheckNamespace)
-	excelExporter := exporter.NewExcelExporter(filename)
-
-	if err := excelExporter.Export(recommendations); err != nil {
-		log.Fatalf("Failed to export recommendations: %v", err)
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("no clusters found under label %q in namespace %s", cfg.ClusterLabel, cfg.CheckNamespace)
 	}
-
-	log.Printf("Recommendations exported to %s", filename)
-	log.Printf("Process completed in %v", time.Since(start))
+	log.Printf("Found %d clusters: %v", len(clusters), clusters)
+	return clusters, nil
 }
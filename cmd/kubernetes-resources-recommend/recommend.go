@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"kubernetes-resources-recommend/internal/backtest"
+	"kubernetes-resources-recommend/internal/cost"
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/history"
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/recommender"
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// newRecommendCommand returns the command generating and exporting memory recommendations
+func newRecommendCommand() *command {
+	var outputs outputList
+	var options exportOptions
+	var historyFlags historyOptions
+	var backtestFlags backtestOptions
+	var namespaces string
+	return &command{
+		name:       "recommend",
+		summary:    "Generate memory recommendations and export them to reports",
+		withConfig: true,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.Var(&outputs, "output", fmt.Sprintf("report `format[=file]` to write, repeatable or comma-separated; formats: %s. "+
+				"The file defaults to <namespace>-resource-recommend.<format>, the namespaces joined with _ when several are analysed, - writes to stdout "+
+				"and a directory receives one patch, helm or vpa file per deployment (default xlsx)", strings.Join(exporter.Formats(), ", ")))
+			fs.StringVar(&options.helmTemplate, "helm-key-path", exporter.DefaultHelmKeyPath,
+				"`template` of the helm values key of each memory quantity; placeholders: {cluster}, {namespace}, {deployment}, {container} and {kind}, which is requests or limits")
+			fs.BoolVar(&options.helmMerge, "helm-merge", false, "merge the helm values into the existing file, keeping its other keys")
+			fs.StringVar(&options.vpaUpdateMode, "vpa-update-mode", exporter.VPAUpdateModeOff,
+				"`mode` of the generated VPAs: Off, Initial, Recreate, InPlaceOrRecreate or Auto")
+			fs.StringVar(&namespaces, "namespaces", "",
+				"comma-separated `namespaces` to analyse into one report, with a sheet per namespace in xlsx (default the checkNamespace)")
+			historyFlags.register(fs)
+			backtestFlags.register(fs)
+		},
+		run: func(ctx context.Context, cfg *config.Config, _ []string, out io.Writer) int {
+			if len(outputs) == 0 {
+				outputs = outputList{{format: exporter.FormatExcel}}
+			}
+			if err := options.parse(); err != nil {
+				log.Print(err)
+				return exitConfigError
+			}
+			if err := backtestFlags.parse(); err != nil {
+				log.Print(err)
+				return exitConfigError
+			}
+			namespaceNames, err := namespaceList(namespaces, cfg)
+			if err != nil {
+				log.Print(err)
+				return exitConfigError
+			}
+			store, err := historyFlags.store()
+			if err != nil {
+				log.Print(err)
+				return exitConfigError
+			}
+			return runRecommend(ctx, cfg, namespaceNames, outputs, options, backtestFlags, store, out)
+		},
+	}
+}
+
+// output is a report format and the file it is written to
+type output struct {
+	format      string
+	destination string // empty for the default file name
+}
+
+// filename returns the file the report is written to, exporter.Stdout for standard output
+func (o output) filename(namespace string) string {
+	if o.destination != "" {
+		return o.destination
+	}
+	return fmt.Sprintf("%s-resource-recommend.%s", namespace, exporter.Extension(o.format))
+}
+
+// exportOptions configures the exporters of the formats that take options
+type exportOptions struct {
+	helmTemplate  string
+	helmKeyPath   exporter.HelmKeyPath
+	helmMerge     bool
+	vpaUpdateMode string
+}
+
+// parse validates the options given as flags
+func (o *exportOptions) parse() error {
+	keyPath, err := exporter.ParseHelmKeyPath(o.helmTemplate)
+	if err != nil {
+		return err
+	}
+	o.helmKeyPath = keyPath
+
+	o.vpaUpdateMode, err = exporter.ParseVPAUpdateMode(o.vpaUpdateMode)
+	return err
+}
+
+// configure applies the options to e, bounding VPA requests like the
+// recommendations of the analysed namespace
+func (o *exportOptions) configure(e exporter.Exporter, cfg *config.Config) {
+	switch e := e.(type) {
+	case *exporter.HelmValuesExporter:
+		e.SetKeyPath(o.helmKeyPath)
+		e.SetMerge(o.helmMerge)
+	case *exporter.VPAExporter:
+		nsCfg := cfg.ForNamespace(cfg.CheckNamespace)
+		e.SetUpdateMode(o.vpaUpdateMode)
+		e.SetRequestBounds(nsCfg.MinRequestMB, nsCfg.MaxRequestMB)
+	}
+}
+
+// backtestOptions are the flags replaying past usage against the recommendations
+type backtestOptions struct {
+	window     string
+	age        time.Duration
+	resolution time.Duration
+}
+
+// register adds the backtest flags to fs
+func (o *backtestOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.window, "backtest", "", "replay the per-pod usage of the last `age`, such as 7d or 72h, against the recommendations and report what would have exceeded them")
+	fs.DurationVar(&o.resolution, "backtest-resolution", backtest.DefaultResolution, "`duration` of each replayed sample, taken with max_over_time")
+}
+
+// parse validates the backtest window given as flag
+func (o *backtestOptions) parse() error {
+	if o.window == "" {
+		return nil
+	}
+	age, err := parseAge(o.window)
+	if err != nil {
+		return fmt.Errorf("invalid backtest window: %w", err)
+	}
+	o.age = age
+	return nil
+}
+
+// run sets the backtest of every recommendation, unless no window is set
+func (o *backtestOptions) run(ctx context.Context, cfg *config.Config, recommendations []types.RecommendationResult) error {
+	if o.age == 0 || len(recommendations) == 0 {
+		return nil
+	}
+	profile, err := prometheus.LoadProfile(cfg.MetricsProfile)
+	if err != nil {
+		return err
+	}
+	promClient := prometheus.NewClient(cfg.PrometheusURL, cfg.HTTPTimeout)
+	promClient.SetReplicaLabel(cfg.ReplicaLabel)
+
+	log.Printf("Backtesting %d recommendations over the last %s", len(recommendations), o.window)
+	b := backtest.New(promClient, profile, cfg.ClusterLabel, o.age)
+	b.SetResolution(o.resolution)
+	return b.Run(ctx, recommendations)
+}
+
+// outputList collects the -output flags
+type outputList []output
+
+// String implements flag.Value
+func (l *outputList) String() string {
+	specs := make([]string, len(*l))
+	for i, o := range *l {
+		specs[i] = o.format
+		if o.destination != "" {
+			specs[i] += "=" + o.destination
+		}
+	}
+	return strings.Join(specs, ",")
+}
+
+// Set implements flag.Value, appending every comma-separated format[=file]
+func (l *outputList) Set(value string) error {
+	for _, spec := range strings.Split(value, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		name, destination, _ := strings.Cut(spec, "=")
+		format, err := exporter.NormalizeFormat(name)
+		if err != nil {
+			return err
+		}
+		*l = append(*l, output{format: format, destination: strings.TrimSpace(destination)})
+	}
+	return nil
+}
+
+// runRecommend generates recommendations for every selected cluster of each
+// namespace, backtests them when asked, saves the run of each namespace to
+// store when set and exports the recommendations of all namespaces to each output
+func runRecommend(ctx context.Context, cfg *config.Config, namespaces []string, outputs []output, options exportOptions, backtests backtestOptions, store *history.Store, out io.Writer) int {
+	start := time.Now()
+	log.Println("Starting Kubernetes resource recommendation")
+
+	var recommendations []types.RecommendationResult
+	runs := make([]history.Run, len(namespaces))
+	counts := make([]int, len(namespaces))
+	for i, namespace := range namespaces {
+		if len(namespaces) > 1 {
+			log.Printf("Analysing namespace %s", namespace)
+		}
+		runs[i] = history.Run{Namespace: namespace, StartedAt: time.Now()}
+		nsRecommendations, err := generateRecommendations(ctx, cfg.ForNamespace(namespace))
+		if err != nil {
+			log.Print(err)
+			return recommendExitCode(err)
+		}
+		runs[i].FinishedAt = time.Now()
+		counts[i] = len(nsRecommendations)
+		recommendations = append(recommendations, nsRecommendations...)
+	}
+	if err := backtests.run(ctx, cfg, recommendations); err != nil {
+		log.Print(err)
+		return exitRecommendFailed
+	}
+
+	if store != nil {
+		offset := 0
+		for i, run := range runs {
+			// Saved after the backtest, which sets the recommendations in place
+			run.Recommendations = recommendations[offset : offset+counts[i]]
+			offset += counts[i]
+			id, err := store.Save(run, runConfig(cfg, run.Namespace))
+			if err != nil {
+				log.Print(err)
+				return exitRecommendFailed
+			}
+			log.Printf("Run %d saved to %s", id, store.GetFilename())
+		}
+	}
+
+	if len(recommendations) == 0 {
+		log.Println("No recommendations generated")
+		return exitOK
+	}
+
+	log.Printf("Generated %d recommendations", len(recommendations))
+
+	for _, o := range outputs {
+		filename := o.filename(strings.Join(namespaces, "_"))
+		reportExporter, err := exporter.New(o.format, filename, out)
+		if err != nil {
+			log.Print(err)
+			return exitConfigError
+		}
+		options.configure(reportExporter, cfg)
+		if err := reportExporter.Export(recommendations); err != nil {
+			log.Printf("Failed to export recommendations: %v", err)
+			return exitRecommendFailed
+		}
+
+		if filename == exporter.Stdout {
+			filename = "stdout"
+		}
+		log.Printf("Recommendations exported to %s as %s", filename, o.format)
+	}
+
+	log.Printf("Process completed in %v", time.Since(start))
+	return exitOK
+}
+
+// generateRecommendations checks the metrics of every selected cluster and
+// returns their recommendations, or the first failure
+func generateRecommendations(ctx context.Context, cfg *config.Config) ([]types.RecommendationResult, error) {
+	profile, err := prometheus.LoadProfile(cfg.MetricsProfile)
+	if err != nil {
+		return nil, &configurationError{err: err}
+	}
+
+	// Initialize Prometheus client
+	promClient := prometheus.NewClient(cfg.PrometheusURL, cfg.HTTPTimeout)
+	promClient.SetReplicaLabel(cfg.ReplicaLabel)
+
+	clusters, err := resolveClusters(ctx, cfg, promClient, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	var recommendations []types.RecommendationResult
+	for _, cluster := range clusters {
+		if cluster != "" {
+			log.Printf("Analysing cluster %s", cluster)
+		}
+
+		// Check if required metrics are available
+		metricsChecker := prometheus.NewMetricsChecker(promClient, cfg.CheckNamespace)
+		metricsChecker.SetProfile(profile)
+		metricsChecker.SetCluster(cfg.ClusterLabel, cluster)
+		if !metricsChecker.CheckRequiredMetrics(ctx) {
+			return nil, fmt.Errorf("required metrics check failed, run '%s check' for details", programName)
+		}
+
+		// Initialize recommender
+		rec := recommender.NewRecommender(promClient, recommendationConfig(cfg, profile, cluster))
+
+		// Generate recommendations
+		log.Println("Generating memory recommendations...")
+		clusterRecommendations, err := rec.GenerateRecommendations(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recommendations: %w", err)
+		}
+		recommendations = append(recommendations, clusterRecommendations...)
+	}
+
+	if err := estimateCosts(ctx, cfg, promClient, profile, recommendations); err != nil {
+		return nil, fmt.Errorf("failed to estimate costs: %w", err)
+	}
+	return recommendations, nil
+}
+
+// configurationError is a failure of generateRecommendations caused by the
+// configuration rather than by Prometheus
+type configurationError struct {
+	err error
+}
+
+func (e *configurationError) Error() string { return e.err.Error() }
+func (e *configurationError) Unwrap() error { return e.err }
+
+// recommendExitCode maps an error of generateRecommendations to the process exit code
+func recommendExitCode(err error) int {
+	var configErr *configurationError
+	if errors.As(err, &configErr) {
+		return exitConfigError
+	}
+	return exitRecommendFailed
+}
+
+// estimateCosts prices the recommendations with the memory prices of cfg,
+// read from the pricing API when one is set; nothing is priced without prices
+func estimateCosts(ctx context.Context, cfg *config.Config, promClient *prometheus.Client, profile *types.MetricsProfile, recommendations []types.RecommendationResult) error {
+	prices := &cost.Prices{
+		Currency:   cfg.Currency,
+		Default:    cfg.MemoryPrice,
+		Namespaces: cfg.NamespacePrices(),
+		NodePools:  cfg.NodePoolPrices,
+		Clusters:   cfg.ClusterPrices,
+	}
+	if cfg.PricesURL != "" {
+		if err := cost.FetchPrices(ctx, cfg.PricesURL, cfg.HTTPTimeout, prices); err != nil {
+			return err
+		}
+	}
+	if !prices.Priced() || len(recommendations) == 0 {
+		return nil
+	}
+
+	log.Printf("Estimating the cost of %d recommendations in %s", len(recommendations), prices.Currency)
+	estimator := cost.New(promClient, profile, prices)
+	estimator.SetClusterLabel(cfg.ClusterLabel)
+	estimator.SetNodePoolLabel(cfg.NodePoolLabel)
+	return estimator.Run(ctx, recommendations)
+}
+
+// recommendationConfig builds the recommender configuration of a cluster,
+// applying the overrides of the analysed namespace
+func recommendationConfig(cfg *config.Config, profile *types.MetricsProfile, cluster string) *types.RecommendationConfig {
+	nsCfg := cfg.ForNamespace(cfg.CheckNamespace)
+	return &types.RecommendationConfig{
+		Namespace:             nsCfg.CheckNamespace,
+		PrometheusURL:         nsCfg.PrometheusURL,
+		MemoryLimitMultiplier: nsCfg.MemoryLimitMultiplier,
+		CountDays:             nsCfg.CountDays,
+		WorkerCount:           nsCfg.WorkerCount,
+		Percentile:            nsCfg.Percentile,
+		MinRequestMB:          nsCfg.MinRequestMB,
+		MaxRequestMB:          nsCfg.MaxRequestMB,
+		Profile:               profile,
+		ClusterLabel:          cfg.ClusterLabel,
+		Cluster:               cluster,
+	}
+}
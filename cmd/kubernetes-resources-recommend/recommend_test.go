@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// newPrometheusServer serves every required metric for a deployment web with
+// one pod whose app container uses 100 MiB, requesting 256 MiB and limited to 512 MiB
+func newPrometheusServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		var response string
+		switch {
+		case strings.Contains(query, "kube_deployment_created"):
+			response = `{"data": {"result": [{"metric": {"deployment": "web"}, "value": [1234567890, "1"]}]}}`
+		case strings.Contains(query, "kube_replicaset_owner"):
+			response = `{"data": {"result": [{"metric": {"replicaset": "web-12345"}, "values": [["1234567890", "1"]]}]}}`
+		case strings.Contains(query, "kube_pod_owner"):
+			response = `{"data": {"result": [{"metric": {"pod": "web-12345-abcde"}, "values": [["1234567890", "1"]]}]}}`
+		case strings.Contains(query, "kube_pod_status_reason"):
+			response = `{"data": {"result": []}}`
+		case strings.Contains(query, "max_over_time"):
+			start := r.URL.Query().Get("start")
+			response = `{"data": {"result": [{"metric": {"pod": "web-12345-abcde"}, "values": [[` + start + `, "104857600"]]}]}}`
+		case strings.Contains(query, "container_memory"):
+			response = `{"data": {"result": [{"metric": {"container": "app"}, "value": [1234567890, "104857600"]}]}}`
+		case strings.Contains(query, "resource_requests"):
+			response = `{"data": {"result": [{"metric": {"container": "app"}, "value": [1234567890, "268435456"]}]}}`
+		case strings.Contains(query, "resource_limits"):
+			response = `{"data": {"result": [{"metric": {"container": "app"}, "value": [1234567890, "536870912"]}]}}`
+		case strings.Contains(query, "kube_deployment_spec_replicas"):
+			response = `{"data": {"result": [{"metric": {"deployment": "web"}, "value": [1234567890, "1"]}]}}`
+		default:
+			response = `{"data": {"result": []}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRecommend_Backtest(t *testing.T) {
+	server := newPrometheusServer(t)
+
+	code, stdout, stderr := runCLI(t, "recommend", "-prometheusUrl", server.URL, "-countDays", "1",
+		"-backtest", "2h", "-backtest-resolution", "5m", "-output", "json=-")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	var recommendations []types.RecommendationResult
+	if err := json.Unmarshal([]byte(stdout), &recommendations); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(recommendations) != 1 || recommendations[0].Backtest == nil {
+		t.Fatalf("Expected a backtested recommendation, got %+v", recommendations)
+	}
+
+	// The 100 MiB sample exceeds the request of 50 MB and the limit of 75 MB
+	result := recommendations[0].Backtest
+	if result.Resolution != 300 || result.Pods != 1 || result.PodsOverLimit != 1 || result.MinutesOverLimit != 5 ||
+		len(result.Peaks) != 1 || result.Peaks[0].Pod != "web-12345-abcde" || !result.Peaks[0].OverLimit {
+		t.Errorf("Unexpected backtest: %+v", result)
+	}
+}
+
+func TestRecommend_InvalidBacktest(t *testing.T) {
+	if code, _, _ := runCLI(t, "recommend", "-backtest", "soon"); code != exitConfigError {
+		t.Errorf("Expected exit code %d, got %d", exitConfigError, code)
+	}
+}
+
+func TestRecommend_Namespaces(t *testing.T) {
+	server := newPrometheusServer(t)
+	report := filepath.Join(t.TempDir(), "report.xlsx")
+
+	code, _, stderr := runCLI(t, "recommend", "-prometheusUrl", server.URL, "-countDays", "1",
+		"-namespaces", "shop, payments", "-output", "xlsx="+report)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+
+	f, err := excelize.OpenFile(report)
+	if err != nil {
+		t.Fatalf("Failed to open the workbook: %v", err)
+	}
+	defer f.Close()
+	sheets := f.GetSheetList()
+	for _, sheet := range []string{"shop", "payments", "Namespace Summary"} {
+		if !slices.Contains(sheets, sheet) {
+			t.Errorf("Expected sheet %q in one workbook, got %v", sheet, sheets)
+		}
+	}
+	rows, err := f.GetRows("payments")
+	if err != nil || len(rows) < 2 || !slices.Contains(rows[1], "web") {
+		t.Errorf("Expected the deployment web on the payments sheet, got %v (%v)", rows, err)
+	}
+}
+
+func TestRecommend_InvalidNamespaces(t *testing.T) {
+	if code, _, _ := runCLI(t, "recommend", "-namespaces", "shop,Not_Valid"); code != exitConfigError {
+		t.Errorf("Expected exit code %d, got %d", exitConfigError, code)
+	}
+}
+
+func TestRecommend_Costs(t *testing.T) {
+	server := newPrometheusServer(t)
+
+	code, stdout, stderr := runCLI(t, "recommend", "-prometheusUrl", server.URL, "-countDays", "1",
+		"-memoryPrice", "0.04", "-currency", "EUR", "-output", "json=-")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	var recommendations []types.RecommendationResult
+	if err := json.Unmarshal([]byte(stdout), &recommendations); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(recommendations) != 1 || recommendations[0].Cost == nil {
+		t.Fatalf("Expected a priced recommendation, got %+v", recommendations)
+	}
+
+	// One replica requesting 256 MiB, then 50 MiB, at 0.04 per GiB-hour
+	expected := types.Cost{Currency: "EUR", PricePerGiBHour: 0.04, PriceSource: "default", Replicas: 1,
+		CurrentMonthly: 7.3, RecommendedMonthly: 1.43, AnnualSavings: 70.49}
+	if *recommendations[0].Cost != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *recommendations[0].Cost)
+	}
+}
+
+func TestRecommend_CostsFromPricingAPI(t *testing.T) {
+	server := newPrometheusServer(t)
+	pricing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code": 200, "data": {"RAM": "0.08", "currencyCode": "GBP"}}`))
+	}))
+	t.Cleanup(pricing.Close)
+
+	code, stdout, stderr := runCLI(t, "recommend", "-prometheusUrl", server.URL, "-countDays", "1",
+		"-pricesUrl", pricing.URL, "-output", "json=-")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	var recommendations []types.RecommendationResult
+	if err := json.Unmarshal([]byte(stdout), &recommendations); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if c := recommendations[0].Cost; c == nil || c.Currency != "GBP" || c.PriceSource != "api" || c.CurrentMonthly != 14.6 {
+		t.Errorf("Expected the price of the pricing API, got %+v", c)
+	}
+
+	pricing.Close()
+	if code, _, _ := runCLI(t, "recommend", "-prometheusUrl", server.URL, "-countDays", "1", "-pricesUrl", pricing.URL, "-output", "json=-"); code != exitRecommendFailed {
+		t.Errorf("Expected exit code %d with the pricing API down, got %d", exitRecommendFailed, code)
+	}
+}
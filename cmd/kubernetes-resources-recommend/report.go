@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// readReport loads the recommendations of a report written by 'recommend',
+// as JSON or as an Excel workbook when path ends in .xlsx
+func readReport(path string) ([]types.RecommendationResult, error) {
+	if strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		return exporter.ReadExcel(path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+
+	var recommendations []types.RecommendationResult
+	if err := json.Unmarshal(content, &recommendations); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return recommendations, nil
+}
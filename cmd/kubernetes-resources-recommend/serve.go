@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"kubernetes-resources-recommend/internal/server"
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// shutdownTimeout bounds the time given to in-flight requests on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// serveOptions are the flags of the serve command
+type serveOptions struct {
+	listen     string
+	interval   time.Duration
+	namespaces string
+}
+
+// newServeCommand returns the command serving recommendations over a REST API
+func newServeCommand() *command {
+	var options serveOptions
+	return &command{
+		name:       "serve",
+		aliases:    []string{"server"},
+		summary:    "Serve recommendations over a REST API, generated on demand or on a schedule",
+		withConfig: true,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.StringVar(&options.listen, "listen", ":8080", "`address` the HTTP server listens on")
+			fs.DurationVar(&options.interval, "interval", 0,
+				"refresh the recommendations of the scheduled namespaces every `duration`, 0 generates them on demand only")
+			fs.StringVar(&options.namespaces, "namespaces", "",
+				"comma-separated `namespaces` served and refreshed on the schedule, other namespaces are answered with 404 (default the checkNamespace)")
+		},
+		run: func(ctx context.Context, cfg *config.Config, _ []string, _ io.Writer) int {
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runServe(ctx, cfg, options, recommendationGenerator(cfg))
+		},
+	}
+}
+
+// namespaceList returns the namespaces of a comma-separated list, the
+// checkNamespace when the list is empty
+func namespaceList(list string, cfg *config.Config) ([]string, error) {
+	var namespaces []string
+	for _, namespace := range strings.Split(list, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" {
+			continue
+		}
+		if !config.ValidNamespace(namespace) {
+			return nil, &config.InvalidNamespaceError{Namespace: namespace}
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	if len(namespaces) == 0 {
+		namespaces = []string{cfg.CheckNamespace}
+	}
+	return namespaces, nil
+}
+
+// recommendationGenerator returns the generator of the recommendations of a
+// namespace, applying its overrides of cfg
+func recommendationGenerator(cfg *config.Config) server.GenerateFunc {
+	return func(ctx context.Context, namespace string) ([]types.RecommendationResult, error) {
+		return generateRecommendations(ctx, cfg.ForNamespace(namespace))
+	}
+}
+
+// runServe serves the recommendations made by generate until ctx is done and
+// returns the process exit code
+func runServe(ctx context.Context, cfg *config.Config, options serveOptions, generate server.GenerateFunc) int {
+	if options.interval < 0 {
+		log.Printf("invalid interval %v, must not be negative", options.interval)
+		return exitConfigError
+	}
+	namespaces, err := namespaceList(options.namespaces, cfg)
+	if err != nil {
+		log.Print(err)
+		return exitConfigError
+	}
+
+	listener, err := net.Listen("tcp", options.listen)
+	if err != nil {
+		log.Printf("Failed to listen on %s: %v", options.listen, err)
+		return exitServeFailed
+	}
+
+	srv := server.New(generate)
+	srv.SetContext(ctx)
+	srv.SetNamespaces(namespaces)
+	httpServer := &http.Server{Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	if options.interval > 0 {
+		log.Printf("Refreshing recommendations of %s every %v", strings.Join(namespaces, ", "), options.interval)
+		go srv.Schedule(ctx, namespaces, options.interval)
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- httpServer.Serve(listener) }()
+	log.Printf("Serving recommendations on %s", listener.Addr())
+
+	select {
+	case err := <-errs:
+		log.Printf("Server stopped: %v", err)
+		return exitServeFailed
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Failed to shut down: %v", err)
+		return exitServeFailed
+	}
+	return exitOK
+}
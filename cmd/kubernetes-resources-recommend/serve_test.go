@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+func TestNamespaceList(t *testing.T) {
+	cfg := &config.Config{CheckNamespace: "default"}
+
+	namespaces, err := namespaceList("", cfg)
+	if err != nil || len(namespaces) != 1 || namespaces[0] != "default" {
+		t.Errorf("Expected the checkNamespace by default, got %v, %v", namespaces, err)
+	}
+
+	namespaces, err = namespaceList("shop, billing,", cfg)
+	if err != nil || len(namespaces) != 2 || namespaces[0] != "shop" || namespaces[1] != "billing" {
+		t.Errorf("Expected shop and billing, got %v, %v", namespaces, err)
+	}
+
+	if _, err := namespaceList("shop,Billing", cfg); err == nil {
+		t.Error("Expected an error for an invalid namespace")
+	}
+}
+
+func TestRecommendationGenerator_Errors(t *testing.T) {
+	prom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "success", "data": {"result": []}}`))
+	}))
+	defer prom.Close()
+
+	tests := []struct {
+		name     string
+		profile  string
+		message  string
+		expected int
+	}{
+		{"Unknown metrics profile", "unknown", "unknown metrics profile", exitConfigError},
+		{"Missing metrics", "", "required metrics check failed", exitRecommendFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{CheckNamespace: "default", PrometheusURL: prom.URL, HTTPTimeout: time.Second, WorkerCount: 1, MetricsProfile: tt.profile}
+			_, err := recommendationGenerator(cfg)(context.Background(), "shop")
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Fatalf("Expected an error about %q, got %v", tt.message, err)
+			}
+			if code := recommendExitCode(err); code != tt.expected {
+				t.Errorf("Expected exit code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestServe_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"Invalid namespace", []string{"serve", "-namespaces", "Shop"}, exitConfigError},
+		{"Negative interval", []string{"serve", "-interval", "-1m"}, exitConfigError},
+		{"Invalid address", []string{"serve", "-listen", "256.0.0.1:http"}, exitServeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _, _ := runCLI(t, tt.args...); code != tt.expected {
+				t.Errorf("Expected exit code %d, got %d", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestRunServe_ScheduleAndShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var runs int32
+	generate := func(_ context.Context, namespace string) ([]types.RecommendationResult, error) {
+		if atomic.AddInt32(&runs, 1) == 2 {
+			cancel()
+		}
+		return nil, nil
+	}
+
+	options := serveOptions{listen: address, interval: time.Hour, namespaces: "shop,billing"}
+	done := make(chan int)
+	go func() { done <- runServe(ctx, &config.Config{CheckNamespace: "default"}, options, generate) }()
+
+	select {
+	case code := <-done:
+		if code != exitOK {
+			t.Errorf("Expected exit code %d, got %d", exitOK, code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not shut down")
+	}
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Errorf("Expected both scheduled namespaces to run, got %d runs", n)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/internal/verify"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// verifyTimeFormat renders the change time of a verification
+const verifyTimeFormat = "2006-01-02 15:04 MST"
+
+// workload is a deployment of a cluster and namespace
+type workload struct {
+	cluster    string
+	namespace  string
+	deployment string
+}
+
+// newVerifyCommand returns the command comparing deployments before and after a change
+func newVerifyCommand() *command {
+	var at, window, input, cluster string
+	var outputs outputList
+	return &command{
+		name:       "verify",
+		args:       "[deployment...]",
+		summary:    "Compare usage, OOM kills, restarts and evictions before and after a change",
+		withConfig: true,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.StringVar(&at, "at", "", "`time` of the change, RFC 3339 such as 2026-10-01T12:00:00Z or unix seconds (required)")
+			fs.StringVar(&window, "window", "7d", "`age` of the windows compared before and after the change, such as 7d or 72h, shortened to the time elapsed since")
+			fs.StringVar(&input, "input", "", "JSON or xlsx `report` written by recommend; its deployments are verified when none is given and the findings are added to it")
+			fs.StringVar(&cluster, "cluster", "", "`cluster` of the given deployments, required when -clusterLabel selects several")
+			fs.Var(&outputs, "output", fmt.Sprintf("report `format[=file]` receiving the findings, repeatable or comma-separated; formats: %s",
+				strings.Join(exporter.Formats(), ", ")))
+		},
+		run: func(ctx context.Context, cfg *config.Config, args []string, out io.Writer) int {
+			changedAt, err := parseChangeTime(at)
+			if err != nil {
+				log.Print(err)
+				return exitConfigError
+			}
+			age, err := parseAge(window)
+			if err != nil {
+				log.Printf("invalid verify window: %v", err)
+				return exitConfigError
+			}
+			var recommendations []types.RecommendationResult
+			if input != "" {
+				if recommendations, err = readReport(input); err != nil {
+					log.Print(err)
+					return exitConfigError
+				}
+			}
+			if len(args) == 0 && input == "" {
+				log.Print("verify expects deployments as arguments or a report given with -input")
+				return exitConfigError
+			}
+			return runVerify(ctx, cfg, changedAt, age, args, cluster, recommendations, outputs, out)
+		},
+	}
+}
+
+// parseChangeTime parses the -at flag, as RFC 3339 or unix seconds
+func parseChangeTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("verify needs the time of the change given with -at")
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	changedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid change time %q, expected RFC 3339 or unix seconds", value)
+	}
+	return changedAt, nil
+}
+
+// runVerify verifies the given deployments of the checked namespace, or the
+// deployments of recommendations when none is given, prints the findings and
+// exports the recommendations with them to each output
+func runVerify(ctx context.Context, cfg *config.Config, changedAt time.Time, window time.Duration, deployments []string, cluster string,
+	recommendations []types.RecommendationResult, outputs []output, out io.Writer) int {
+	profile, err := prometheus.LoadProfile(cfg.MetricsProfile)
+	if err != nil {
+		log.Print(err)
+		return exitConfigError
+	}
+	promClient := prometheus.NewClient(cfg.PrometheusURL, cfg.HTTPTimeout)
+	promClient.SetReplicaLabel(cfg.ReplicaLabel)
+
+	var workloads []workload
+	if len(deployments) > 0 {
+		if cluster == "" {
+			clusters, err := resolveClusters(ctx, cfg, promClient, profile)
+			if err != nil {
+				log.Print(err)
+				return exitRecommendFailed
+			}
+			if len(clusters) > 1 {
+				log.Printf("verify checks the deployments of one cluster, select one of %s with -cluster", strings.Join(clusters, ", "))
+				return exitConfigError
+			}
+			cluster = clusters[0]
+		}
+		for _, deployment := range deployments {
+			workloads = append(workloads, workload{cluster: cluster, namespace: cfg.CheckNamespace, deployment: deployment})
+		}
+	} else {
+		workloads = reportWorkloads(recommendations)
+	}
+
+	log.Printf("Verifying %d deployments against the change at %s", len(workloads), changedAt.Format(time.RFC3339))
+	for _, w := range workloads {
+		v := verify.New(promClient, profile, w.namespace)
+		v.SetCluster(cfg.ClusterLabel, w.cluster)
+		v.SetWindow(window)
+		v.SetPercentile(cfg.ForNamespace(w.namespace).Percentile)
+		verifications, err := v.Verify(ctx, changedAt, w.deployment)
+		if err != nil {
+			log.Printf("Failed to verify %s/%s: %v", w.namespace, w.deployment, err)
+			return exitRecommendFailed
+		}
+		if len(verifications) == 0 {
+			log.Printf("No usage found for %s/%s around the change", w.namespace, w.deployment)
+		}
+		recommendations = verify.Apply(recommendations, w.cluster, w.namespace, w.deployment, verifications)
+	}
+
+	toStdout := false
+	for _, o := range outputs {
+		toStdout = toStdout || o.filename(cfg.CheckNamespace) == exporter.Stdout
+	}
+	if !toStdout {
+		if err := printVerifications(out, recommendations); err != nil {
+			log.Print(err)
+			return exitRecommendFailed
+		}
+	}
+
+	for _, o := range outputs {
+		filename := o.filename(cfg.CheckNamespace)
+		reportExporter, err := exporter.New(o.format, filename, out)
+		if err != nil {
+			log.Print(err)
+			return exitConfigError
+		}
+		if err := reportExporter.Export(recommendations); err != nil {
+			log.Printf("Failed to export the verification: %v", err)
+			return exitRecommendFailed
+		}
+		if filename == exporter.Stdout {
+			filename = "stdout"
+		}
+		log.Printf("Verification exported to %s as %s", filename, o.format)
+	}
+
+	for _, rec := range recommendations {
+		if rec.Verification != nil && len(rec.Verification.Regressions) > 0 {
+			return exitVerifyRegression
+		}
+	}
+	return exitOK
+}
+
+// reportWorkloads returns the distinct deployments of recommendations, in report order
+func reportWorkloads(recommendations []types.RecommendationResult) []workload {
+	seen := make(map[workload]bool)
+	var workloads []workload
+	for _, rec := range recommendations {
+		w := workload{cluster: rec.Cluster, namespace: rec.Namespace, deployment: rec.Deployment}
+		if !seen[w] {
+			seen[w] = true
+			workloads = append(workloads, w)
+		}
+	}
+	return workloads
+}
+
+// printVerifications writes a before and after table per verified container,
+// then the number of containers that regressed
+func printVerifications(w io.Writer, recommendations []types.RecommendationResult) error {
+	var b strings.Builder
+	verified, regressed := 0, 0
+	for _, rec := range recommendations {
+		v := rec.Verification
+		if v == nil {
+			continue
+		}
+		verified++
+		name := rec.Namespace + "/" + rec.Deployment + "/" + rec.Container
+		if rec.Cluster != "" {
+			name = rec.Cluster + "/" + name
+		}
+		fmt.Fprintf(&b, "%s: change at %s, windows of %s\n", name, v.ChangedAt.Format(verifyTimeFormat), time.Duration(v.Window)*time.Second)
+		fmt.Fprintf(&b, "  %-12s %10s %10s\n", "", "BEFORE", "AFTER")
+		fmt.Fprintf(&b, "  %-12s %10.2f %10.2f\n", "Usage (MB)", toMB(v.Before.UsageBytes), toMB(v.After.UsageBytes))
+		fmt.Fprintf(&b, "  %-12s %10d %10d\n", "OOM kills", v.Before.OOMKills, v.After.OOMKills)
+		fmt.Fprintf(&b, "  %-12s %10d %10d\n", "Restarts", v.Before.Restarts, v.After.Restarts)
+		if v.Evictions != nil {
+			fmt.Fprintf(&b, "  %-12s %10d %10d  (pods of the whole deployment)\n", "Evictions", v.Evictions.Before, v.Evictions.After)
+		}
+		if len(v.Regressions) > 0 {
+			regressed++
+			fmt.Fprintf(&b, "  Regressions: %s\n\n", strings.Join(v.Regressions, "; "))
+		} else {
+			b.WriteString("  Regressions: none\n\n")
+		}
+	}
+	fmt.Fprintf(&b, "%d of %d verified containers regressed\n", regressed, verified)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
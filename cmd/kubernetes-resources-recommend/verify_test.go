@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestVerify_Text(t *testing.T) {
+	server := newPrometheusServer(t)
+	at := strconv.FormatInt(time.Now().Add(-2*time.Hour).Unix(), 10)
+
+	code, stdout, stderr := runCLI(t, "verify", "-prometheusUrl", server.URL, "-checkNamespace", "shop", "-at", at, "web")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	for _, expected := range []string{
+		"shop/web/app: change at ",
+		", windows of 2h0m0s\n",
+		"  Usage (MB)       100.00     100.00\n",
+		"  OOM kills             0          0\n",
+		"  Evictions             0          0  (pods of the whole deployment)\n",
+		"  Regressions: none\n",
+		"0 of 1 verified containers regressed\n",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestVerify_Report(t *testing.T) {
+	server := newPrometheusServer(t)
+	report := filepath.Join(t.TempDir(), "report.json")
+	content, err := json.Marshal([]types.RecommendationResult{{
+		Namespace: "default", Deployment: "web", Container: "app",
+		RecommendedRequestMB: 50, RecommendedRequestBytes: 50 * 1024 * 1024,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(report, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	// The usage of 100 MiB after the change exceeds the request of 50 MB
+	code, stdout, stderr := runCLI(t, "verify", "-prometheusUrl", server.URL, "-at", at, "-input", report, "-output", "json=-")
+	if code != exitVerifyRegression {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitVerifyRegression, code, stderr)
+	}
+	var recommendations []types.RecommendationResult
+	if err := json.Unmarshal([]byte(stdout), &recommendations); err != nil {
+		t.Fatalf("Invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(recommendations) != 1 || recommendations[0].Verification == nil {
+		t.Fatalf("Expected a verified recommendation, got %+v", recommendations)
+	}
+	regressions := recommendations[0].Verification.Regressions
+	if len(regressions) != 1 || regressions[0] != "usage of 100 MB above the recommended request of 50 MB" {
+		t.Errorf("Unexpected regressions: %v", regressions)
+	}
+}
+
+func TestVerify_Errors(t *testing.T) {
+	for _, args := range [][]string{
+		{"verify", "web"},
+		{"verify", "-at", "yesterday", "web"},
+		{"verify", "-at", "1700000000", "-window", "soon", "web"},
+		{"verify", "-at", "1700000000"},
+	} {
+		if code, _, _ := runCLI(t, args...); code != exitConfigError {
+			t.Errorf("%v: expected exit code %d, got %d", args, exitConfigError, code)
+		}
+	}
+}
@@ -2,7 +2,11 @@ module kubernetes-resources-recommend
 
 go 1.23.9
 
-require github.com/xuri/excelize/v2 v2.9.1
+require (
+	github.com/xuri/excelize/v2 v2.9.1
+	go.etcd.io/bbolt v1.4.3
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/richardlehane/mscfb v1.0.4 // indirect
@@ -12,5 +16,6 @@ require (
 	github.com/xuri/nfp v0.0.1 // indirect
 	golang.org/x/crypto v0.38.0 // indirect
 	golang.org/x/net v0.40.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/text v0.25.0 // indirect
 )
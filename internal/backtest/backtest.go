@@ -0,0 +1,137 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// DefaultResolution is the width of each replayed sample
+const DefaultResolution = time.Minute
+
+// maxPoints bounds the samples per series of one range query, below the
+// 11,000 points Prometheus accepts
+const maxPoints = 10000
+
+// maxPeaks bounds the peaks reported per container
+const maxPeaks = 10
+
+// Backtester replays the past per-pod usage of containers against their
+// recommended request and limit
+type Backtester struct {
+	client       *prometheus.Client
+	profile      *types.MetricsProfile
+	clusterLabel string
+	window       time.Duration
+	resolution   time.Duration
+	now          func() time.Time
+}
+
+// New creates a backtester replaying the window before now at DefaultResolution.
+// clusterLabel scopes the queries to the cluster of each recommendation, empty for a single cluster.
+func New(client *prometheus.Client, profile *types.MetricsProfile, clusterLabel string, window time.Duration) *Backtester {
+	return &Backtester{
+		client:       client,
+		profile:      profile,
+		clusterLabel: clusterLabel,
+		window:       window,
+		resolution:   DefaultResolution,
+		now:          time.Now,
+	}
+}
+
+// SetResolution sets the width of each replayed sample, the maximum usage
+// over it being compared to the recommendation
+func (b *Backtester) SetResolution(resolution time.Duration) {
+	b.resolution = resolution
+}
+
+// Run sets the Backtest of every recommendation, stopping at the first failed query
+func (b *Backtester) Run(ctx context.Context, recommendations []types.RecommendationResult) error {
+	if b.resolution < time.Second || b.window < b.resolution {
+		return fmt.Errorf("invalid backtest: the resolution must be at least 1s and the window at least the resolution")
+	}
+	end := b.now().Truncate(b.resolution)
+	start := end.Add(-b.window)
+	for i := range recommendations {
+		result, err := b.container(ctx, recommendations[i], start, end)
+		if err != nil {
+			rec := recommendations[i]
+			return fmt.Errorf("failed to backtest %s/%s/%s: %w", rec.Namespace, rec.Deployment, rec.Container, err)
+		}
+		recommendations[i].Backtest = result
+	}
+	return nil
+}
+
+// container replays the usage of the pods of the container of rec between start and end
+func (b *Backtester) container(ctx context.Context, rec types.RecommendationResult, start, end time.Time) (*types.Backtest, error) {
+	step := int64(b.resolution / time.Second)
+	selector := prometheus.Selector(b.profile.ContainerMemory,
+		prometheus.Eq(b.clusterLabel, rec.Cluster),
+		prometheus.Eq(b.profile.NamespaceLabel, rec.Namespace),
+		prometheus.Eq(b.profile.CadvisorContainerLabel, rec.Container),
+		prometheus.DeploymentPods(b.profile.CadvisorPodLabel, rec.Deployment))
+	promql := fmt.Sprintf(`max by (%s) (max_over_time(%s[%ds]))`, b.profile.CadvisorPodLabel, selector, step)
+
+	result := &types.Backtest{Start: start.UTC(), End: end.UTC(), Resolution: int(step)}
+	peaks := make(map[string]types.BacktestPeak)
+	overRequest := make(map[string]bool)
+	overLimit := make(map[string]bool)
+
+	// Query the window in chunks of at most maxPoints samples, each sample
+	// covering the resolution up to its timestamp
+	first, last := start.Unix()+step, end.Unix()
+	for chunkStart := first; chunkStart <= last; chunkStart += step * maxPoints {
+		chunkEnd := min(chunkStart+step*(maxPoints-1), last)
+		data, err := b.client.QueryRange(ctx, promql, chunkStart, chunkEnd, int(step))
+		if err != nil {
+			return nil, err
+		}
+		for _, series := range data.Data.Result {
+			pod := series.Metric[b.profile.CadvisorPodLabel]
+			for _, sample := range series.Values {
+				at, bytes, ok := prometheus.ParseSample(sample)
+				if !ok {
+					continue
+				}
+				if peak, seen := peaks[pod]; !seen || bytes > peak.Bytes {
+					peaks[pod] = types.BacktestPeak{Pod: pod, Time: time.Unix(at, 0).UTC(), Bytes: bytes}
+				}
+				if bytes > rec.RecommendedRequestBytes {
+					overRequest[pod] = true
+					result.MinutesOverRequest += float64(step) / 60
+				}
+				if bytes > rec.RecommendedLimitBytes {
+					overLimit[pod] = true
+					result.MinutesOverLimit += float64(step) / 60
+				}
+			}
+		}
+	}
+
+	result.Pods = len(peaks)
+	result.PodsOverRequest = len(overRequest)
+	result.PodsOverLimit = len(overLimit)
+	for pod, peak := range peaks {
+		result.PeakBytes = max(result.PeakBytes, peak.Bytes)
+		if overRequest[pod] {
+			peak.OverLimit = overLimit[pod]
+			result.Peaks = append(result.Peaks, peak)
+		}
+	}
+	sort.Slice(result.Peaks, func(i, j int) bool {
+		if result.Peaks[i].Bytes != result.Peaks[j].Bytes {
+			return result.Peaks[i].Bytes > result.Peaks[j].Bytes
+		}
+		return result.Peaks[i].Pod < result.Peaks[j].Pod
+	})
+	if len(result.Peaks) > maxPeaks {
+		result.Peaks = result.Peaks[:maxPeaks]
+	}
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+const mb = 1024 * 1024
+
+// rangeQuery is the query, range and step of a request to the test server
+type rangeQuery struct {
+	promql           string
+	start, end, step int64
+}
+
+// newUsageServer answers every range query with web-1 using 100 then 300 MiB
+// and web-2 using 150 MiB at the start of the range, recording the queries
+func newUsageServer(t *testing.T) (*httptest.Server, *[]rangeQuery) {
+	t.Helper()
+	var mu sync.Mutex
+	var queries []rangeQuery
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		query := rangeQuery{promql: params.Get("query")}
+		query.start, _ = strconv.ParseInt(params.Get("start"), 10, 64)
+		query.end, _ = strconv.ParseInt(params.Get("end"), 10, 64)
+		query.step, _ = strconv.ParseInt(params.Get("step"), 10, 64)
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+
+		fmt.Fprintf(w, `{"data": {"result": [
+			{"metric": {"pod": "web-1"}, "values": [[%d, "%d"], [%d, "%d"]]},
+			{"metric": {"pod": "web-2"}, "values": [[%d, "%d"]]}
+		]}}`, query.start, 100*mb, query.start+query.step, 300*mb, query.start, 150*mb)
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+// recommendation is a request of 128 MiB and a limit of 256 MiB
+var recommendation = types.RecommendationResult{
+	Namespace: "shop", Deployment: "web", Container: "app",
+	RecommendedRequestBytes: 128 * mb, RecommendedLimitBytes: 256 * mb,
+}
+
+func TestBacktester_Run(t *testing.T) {
+	server, queries := newUsageServer(t)
+	now := time.Date(2026, 10, 18, 12, 0, 30, 0, time.UTC)
+	b := New(prometheus.NewClient(server.URL, 30*time.Second), prometheus.DefaultProfile(), "", time.Hour)
+	b.now = func() time.Time { return now }
+
+	recommendations := []types.RecommendationResult{recommendation}
+	if err := b.Run(context.Background(), recommendations); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	profile := prometheus.DefaultProfile()
+	expectedQuery := rangeQuery{
+		promql: fmt.Sprintf(`max by (%s) (max_over_time(%s{namespace="shop", %s="app", %s=~"web-[a-z0-9]{5,10}-[a-z0-9]{5}"}[60s]))`,
+			profile.CadvisorPodLabel, profile.ContainerMemory, profile.CadvisorContainerLabel, profile.CadvisorPodLabel),
+		start: now.Truncate(time.Minute).Add(-time.Hour).Unix() + 60,
+		end:   now.Truncate(time.Minute).Unix(),
+		step:  60,
+	}
+	if len(*queries) != 1 || (*queries)[0] != expectedQuery {
+		t.Fatalf("Expected the query %+v, got %+v", expectedQuery, *queries)
+	}
+
+	result := recommendations[0].Backtest
+	if result == nil {
+		t.Fatal("Expected a backtest")
+	}
+	if result.Pods != 2 || result.PodsOverRequest != 2 || result.MinutesOverRequest != 2 ||
+		result.PodsOverLimit != 1 || result.MinutesOverLimit != 1 || result.PeakBytes != 300*mb {
+		t.Errorf("Unexpected backtest: %+v", result)
+	}
+	expectedPeaks := []types.BacktestPeak{
+		{Pod: "web-1", Time: time.Unix(expectedQuery.start+60, 0).UTC(), Bytes: 300 * mb, OverLimit: true},
+		{Pod: "web-2", Time: time.Unix(expectedQuery.start, 0).UTC(), Bytes: 150 * mb},
+	}
+	if len(result.Peaks) != 2 || result.Peaks[0] != expectedPeaks[0] || result.Peaks[1] != expectedPeaks[1] {
+		t.Errorf("Expected peaks %+v, got %+v", expectedPeaks, result.Peaks)
+	}
+}
+
+func TestBacktester_Run_Chunks(t *testing.T) {
+	server, queries := newUsageServer(t)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	b := New(prometheus.NewClient(server.URL, 30*time.Second), prometheus.DefaultProfile(), "", 7*24*time.Hour)
+	b.now = func() time.Time { return now }
+
+	recommendations := []types.RecommendationResult{recommendation}
+	if err := b.Run(context.Background(), recommendations); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 10,080 minutes take two queries, the second starting a step after the first ends
+	if len(*queries) != 2 {
+		t.Fatalf("Expected 2 queries, got %d", len(*queries))
+	}
+	first, second := (*queries)[0], (*queries)[1]
+	if first.start != now.Add(-7*24*time.Hour).Unix()+60 || first.end != first.start+60*(maxPoints-1) ||
+		second.start != first.end+60 || second.end != now.Unix() {
+		t.Errorf("Unexpected chunks: %+v, %+v", first, second)
+	}
+	if result := recommendations[0].Backtest; result.Pods != 2 || result.MinutesOverRequest != 4 || result.MinutesOverLimit != 2 {
+		t.Errorf("Expected the minutes of both chunks, got %+v", result)
+	}
+}
+
+func TestBacktester_Run_Errors(t *testing.T) {
+	b := New(prometheus.NewClient("http://127.0.0.1:0", time.Second), prometheus.DefaultProfile(), "", time.Hour)
+	if err := b.Run(context.Background(), []types.RecommendationResult{recommendation}); err == nil {
+		t.Error("Expected an error for an unreachable Prometheus")
+	}
+
+	b.SetResolution(2 * time.Hour)
+	if err := b.Run(context.Background(), nil); err == nil {
+		t.Error("Expected an error for a resolution wider than the window")
+	}
+}
@@ -0,0 +1,130 @@
+package binpack
+
+import (
+	"sort"
+)
+
+// Node is a schedulable node and the memory reserved on it by DaemonSet pods,
+// which stay on every node whatever the packing
+type Node struct {
+	Name             string
+	Pool             string
+	AllocatableBytes float64
+	ReservedBytes    float64
+}
+
+// Pod is a running pod with its memory requests before and after applying the recommendations
+type Pod struct {
+	Namespace        string
+	Name             string
+	Node             string
+	CurrentBytes     float64
+	RecommendedBytes float64
+}
+
+// PoolResult compares the nodes of a node pool the pods pack onto with their
+// current and their recommended requests
+type PoolResult struct {
+	Cluster string `json:"cluster,omitempty"`
+	Pool    string `json:"node_pool"` // empty without a node pool label
+	Nodes   int    `json:"nodes"`
+	Pods    int    `json:"pods"`
+
+	AllocatableBytes        float64 `json:"allocatable_bytes"`
+	ReservedBytes           float64 `json:"reserved_bytes"` // requests of the DaemonSet pods
+	CurrentRequestBytes     float64 `json:"current_request_bytes"`
+	RecommendedRequestBytes float64 `json:"recommended_request_bytes"`
+
+	// CurrentNodes and RecommendedNodes are the nodes the pods pack onto,
+	// Drainable the nodes the recommended requests free of the current ones,
+	// each unschedulable pod keeping a node of its own
+	CurrentNodes     int `json:"current_nodes"`
+	RecommendedNodes int `json:"recommended_nodes"`
+	Drainable        int `json:"drainable"`
+
+	// Unschedulable counts the pods fitting no node with the recommended requests
+	Unschedulable int `json:"unschedulable"`
+}
+
+// Simulate re-packs the pods of each node pool onto its nodes with a
+// first-fit-decreasing scheduler, once with the current and once with the
+// recommended requests. Pods stay in the pool of the node they run on, pods
+// on unknown nodes are left out. Results are sorted by pool.
+func Simulate(nodes []Node, pods []Pod) []PoolResult {
+	pools := make(map[string]*PoolResult)
+	capacities := make(map[string][]float64)
+	poolOf := make(map[string]string, len(nodes))
+
+	sorted := append([]Node(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, node := range sorted {
+		result, ok := pools[node.Pool]
+		if !ok {
+			result = &PoolResult{Pool: node.Pool}
+			pools[node.Pool] = result
+		}
+		result.Nodes++
+		result.AllocatableBytes += node.AllocatableBytes
+		result.ReservedBytes += node.ReservedBytes
+		capacities[node.Pool] = append(capacities[node.Pool], max(node.AllocatableBytes-node.ReservedBytes, 0))
+		poolOf[node.Name] = node.Pool
+	}
+
+	current := make(map[string][]float64)
+	recommended := make(map[string][]float64)
+	for _, pod := range pods {
+		pool, ok := poolOf[pod.Node]
+		if !ok {
+			continue
+		}
+		result := pools[pool]
+		result.Pods++
+		result.CurrentRequestBytes += pod.CurrentBytes
+		result.RecommendedRequestBytes += pod.RecommendedBytes
+		current[pool] = append(current[pool], pod.CurrentBytes)
+		recommended[pool] = append(recommended[pool], pod.RecommendedBytes)
+	}
+
+	results := make([]PoolResult, 0, len(pools))
+	for pool, result := range pools {
+		result.CurrentNodes, _ = Pack(capacities[pool], current[pool])
+		result.RecommendedNodes, result.Unschedulable = Pack(capacities[pool], recommended[pool])
+		result.Drainable = max(result.CurrentNodes-result.RecommendedNodes-result.Unschedulable, 0)
+		results = append(results, *result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Pool < results[j].Pool })
+	return results
+}
+
+// Pack places requests onto bins of capacities with first-fit-decreasing:
+// the largest request first, each onto the first bin with room, the bins
+// being tried largest first. It returns the bins used and the requests
+// fitting no bin.
+func Pack(capacities, requests []float64) (used, unplaced int) {
+	free := append([]float64(nil), capacities...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(free)))
+	items := append([]float64(nil), requests...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(items)))
+
+	occupied := make([]bool, len(free))
+	for _, item := range items {
+		placed := false
+		for i := range free {
+			if item <= free[i] {
+				free[i] -= item
+				occupied[i] = true
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			unplaced++
+		}
+	}
+	for _, o := range occupied {
+		if o {
+			used++
+		}
+	}
+	return used, unplaced
+}
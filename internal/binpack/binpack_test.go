@@ -0,0 +1,83 @@
+package binpack
+
+import (
+	"reflect"
+	"testing"
+)
+
+const gib = 1024 * 1024 * 1024
+
+func TestPack(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacities []float64
+		requests   []float64
+		used       int
+		unplaced   int
+	}{
+		{"Empty", []float64{8, 8}, nil, 0, 0},
+		{"Largest first", []float64{10, 10, 10}, []float64{3, 7, 3, 7}, 2, 0},
+		{"Largest bins first", []float64{4, 16}, []float64{6, 6, 4}, 1, 0},
+		{"Overflow onto the next bin", []float64{8, 8, 8}, []float64{5, 5, 5}, 3, 0},
+		{"Unplaceable", []float64{8, 8}, []float64{9, 2}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used, unplaced := Pack(tt.capacities, tt.requests)
+			if used != tt.used || unplaced != tt.unplaced {
+				t.Errorf("Expected %d bins used and %d unplaced, got %d and %d", tt.used, tt.unplaced, used, unplaced)
+			}
+		})
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	nodes := []Node{
+		{Name: "node-3", Pool: "general", AllocatableBytes: 8 * gib, ReservedBytes: 1 * gib},
+		{Name: "node-1", Pool: "general", AllocatableBytes: 8 * gib, ReservedBytes: 1 * gib},
+		{Name: "node-2", Pool: "general", AllocatableBytes: 8 * gib, ReservedBytes: 1 * gib},
+		{Name: "big-1", Pool: "highmem", AllocatableBytes: 32 * gib},
+	}
+	pods := []Pod{
+		{Name: "web-1", Node: "node-1", CurrentBytes: 4 * gib, RecommendedBytes: 2 * gib},
+		{Name: "web-2", Node: "node-2", CurrentBytes: 4 * gib, RecommendedBytes: 2 * gib},
+		{Name: "web-3", Node: "node-3", CurrentBytes: 4 * gib, RecommendedBytes: 2 * gib},
+		{Name: "api-1", Node: "node-1", CurrentBytes: 2 * gib, RecommendedBytes: 1.5 * gib},
+		{Name: "db-1", Node: "big-1", CurrentBytes: 16 * gib, RecommendedBytes: 40 * gib},
+		{Name: "pending", Node: "", CurrentBytes: 1 * gib, RecommendedBytes: 1 * gib},
+	}
+
+	expected := []PoolResult{
+		{
+			Pool: "general", Nodes: 3, Pods: 4,
+			AllocatableBytes: 24 * gib, ReservedBytes: 3 * gib, CurrentRequestBytes: 14 * gib, RecommendedRequestBytes: 7.5 * gib,
+			CurrentNodes: 3, RecommendedNodes: 2, Drainable: 1,
+		},
+		{
+			Pool: "highmem", Nodes: 1, Pods: 1,
+			AllocatableBytes: 32 * gib, CurrentRequestBytes: 16 * gib, RecommendedRequestBytes: 40 * gib,
+			CurrentNodes: 1, RecommendedNodes: 0, Drainable: 0, Unschedulable: 1,
+		},
+	}
+	if results := Simulate(nodes, pods); !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+}
+
+func TestSimulate_DrainableFromCurrentNodes(t *testing.T) {
+	nodes := []Node{
+		{Name: "node-1", Pool: "general", AllocatableBytes: 8 * gib},
+		{Name: "node-2", Pool: "general", AllocatableBytes: 8 * gib},
+		{Name: "node-3", Pool: "general", AllocatableBytes: 8 * gib},
+	}
+	pods := []Pod{
+		{Name: "web-1", Node: "node-1", CurrentBytes: 6 * gib, RecommendedBytes: 3 * gib},
+		{Name: "web-2", Node: "node-2", CurrentBytes: 6 * gib, RecommendedBytes: 3 * gib},
+	}
+
+	// The pods already pack onto 2 of the 3 nodes, so the recommendation frees 1
+	results := Simulate(nodes, pods)
+	if len(results) != 1 || results[0].CurrentNodes != 2 || results[0].RecommendedNodes != 1 || results[0].Drainable != 1 {
+		t.Errorf("Expected 2 current, 1 recommended and 1 drainable node, got %+v", results)
+	}
+}
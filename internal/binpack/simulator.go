@@ -0,0 +1,195 @@
+package binpack
+
+import (
+	"context"
+	"fmt"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// daemonSetKind is the owner kind of the pods running on every node
+const daemonSetKind = "DaemonSet"
+
+// Simulator loads the nodes and running pods of a cluster from Prometheus and
+// re-packs them with the recommended requests
+type Simulator struct {
+	client        *prometheus.Client
+	profile       *types.MetricsProfile
+	clusterLabel  string
+	cluster       string
+	nodePoolLabel string
+}
+
+// New creates a simulator of a single cluster with every node in one pool
+func New(client *prometheus.Client, profile *types.MetricsProfile) *Simulator {
+	return &Simulator{
+		client:  client,
+		profile: profile,
+	}
+}
+
+// SetCluster restricts the queries to one cluster of a shared Prometheus/Thanos
+func (s *Simulator) SetCluster(clusterLabel, cluster string) {
+	s.clusterLabel = clusterLabel
+	s.cluster = cluster
+}
+
+// SetNodePoolLabel sets the kube_node_labels label naming the node pool of a
+// node, empty to pack every node as one pool
+func (s *Simulator) SetNodePoolLabel(label string) {
+	s.nodePoolLabel = label
+}
+
+// Run packs the running pods of the cluster with their current requests and
+// with the requests of the matching recommendations, per node pool
+func (s *Simulator) Run(ctx context.Context, recommendations []types.RecommendationResult) ([]PoolResult, error) {
+	nodes, err := s.nodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := s.pods(ctx, recommendations)
+	if err != nil {
+		return nil, err
+	}
+
+	// DaemonSet pods are not re-packed, they reserve their requests on their node
+	reserved := make(map[string]float64)
+	var packed []Pod
+	for _, pod := range pods {
+		if pod.daemonSet {
+			reserved[pod.Node] += pod.CurrentBytes
+		} else {
+			packed = append(packed, pod.Pod)
+		}
+	}
+	for i := range nodes {
+		nodes[i].ReservedBytes = reserved[nodes[i].Name]
+	}
+
+	results := Simulate(nodes, packed)
+	for i := range results {
+		results[i].Cluster = s.cluster
+	}
+	return results, nil
+}
+
+// nodes returns the allocatable memory and node pool of every node
+func (s *Simulator) nodes(ctx context.Context) ([]Node, error) {
+	p := s.profile
+	promql := fmt.Sprintf(`max by (%s) (%s)`, p.NodeLabel,
+		s.selector(p.NodeAllocatable, prometheus.Eq(p.ResourceLabel, p.ResourceMemory)))
+	allocatable, err := s.client.Query(ctx, promql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the allocatable memory of the nodes: %w", err)
+	}
+
+	poolOf := make(map[string]string)
+	if s.nodePoolLabel != "" {
+		labels, err := s.client.Query(ctx, fmt.Sprintf(`max by (%s, %s) (%s)`, p.NodeLabel, s.nodePoolLabel, s.selector(p.NodeLabels)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query the node pools: %w", err)
+		}
+		for _, result := range labels.Data.Result {
+			poolOf[result.Metric[p.NodeLabel]] = result.Metric[s.nodePoolLabel]
+		}
+	}
+
+	var nodes []Node
+	for _, result := range allocatable.Data.Result {
+		if bytes, ok := prometheus.ParseValue(result.Value); ok {
+			name := result.Metric[p.NodeLabel]
+			nodes = append(nodes, Node{Name: name, Pool: poolOf[name], AllocatableBytes: bytes})
+		}
+	}
+	return nodes, nil
+}
+
+// runningPod is a pod to pack, or a DaemonSet pod reserving its requests
+type runningPod struct {
+	Pod
+	daemonSet bool
+}
+
+// pods returns the running pods with their node and requests, the requests of
+// the containers of recommendations being replaced by the recommended ones
+func (s *Simulator) pods(ctx context.Context, recommendations []types.RecommendationResult) ([]runningPod, error) {
+	p := s.profile
+	running := fmt.Sprintf(`max by (%s, %s, %s) (%s) and on (%s, %s) (%s == 1)`,
+		p.NamespaceLabel, p.PodLabel, p.NodeLabel, s.selector(p.PodInfo),
+		p.NamespaceLabel, p.PodLabel, s.selector(p.PodStatusPhase, prometheus.Eq(p.PhaseLabel, "Running")))
+	info, err := s.client.Query(ctx, running)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the running pods: %w", err)
+	}
+
+	requests, err := s.client.Query(ctx, fmt.Sprintf(`sum by (%s, %s, %s) (%s)`, p.NamespaceLabel, p.PodLabel, p.ContainerLabel,
+		s.selector(p.MemoryRequests, prometheus.Eq(p.ResourceLabel, p.ResourceMemory))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the memory requests of the pods: %w", err)
+	}
+
+	daemonSets, err := s.client.Query(ctx, fmt.Sprintf(`max by (%s, %s) (%s)`, p.NamespaceLabel, p.PodLabel,
+		s.selector(p.PodOwner, prometheus.Eq(p.OwnerKindLabel, daemonSetKind))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the DaemonSet pods: %w", err)
+	}
+	isDaemonSet := make(map[string]bool, len(daemonSets.Data.Result))
+	for _, result := range daemonSets.Data.Result {
+		isDaemonSet[result.Metric[p.NamespaceLabel]+"/"+result.Metric[p.PodLabel]] = true
+	}
+
+	recommended := make(map[string]float64, len(recommendations))
+	for _, rec := range recommendations {
+		if rec.Cluster == s.cluster {
+			recommended[rec.Namespace+"/"+rec.Deployment+"/"+rec.Container] = rec.RecommendedRequestBytes
+		}
+	}
+
+	pods := make(map[string]*runningPod, len(info.Data.Result))
+	var order []string
+	for _, result := range info.Data.Result {
+		key := result.Metric[p.NamespaceLabel] + "/" + result.Metric[p.PodLabel]
+		if _, seen := pods[key]; seen {
+			continue
+		}
+		pods[key] = &runningPod{
+			Pod: Pod{
+				Namespace: result.Metric[p.NamespaceLabel],
+				Name:      result.Metric[p.PodLabel],
+				Node:      result.Metric[p.NodeLabel],
+			},
+			daemonSet: isDaemonSet[key],
+		}
+		order = append(order, key)
+	}
+
+	for _, result := range requests.Data.Result {
+		pod, ok := pods[result.Metric[p.NamespaceLabel]+"/"+result.Metric[p.PodLabel]]
+		if !ok {
+			continue
+		}
+		bytes, ok := prometheus.ParseValue(result.Value)
+		if !ok {
+			continue
+		}
+		pod.CurrentBytes += bytes
+		if deployment := prometheus.DeploymentOfPod(pod.Name); deployment != "" && !pod.daemonSet {
+			if request, ok := recommended[pod.Namespace+"/"+deployment+"/"+result.Metric[p.ContainerLabel]]; ok {
+				bytes = request
+			}
+		}
+		pod.RecommendedBytes += bytes
+	}
+
+	result := make([]runningPod, 0, len(order))
+	for _, key := range order {
+		result = append(result, *pods[key])
+	}
+	return result, nil
+}
+
+// selector renders a series selector restricted to the simulated cluster
+func (s *Simulator) selector(metric string, matchers ...prometheus.Matcher) string {
+	return prometheus.Selector(metric, append([]prometheus.Matcher{prometheus.Eq(s.clusterLabel, s.cluster)}, matchers...)...)
+}
@@ -0,0 +1,113 @@
+package binpack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// newSimulatorServer serves two 4 GiB general nodes, each running a pod of
+// web requesting 2 GiB and a DaemonSet pod requesting 1 GiB, and a highmem
+// node running a pod of db
+func newSimulatorServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+
+		response := `{"data": {"result": []}}`
+		switch {
+		case strings.Contains(query, "kube_node_status_allocatable"):
+			response = `{"data": {"result": [
+				{"metric": {"node": "node-1"}, "value": [1, "4294967296"]},
+				{"metric": {"node": "node-2"}, "value": [1, "4294967296"]},
+				{"metric": {"node": "big-1"}, "value": [1, "17179869184"]}]}}`
+		case strings.Contains(query, "kube_node_labels"):
+			response = `{"data": {"result": [
+				{"metric": {"node": "node-1", "label_pool": "general"}, "value": [1, "1"]},
+				{"metric": {"node": "node-2", "label_pool": "general"}, "value": [1, "1"]},
+				{"metric": {"node": "big-1", "label_pool": "highmem"}, "value": [1, "1"]}]}}`
+		case strings.Contains(query, "kube_pod_info"):
+			response = `{"data": {"result": [
+				{"metric": {"namespace": "shop", "pod": "web-5d8f7c9b6-aaaaa", "node": "node-1"}, "value": [1, "1"]},
+				{"metric": {"namespace": "shop", "pod": "web-5d8f7c9b6-bbbbb", "node": "node-2"}, "value": [1, "1"]},
+				{"metric": {"namespace": "shop", "pod": "db-0", "node": "big-1"}, "value": [1, "1"]},
+				{"metric": {"namespace": "monitoring", "pod": "agent-x1", "node": "node-1"}, "value": [1, "1"]},
+				{"metric": {"namespace": "monitoring", "pod": "agent-x2", "node": "node-2"}, "value": [1, "1"]}]}}`
+		case strings.Contains(query, "kube_pod_container_resource_requests"):
+			response = `{"data": {"result": [
+				{"metric": {"namespace": "shop", "pod": "web-5d8f7c9b6-aaaaa", "container": "app"}, "value": [1, "2147483648"]},
+				{"metric": {"namespace": "shop", "pod": "web-5d8f7c9b6-bbbbb", "container": "app"}, "value": [1, "2147483648"]},
+				{"metric": {"namespace": "shop", "pod": "db-0", "container": "db"}, "value": [1, "8589934592"]},
+				{"metric": {"namespace": "monitoring", "pod": "agent-x1", "container": "agent"}, "value": [1, "1073741824"]},
+				{"metric": {"namespace": "monitoring", "pod": "agent-x2", "container": "agent"}, "value": [1, "1073741824"]}]}}`
+		case strings.Contains(query, "kube_pod_owner"):
+			response = `{"data": {"result": [
+				{"metric": {"namespace": "monitoring", "pod": "agent-x1"}, "value": [1, "1"]},
+				{"metric": {"namespace": "monitoring", "pod": "agent-x2"}, "value": [1, "1"]}]}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+func TestSimulator_Run(t *testing.T) {
+	server, queries := newSimulatorServer(t)
+	s := New(prometheus.NewClient(server.URL, 30*time.Second), prometheus.DefaultProfile())
+	s.SetCluster("cluster", "prod")
+	s.SetNodePoolLabel("label_pool")
+
+	recommendations := []types.RecommendationResult{
+		{Cluster: "prod", Namespace: "shop", Deployment: "web", Container: "app", RecommendedRequestBytes: 1 * gib},
+		{Cluster: "staging", Namespace: "shop", Deployment: "db", Container: "db", RecommendedRequestBytes: 1 * gib},
+	}
+	results, err := s.Run(context.Background(), recommendations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// With 1 GiB each, both web pods fit the 3 GiB left by the agent on one node
+	expected := []PoolResult{
+		{
+			Cluster: "prod", Pool: "general", Nodes: 2, Pods: 2,
+			AllocatableBytes: 8 * gib, ReservedBytes: 2 * gib, CurrentRequestBytes: 4 * gib, RecommendedRequestBytes: 2 * gib,
+			CurrentNodes: 2, RecommendedNodes: 1, Drainable: 1,
+		},
+		{
+			Cluster: "prod", Pool: "highmem", Nodes: 1, Pods: 1,
+			AllocatableBytes: 16 * gib, CurrentRequestBytes: 8 * gib, RecommendedRequestBytes: 8 * gib,
+			CurrentNodes: 1, RecommendedNodes: 1,
+		},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+
+	for _, query := range []string{
+		`max by (node) (kube_node_status_allocatable{cluster="prod", resource="memory"})`,
+		`max by (namespace, pod, node) (kube_pod_info{cluster="prod"}) and on (namespace, pod) (kube_pod_status_phase{cluster="prod", phase="Running"} == 1)`,
+		`max by (namespace, pod) (kube_pod_owner{cluster="prod", owner_kind="DaemonSet"})`,
+	} {
+		found := false
+		for _, q := range *queries {
+			found = found || q == query
+		}
+		if !found {
+			t.Errorf("Expected the query %s, got %v", query, *queries)
+		}
+	}
+}
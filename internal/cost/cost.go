@@ -0,0 +1,190 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// Hours priced per month and per year
+const (
+	hoursPerMonth = 730
+	hoursPerYear  = 8760
+)
+
+// bytesPerGiB converts the request bytes to the GiB the prices are quoted in
+const bytesPerGiB = 1024 * 1024 * 1024
+
+// Estimator prices the current and recommended memory requests of containers
+// across the replicas of their deployment
+type Estimator struct {
+	client        *prometheus.Client
+	profile       *types.MetricsProfile
+	prices        *Prices
+	clusterLabel  string
+	nodePoolLabel string
+}
+
+// New creates an estimator applying prices
+func New(client *prometheus.Client, profile *types.MetricsProfile, prices *Prices) *Estimator {
+	return &Estimator{
+		client:  client,
+		profile: profile,
+		prices:  prices,
+	}
+}
+
+// SetClusterLabel scopes the queries to the cluster of each recommendation, empty for a single cluster
+func (e *Estimator) SetClusterLabel(label string) {
+	e.clusterLabel = label
+}
+
+// SetNodePoolLabel sets the kube_node_labels label naming the node pool of a
+// node, empty to leave the node pool prices out
+func (e *Estimator) SetNodePoolLabel(label string) {
+	e.nodePoolLabel = label
+}
+
+// scope is a namespace of a cluster
+type scope struct {
+	cluster   string
+	namespace string
+}
+
+// Run sets the Cost of every recommendation with a non-zero price, stopping
+// at the first failed query
+func (e *Estimator) Run(ctx context.Context, recommendations []types.RecommendationResult) error {
+	replicas := make(map[scope]map[string]float64)
+	nodePools := make(map[scope]map[string]string)
+	withNodePools := e.nodePoolLabel != "" && len(e.prices.NodePools) > 0
+
+	for i := range recommendations {
+		rec := &recommendations[i]
+		s := scope{cluster: rec.Cluster, namespace: rec.Namespace}
+		if _, ok := replicas[s]; !ok {
+			var err error
+			if replicas[s], err = e.replicas(ctx, s); err != nil {
+				return fmt.Errorf("failed to query the replicas of %s: %w", s.namespace, err)
+			}
+			if withNodePools {
+				if nodePools[s], err = e.nodePools(ctx, s); err != nil {
+					return fmt.Errorf("failed to query the node pools of %s: %w", s.namespace, err)
+				}
+			}
+		}
+
+		nodePool := nodePools[s][rec.Deployment]
+		price, source := e.prices.price(rec.Cluster, rec.Namespace, nodePool)
+		if price <= 0 {
+			continue
+		}
+
+		// A deployment missing from kube-state-metrics is priced as one replica
+		count := int(replicas[s][rec.Deployment])
+		if count <= 0 {
+			count = 1
+		}
+		hourly := func(bytes float64) float64 { return bytes / bytesPerGiB * price * float64(count) }
+		rec.Cost = &types.Cost{
+			Currency:           e.prices.Currency,
+			PricePerGiBHour:    price,
+			PriceSource:        source,
+			NodePool:           nodePool,
+			Replicas:           count,
+			CurrentMonthly:     round(hourly(rec.CurrentRequestBytes) * hoursPerMonth),
+			RecommendedMonthly: round(hourly(rec.RecommendedRequestBytes) * hoursPerMonth),
+			AnnualSavings:      round((hourly(rec.CurrentRequestBytes) - hourly(rec.RecommendedRequestBytes)) * hoursPerYear),
+		}
+	}
+	return nil
+}
+
+// replicas returns the desired replicas of each deployment of s
+func (e *Estimator) replicas(ctx context.Context, s scope) (map[string]float64, error) {
+	promql := fmt.Sprintf(`max by (%s) (%s)`, e.profile.DeploymentLabel, e.selector(e.profile.DeploymentReplicas, s,
+		prometheus.Eq(e.profile.NamespaceLabel, s.namespace)))
+	data, err := e.client.Query(ctx, promql)
+	if err != nil {
+		return nil, err
+	}
+	replicas := make(map[string]float64, len(data.Data.Result))
+	for _, result := range data.Data.Result {
+		if value, ok := prometheus.ParseValue(result.Value); ok {
+			replicas[result.Metric[e.profile.DeploymentLabel]] = value
+		}
+	}
+	return replicas, nil
+}
+
+// nodePools returns the node pool of each deployment of s, the pool running
+// most of its pods
+func (e *Estimator) nodePools(ctx context.Context, s scope) (map[string]string, error) {
+	p := e.profile
+	nodes, err := e.client.Query(ctx, fmt.Sprintf(`max by (%s, %s) (%s)`, p.NodeLabel, e.nodePoolLabel, e.selector(p.NodeLabels, s)))
+	if err != nil {
+		return nil, err
+	}
+	poolOf := make(map[string]string, len(nodes.Data.Result))
+	for _, result := range nodes.Data.Result {
+		poolOf[result.Metric[p.NodeLabel]] = result.Metric[e.nodePoolLabel]
+	}
+
+	pods, err := e.client.Query(ctx, fmt.Sprintf(`max by (%s, %s) (%s)`, p.PodLabel, p.NodeLabel,
+		e.selector(p.PodInfo, s, prometheus.Eq(p.NamespaceLabel, s.namespace))))
+	if err != nil {
+		return nil, err
+	}
+	var podNames []string
+	podPool := make(map[string]string, len(pods.Data.Result))
+	for _, result := range pods.Data.Result {
+		pod := result.Metric[p.PodLabel]
+		if pool := poolOf[result.Metric[p.NodeLabel]]; pool != "" {
+			podNames = append(podNames, pod)
+			podPool[pod] = pool
+		}
+	}
+
+	// Count the pods of each deployment per pool, a pod web-5d8f7c9b6-x2k9z
+	// belonging to the deployment web only
+	counts := make(map[string]map[string]int)
+	for _, pod := range podNames {
+		deployment := prometheus.DeploymentOfPod(pod)
+		if deployment == "" {
+			continue
+		}
+		if counts[deployment] == nil {
+			counts[deployment] = make(map[string]int)
+		}
+		counts[deployment][podPool[pod]]++
+	}
+
+	pools := make(map[string]string, len(counts))
+	for deployment, byPool := range counts {
+		names := make([]string, 0, len(byPool))
+		for pool := range byPool {
+			names = append(names, pool)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if byPool[names[i]] != byPool[names[j]] {
+				return byPool[names[i]] > byPool[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		pools[deployment] = names[0]
+	}
+	return pools, nil
+}
+
+// selector renders a series selector restricted to the cluster of s
+func (e *Estimator) selector(metric string, s scope, matchers ...prometheus.Matcher) string {
+	return prometheus.Selector(metric, append([]prometheus.Matcher{prometheus.Eq(e.clusterLabel, s.cluster)}, matchers...)...)
+}
+
+// round rounds an amount to cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
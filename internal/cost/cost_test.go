@@ -0,0 +1,124 @@
+package cost
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+const gib = 1024 * 1024 * 1024
+
+// newCostServer serves 4 replicas of web and 1 of api, web running two pods
+// on the highmem pool and one on the general pool, next to three pods of
+// web-api on the general pool
+func newCostServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+
+		response := `{"data": {"result": []}}`
+		switch {
+		case strings.Contains(query, "kube_deployment_spec_replicas"):
+			response = `{"data": {"result": [
+				{"metric": {"deployment": "web"}, "value": [1, "4"]},
+				{"metric": {"deployment": "api"}, "value": [1, "1"]}]}}`
+		case strings.Contains(query, "kube_node_labels"):
+			response = `{"data": {"result": [
+				{"metric": {"node": "node-1", "label_pool": "highmem"}, "value": [1, "1"]},
+				{"metric": {"node": "node-2", "label_pool": "general"}, "value": [1, "1"]}]}}`
+		case strings.Contains(query, "kube_pod_info"):
+			response = `{"data": {"result": [
+				{"metric": {"pod": "web-5d8f7c9b6-aaaaa", "node": "node-1"}, "value": [1, "1"]},
+				{"metric": {"pod": "web-5d8f7c9b6-bbbbb", "node": "node-1"}, "value": [1, "1"]},
+				{"metric": {"pod": "web-5d8f7c9b6-ccccc", "node": "node-2"}, "value": [1, "1"]},
+				{"metric": {"pod": "web-api-6b7c8d9f4-eeeee", "node": "node-2"}, "value": [1, "1"]},
+				{"metric": {"pod": "web-api-6b7c8d9f4-fffff", "node": "node-2"}, "value": [1, "1"]},
+				{"metric": {"pod": "web-api-6b7c8d9f4-ggggg", "node": "node-2"}, "value": [1, "1"]},
+				{"metric": {"pod": "api-7c9d6f5b8-ddddd", "node": "node-2"}, "value": [1, "1"]}]}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+func TestEstimator_Run(t *testing.T) {
+	server, queries := newCostServer(t)
+	prices := &Prices{Currency: "EUR", Default: 0.01, NodePools: map[string]float64{"highmem": 0.02}}
+	e := New(prometheus.NewClient(server.URL, 30*time.Second), prometheus.DefaultProfile(), prices)
+	e.SetClusterLabel("cluster")
+	e.SetNodePoolLabel("label_pool")
+
+	recommendations := []types.RecommendationResult{
+		{Cluster: "prod", Namespace: "shop", Deployment: "web", Container: "app", CurrentRequestBytes: 2 * gib, RecommendedRequestBytes: gib},
+		{Cluster: "prod", Namespace: "shop", Deployment: "api", Container: "app", CurrentRequestBytes: gib, RecommendedRequestBytes: 1.5 * gib},
+	}
+	if err := e.Run(context.Background(), recommendations); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 4 replicas of 2 GiB then 1 GiB at 0.02 per GiB-hour, the pods of web-api
+	// leaving the pool of web alone
+	web := &types.Cost{Currency: "EUR", PricePerGiBHour: 0.02, PriceSource: SourceNodePool, NodePool: "highmem", Replicas: 4,
+		CurrentMonthly: 116.8, RecommendedMonthly: 58.4, AnnualSavings: 700.8}
+	if !reflect.DeepEqual(recommendations[0].Cost, web) {
+		t.Errorf("Expected %+v, got %+v", web, recommendations[0].Cost)
+	}
+	api := &types.Cost{Currency: "EUR", PricePerGiBHour: 0.01, PriceSource: SourceDefault, NodePool: "general", Replicas: 1,
+		CurrentMonthly: 7.3, RecommendedMonthly: 10.95, AnnualSavings: -43.8}
+	if !reflect.DeepEqual(recommendations[1].Cost, api) {
+		t.Errorf("Expected %+v, got %+v", api, recommendations[1].Cost)
+	}
+
+	// The queries of the namespace run once
+	expected := []string{
+		`max by (deployment) (kube_deployment_spec_replicas{cluster="prod", namespace="shop"})`,
+		`max by (node, label_pool) (kube_node_labels{cluster="prod"})`,
+		`max by (pod, node) (kube_pod_info{cluster="prod", namespace="shop"})`,
+	}
+	if !reflect.DeepEqual(*queries, expected) {
+		t.Errorf("Expected queries %v, got %v", expected, *queries)
+	}
+}
+
+func TestEstimator_Run_Unpriced(t *testing.T) {
+	server, queries := newCostServer(t)
+	prices := &Prices{Currency: "USD", Clusters: map[string]float64{"prod": 0.01}}
+	e := New(prometheus.NewClient(server.URL, 30*time.Second), prometheus.DefaultProfile(), prices)
+
+	recommendations := []types.RecommendationResult{
+		{Namespace: "shop", Deployment: "web", Container: "app", CurrentRequestBytes: gib},
+		{Namespace: "shop", Deployment: "gone", Container: "app", CurrentRequestBytes: gib},
+	}
+	if err := e.Run(context.Background(), recommendations); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if recommendations[0].Cost != nil || recommendations[1].Cost != nil {
+		t.Errorf("Expected the containers outside the priced cluster to be left unpriced, got %+v", recommendations)
+	}
+	if len(*queries) != 1 {
+		t.Errorf("Expected no node pool queries without a node pool label, got %v", *queries)
+	}
+
+	prices.Default = 0.01
+	if err := e.Run(context.Background(), recommendations); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c := recommendations[1].Cost; c == nil || c.Replicas != 1 || c.CurrentMonthly != 7.3 {
+		t.Errorf("Expected a deployment without replicas priced as one replica, got %+v", c)
+	}
+}
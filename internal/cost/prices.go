@@ -0,0 +1,125 @@
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Price sources, from the most specific
+const (
+	SourceNamespace = "namespace"
+	SourceNodePool  = "node pool"
+	SourceCluster   = "cluster"
+	SourceAPI       = "api"
+	SourceDefault   = "default"
+)
+
+// Prices holds the price of a GiB of memory request per hour. The price of
+// a container is the first set of its namespace, node pool and cluster
+// prices, then Default.
+type Prices struct {
+	Currency string
+	Default  float64
+
+	// DefaultSource tells where Default came from, SourceDefault or SourceAPI
+	DefaultSource string
+
+	Namespaces map[string]float64
+	NodePools  map[string]float64
+	Clusters   map[string]float64
+}
+
+// Priced reports whether any price is set
+func (p *Prices) Priced() bool {
+	return p.Default > 0 || len(p.Namespaces) > 0 || len(p.NodePools) > 0 || len(p.Clusters) > 0
+}
+
+// price returns the price of a container of namespace on nodePool in
+// cluster, with its source. A zero price leaves the container unpriced.
+func (p *Prices) price(cluster, namespace, nodePool string) (float64, string) {
+	if price, ok := p.Namespaces[namespace]; ok {
+		return price, SourceNamespace
+	}
+	if price, ok := p.NodePools[nodePool]; ok && nodePool != "" {
+		return price, SourceNodePool
+	}
+	if price, ok := p.Clusters[cluster]; ok {
+		return price, SourceCluster
+	}
+	source := p.DefaultSource
+	if source == "" {
+		source = SourceDefault
+	}
+	return p.Default, source
+}
+
+// pricingResponse is the custom pricing returned by an OpenCost-style API,
+// its values being strings as OpenCost writes them
+type pricingResponse struct {
+	Code int `json:"code"`
+	Data struct {
+		RAM          json.RawMessage `json:"RAM"`
+		CurrencyCode string          `json:"currencyCode"`
+	} `json:"data"`
+}
+
+// FetchPrices sets the default price and currency of prices to the RAM price
+// per GiB-hour of an OpenCost-style pricing API at url, answering
+//
+//	{"code": 200, "data": {"RAM": "0.004237", "currencyCode": "USD"}}
+func FetchPrices(ctx context.Context, url string, timeout time.Duration, prices *Prices) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid pricing API URL %q: %w", url, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query the pricing API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pricing API returned status %d", resp.StatusCode)
+	}
+
+	var response pricingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to parse the pricing API response: %w", err)
+	}
+	if response.Code != 0 && response.Code != http.StatusOK {
+		return fmt.Errorf("pricing API returned code %d", response.Code)
+	}
+
+	// OpenCost quotes prices as strings, accept plain numbers as well
+	var raw interface{}
+	if err := json.Unmarshal(response.Data.RAM, &raw); err != nil {
+		return fmt.Errorf("pricing API response has no RAM price")
+	}
+	var price float64
+	switch value := raw.(type) {
+	case float64:
+		price = value
+	case string:
+		if price, err = strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("invalid RAM price %q from the pricing API", value)
+		}
+	default:
+		return fmt.Errorf("pricing API response has no RAM price")
+	}
+	if price < 0 {
+		return fmt.Errorf("invalid RAM price %g from the pricing API", price)
+	}
+
+	prices.Default = price
+	prices.DefaultSource = SourceAPI
+	if response.Data.CurrencyCode != "" {
+		prices.Currency = response.Data.CurrencyCode
+	}
+	return nil
+}
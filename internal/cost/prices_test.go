@@ -0,0 +1,93 @@
+package cost
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrices_Price(t *testing.T) {
+	prices := &Prices{
+		Default:    0.004,
+		Namespaces: map[string]float64{"batch": 0.002},
+		NodePools:  map[string]float64{"highmem": 0.003},
+		Clusters:   map[string]float64{"prod-us": 0.005},
+	}
+
+	tests := []struct {
+		cluster, namespace, nodePool string
+		price                        float64
+		source                       string
+	}{
+		{"prod-us", "batch", "highmem", 0.002, SourceNamespace},
+		{"prod-us", "shop", "highmem", 0.003, SourceNodePool},
+		{"prod-us", "shop", "", 0.005, SourceCluster},
+		{"prod-eu", "shop", "general", 0.004, SourceDefault},
+	}
+	for _, tt := range tests {
+		price, source := prices.price(tt.cluster, tt.namespace, tt.nodePool)
+		if price != tt.price || source != tt.source {
+			t.Errorf("%s/%s/%s: expected %g from %s, got %g from %s", tt.cluster, tt.namespace, tt.nodePool, tt.price, tt.source, price, source)
+		}
+	}
+
+	if (&Prices{Currency: "USD"}).Priced() {
+		t.Error("Expected prices without any price to be unpriced")
+	}
+	if !prices.Priced() {
+		t.Error("Expected prices to be priced")
+	}
+}
+
+func TestFetchPrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		price    float64
+		currency string
+		err      string
+	}{
+		{"OpenCost strings", `{"code": 200, "data": {"RAM": "0.004237", "currencyCode": "EUR"}}`, 0.004237, "EUR", ""},
+		{"Plain number", `{"data": {"RAM": 0.005}}`, 0.005, "USD", ""},
+		{"Missing RAM", `{"code": 200, "data": {"CPU": "0.031611"}}`, 0, "", "no RAM price"},
+		{"Invalid RAM", `{"code": 200, "data": {"RAM": "cheap"}}`, 0, "", `invalid RAM price "cheap"`},
+		{"Error code", `{"code": 500, "data": {}}`, 0, "", "returned code 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			prices := &Prices{Currency: "USD", Default: 0.001}
+			err := FetchPrices(context.Background(), server.URL, time.Second, prices)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if prices.Default != tt.price || prices.Currency != tt.currency || prices.DefaultSource != SourceAPI {
+				t.Errorf("Expected %g %s from the API, got %+v", tt.price, tt.currency, prices)
+			}
+		})
+	}
+}
+
+func TestFetchPrices_Status(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if err := FetchPrices(context.Background(), server.URL, time.Second, &Prices{}); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("Expected a status error, got %v", err)
+	}
+}
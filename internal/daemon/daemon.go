@@ -0,0 +1,165 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"kubernetes-resources-recommend/internal/history"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// Defaults of the schedule
+const (
+	DefaultInterval = 6 * time.Hour
+	DefaultJitter   = 5 * time.Minute
+)
+
+// GenerateFunc generates the recommendations of a namespace
+type GenerateFunc func(ctx context.Context, namespace string) ([]types.RecommendationResult, error)
+
+// Daemon re-runs the recommendations of namespaces on a schedule, recording
+// every run in a history ring and logging what changed since the previous run
+type Daemon struct {
+	generate   GenerateFunc
+	ring       *history.Ring
+	namespaces []string
+	interval   time.Duration
+	jitter     time.Duration
+	threshold  history.Threshold
+	store      *history.Store
+	runConfig  func(namespace string) *types.RecommendationConfig
+
+	now    func() time.Time
+	random func(n int64) int64
+}
+
+// New creates a daemon running namespaces every DefaultInterval, each after a
+// random delay of up to DefaultJitter
+func New(generate GenerateFunc, ring *history.Ring, namespaces []string) *Daemon {
+	return &Daemon{
+		generate:   generate,
+		ring:       ring,
+		namespaces: namespaces,
+		interval:   DefaultInterval,
+		jitter:     DefaultJitter,
+		now:        time.Now,
+		random:     rand.Int63n,
+	}
+}
+
+// SetInterval sets the time between the starts of two cycles over the namespaces
+func (d *Daemon) SetInterval(interval time.Duration) {
+	d.interval = interval
+}
+
+// SetJitter sets the maximum random delay before the run of each namespace, 0 for none
+func (d *Daemon) SetJitter(jitter time.Duration) {
+	d.jitter = jitter
+}
+
+// SetThreshold sets the move above which a recommendation is reported as changed
+func (d *Daemon) SetThreshold(threshold history.Threshold) {
+	d.threshold = threshold
+}
+
+// SetStore saves every run in store as well, with the configuration returned
+// by runConfig for its namespace
+func (d *Daemon) SetStore(store *history.Store, runConfig func(namespace string) *types.RecommendationConfig) {
+	d.store = store
+	d.runConfig = runConfig
+}
+
+// Run cycles over the namespaces until ctx is done. A failed run is logged
+// and retried on the next cycle.
+func (d *Daemon) Run(ctx context.Context) {
+	for {
+		start := d.now()
+		for _, namespace := range d.namespaces {
+			if !sleep(ctx, d.delay()) {
+				return
+			}
+			if _, err := d.RunOnce(ctx, namespace); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("Run of %s failed: %v", namespace, err)
+			}
+		}
+
+		next := d.interval - d.now().Sub(start)
+		log.Printf("Cycle completed, next in %v", next.Round(time.Second))
+		if !sleep(ctx, next) {
+			return
+		}
+	}
+}
+
+// RunOnce generates the recommendations of namespace, records the run and
+// logs the changes since the previous run
+func (d *Daemon) RunOnce(ctx context.Context, namespace string) (history.Run, error) {
+	run := history.Run{Namespace: namespace, StartedAt: d.now().UTC()}
+	recommendations, err := d.generate(ctx, namespace)
+	if err != nil {
+		return run, err
+	}
+	run.FinishedAt = d.now().UTC()
+	run.Recommendations = recommendations
+
+	previous, err := d.ring.Add(run)
+	if err != nil {
+		return run, fmt.Errorf("failed to record run: %w", err)
+	}
+	if d.store != nil {
+		if _, err := d.store.Save(run, d.runConfig(namespace)); err != nil {
+			return run, err
+		}
+	}
+	if previous == nil {
+		log.Printf("First run of %s: %d recommendations", namespace, len(recommendations))
+		return run, nil
+	}
+	logChanges(namespace, history.Compare(previous.Recommendations, recommendations, d.threshold))
+	return run, nil
+}
+
+// delay returns the random delay before the run of a namespace
+func (d *Daemon) delay() time.Duration {
+	if d.jitter <= 0 {
+		return 0
+	}
+	return time.Duration(d.random(int64(d.jitter)))
+}
+
+// logChanges logs the summary of changes, then one line per change
+func logChanges(namespace string, changes history.Changes) {
+	log.Printf("Changes of %s since the previous run: %s", namespace, changes.Summary())
+	for _, rec := range changes.Added {
+		log.Printf("  added %s: request %d MB, limit %d MB", history.KeyOf(rec), rec.RecommendedRequestMB, rec.RecommendedLimitMB)
+	}
+	for _, rec := range changes.Removed {
+		log.Printf("  removed %s", history.KeyOf(rec))
+	}
+	for _, change := range changes.Changed {
+		log.Printf("  changed %s: request %d -> %d MB (%+d), limit %d -> %d MB (%+d)", change.ContainerKey,
+			change.PreviousRequestMB, change.RequestMB, change.RequestDeltaMB(),
+			change.PreviousLimitMB, change.LimitMB, change.LimitDeltaMB())
+	}
+}
+
+// sleep waits for d, reporting false when ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,126 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/history"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestDaemon_RunOnce(t *testing.T) {
+	requestMB := int64(256)
+	d := New(func(_ context.Context, namespace string) ([]types.RecommendationResult, error) {
+		if namespace == "broken" {
+			return nil, errors.New("prometheus unreachable")
+		}
+		return []types.RecommendationResult{{Namespace: namespace, Deployment: "web", Container: "app", RecommendedRequestMB: requestMB}}, nil
+	}, history.NewRing(3), []string{"shop"})
+
+	for i := 0; i < 2; i++ {
+		run, err := d.RunOnce(context.Background(), "shop")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if run.StartedAt.IsZero() || run.FinishedAt.Before(run.StartedAt) {
+			t.Errorf("Unexpected run times: %v - %v", run.StartedAt, run.FinishedAt)
+		}
+		requestMB = 320
+	}
+
+	runs := d.ring.Runs("shop")
+	if len(runs) != 2 || runs[1].Recommendations[0].RecommendedRequestMB != 320 {
+		t.Errorf("Expected both runs recorded, got %+v", runs)
+	}
+
+	if _, err := d.RunOnce(context.Background(), "broken"); err == nil {
+		t.Error("Expected an error for a failed run")
+	}
+	if _, ok := d.ring.Latest("broken"); ok {
+		t.Error("Expected a failed run not to be recorded")
+	}
+}
+
+func TestDaemon_RunOnce_Store(t *testing.T) {
+	d := New(func(_ context.Context, namespace string) ([]types.RecommendationResult, error) {
+		return []types.RecommendationResult{{Namespace: namespace, Deployment: "web", Container: "app"}}, nil
+	}, history.NewRing(1), []string{"shop"})
+	store := history.NewStore(filepath.Join(t.TempDir(), "history.db"))
+	d.SetStore(store, func(namespace string) *types.RecommendationConfig {
+		return &types.RecommendationConfig{Namespace: namespace, CountDays: 7}
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := d.RunOnce(context.Background(), "shop"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// The store keeps every run, the ring the last one only
+	runs, err := store.Runs("shop")
+	if err != nil || len(runs) != 2 || runs[1].Config.CountDays != 7 {
+		t.Errorf("Expected 2 runs with their configuration, got %+v, %v", runs, err)
+	}
+}
+
+func TestDaemon_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var order []string
+	d := New(func(_ context.Context, namespace string) ([]types.RecommendationResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, namespace)
+		// Stop within the second cycle
+		if len(order) == 3 {
+			cancel()
+		}
+		return nil, nil
+	}, history.NewRing(5), []string{"shop", "billing"})
+	d.SetInterval(time.Millisecond)
+
+	var delays []int64
+	d.random = func(n int64) int64 {
+		delays = append(delays, n)
+		return 0
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Daemon did not stop")
+	}
+
+	if len(order) != 3 || order[0] != "shop" || order[1] != "billing" || order[2] != "shop" {
+		t.Errorf("Expected the namespaces in turn, got %v", order)
+	}
+	if len(delays) == 0 || delays[0] != int64(DefaultJitter) {
+		t.Errorf("Expected delays drawn up to the jitter, got %v", delays)
+	}
+	if runs := d.ring.Runs("shop"); len(runs) != 2 {
+		t.Errorf("Expected 2 runs of shop, got %d", len(runs))
+	}
+}
+
+func TestDaemon_delay(t *testing.T) {
+	d := New(nil, history.NewRing(1), nil)
+	d.SetJitter(0)
+	d.random = func(int64) int64 {
+		t.Error("Expected no random delay without jitter")
+		return 0
+	}
+	if delay := d.delay(); delay != 0 {
+		t.Errorf("Expected no delay, got %v", delay)
+	}
+}
@@ -0,0 +1,100 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// CSVExporter exports recommendations as CSV, with one header named after
+// each JSON field of RecommendationResult so the columns load straight into
+// tables such as BigQuery
+type CSVExporter struct {
+	filename string
+	stdout   io.Writer
+}
+
+// NewCSVExporter creates a new CSV exporter, writing to stdout when filename is Stdout
+func NewCSVExporter(filename string) *CSVExporter {
+	return &CSVExporter{
+		filename: filename,
+	}
+}
+
+// csvHeader lists the CSV columns, in the order of csvRecord
+var csvHeader = []string{
+	"cluster", "namespace", "deployment", "container",
+	"current_request_mb", "current_limit_mb", "current_request_bytes", "current_limit_bytes",
+	"recommended_request_mb", "recommended_limit_mb", "recommended_request_bytes", "recommended_limit_bytes",
+	"request_optimization_mb", "limit_optimization_mb", "request_optimization_percent", "limit_optimization_percent",
+	"memory_limit_multiplier",
+}
+
+// csvCostHeader lists the columns appended when any recommendation is priced,
+// named after the JSON fields of Cost
+var csvCostHeader = []string{
+	"cost_currency", "cost_price_per_gib_hour", "cost_price_source", "cost_node_pool", "cost_replicas",
+	"cost_current_monthly", "cost_recommended_monthly", "cost_annual_savings",
+}
+
+// Export writes a header row followed by one row per recommendation, with
+// the cost columns when any recommendation is priced
+func (e *CSVExporter) Export(recommendations []types.RecommendationResult) error {
+	_, priced := costCurrency(recommendations)
+	return writeOutput(e.filename, e.stdout, func(w io.Writer) error {
+		writer := csv.NewWriter(w)
+		header := csvHeader
+		if priced {
+			header = append(append([]string(nil), csvHeader...), csvCostHeader...)
+		}
+		if err := writer.Write(header); err != nil {
+			return fmt.Errorf("failed to write CSV header: %w", err)
+		}
+		for _, rec := range recommended(recommendations) {
+			record := csvRecord(rec)
+			if priced {
+				record = append(record, csvCostRecord(rec.Cost)...)
+			}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write CSV record: %w", err)
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+}
+
+// csvRecord converts a recommendation to a CSV row
+func csvRecord(rec types.RecommendationResult) []string {
+	integer := func(v int64) string { return strconv.FormatInt(v, 10) }
+	float := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+	return []string{
+		rec.Cluster, rec.Namespace, rec.Deployment, rec.Container,
+		integer(rec.CurrentRequestMB), integer(rec.CurrentLimitMB), float(rec.CurrentRequestBytes), float(rec.CurrentLimitBytes),
+		integer(rec.RecommendedRequestMB), integer(rec.RecommendedLimitMB), float(rec.RecommendedRequestBytes), float(rec.RecommendedLimitBytes),
+		integer(rec.RequestOptimizationMB), integer(rec.LimitOptimizationMB),
+		strconv.FormatFloat(rec.RequestOptimizationPct, 'f', 2, 64), strconv.FormatFloat(rec.LimitOptimizationPct, 'f', 2, 64),
+		float(rec.MemoryLimitMultiplier),
+	}
+}
+
+// csvCostRecord converts the cost of a recommendation to the cost columns, empty when unpriced
+func csvCostRecord(cost *types.Cost) []string {
+	if cost == nil {
+		return make([]string, len(csvCostHeader))
+	}
+	float := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return []string{
+		cost.Currency, float(cost.PricePerGiBHour), cost.PriceSource, cost.NodePool, strconv.Itoa(cost.Replicas),
+		float(cost.CurrentMonthly), float(cost.RecommendedMonthly), float(cost.AnnualSavings),
+	}
+}
+
+// GetFilename returns the filename that will be used for export
+func (e *CSVExporter) GetFilename() string {
+	return e.filename
+}
@@ -0,0 +1,105 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestCSVExporter_Export(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewCSVExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()
+	recs[0].Cluster = "prod-eu"
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %d rows", len(records))
+	}
+
+	header := records[0]
+	if len(header) != len(csvHeader) || header[0] != "cluster" || header[len(header)-1] != "memory_limit_multiplier" {
+		t.Errorf("Unexpected header: %v", header)
+	}
+	for i, record := range records {
+		if len(record) != len(header) {
+			t.Errorf("Row %d has %d fields, expected %d", i, len(record), len(header))
+		}
+	}
+
+	expected := map[string]string{
+		"cluster":                      "prod-eu",
+		"deployment":                   "web-app",
+		"recommended_request_mb":       "256",
+		"request_optimization_percent": "50.00",
+		"memory_limit_multiplier":      "1.5",
+	}
+	for i, name := range header {
+		if value, ok := expected[name]; ok && records[1][i] != value {
+			t.Errorf("Expected %s '%s', got '%s'", name, value, records[1][i])
+		}
+	}
+
+	// Values containing separators survive the round trip
+	if records[2][2] != "api|server" {
+		t.Errorf("Expected deployment 'api|server', got '%s'", records[2][2])
+	}
+}
+
+func TestCSVExporter_Export_Costs(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewCSVExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()
+	recs[0].Cost = &types.Cost{Currency: "EUR", PricePerGiBHour: 0.004, PriceSource: "node pool", NodePool: "highmem", Replicas: 3,
+		CurrentMonthly: 4.38, RecommendedMonthly: 2.19, AnnualSavings: 26.28}
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	header := records[0]
+	if len(header) != len(csvHeader)+len(csvCostHeader) || header[len(header)-1] != "cost_annual_savings" {
+		t.Fatalf("Unexpected header: %v", header)
+	}
+	costs := records[1][len(csvHeader):]
+	expected := []string{"EUR", "0.004", "node pool", "highmem", "3", "4.38", "2.19", "26.28"}
+	for i, value := range expected {
+		if costs[i] != value {
+			t.Errorf("Expected %s '%s', got '%s'", csvCostHeader[i], value, costs[i])
+		}
+	}
+	for i, value := range records[2][len(csvHeader):] {
+		if value != "" {
+			t.Errorf("Expected an empty %s for the unpriced row, got '%s'", csvCostHeader[i], value)
+		}
+	}
+}
+
+func TestCSVExporter_Export_Empty(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewCSVExporter(Stdout)
+	exporter.stdout = &stdout
+
+	if err := exporter.Export(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	records, _ := csv.NewReader(&stdout).ReadAll()
+	if len(records) != 1 {
+		t.Errorf("Expected only the header row, got %d rows", len(records))
+	}
+}
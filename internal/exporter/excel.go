@@ -2,6 +2,10 @@ package exporter
 
 import (
 	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
 
 	"kubernetes-resources-recommend/internal/types"
 
@@ -11,40 +15,86 @@ import (
 // ExcelExporter handles exporting recommendations to Excel format
 type ExcelExporter struct {
 	filename string
+	stdout   io.Writer
 }
 
-// NewExcelExporter creates a new Excel exporter
+// NewExcelExporter creates a new Excel exporter, writing to stdout when filename is Stdout
 func NewExcelExporter(filename string) *ExcelExporter {
 	return &ExcelExporter{
 		filename: filename,
 	}
 }
 
-// Export saves recommendations to an Excel file
+// recommendationsSheet holds every recommendation, followed by the summary statistics
+const recommendationsSheet = "Resource Recommendations"
+
+// Export saves recommendations to an Excel file. Besides the sheet of every
+// recommendation, a workbook covering several namespaces gets a rollup of the
+// namespaces ranked by savings and one sheet per namespace.
 func (e *ExcelExporter) Export(recommendations []types.RecommendationResult) error {
 	f := excelize.NewFile()
 	defer f.Close()
 
-	sheetName := "Resource Recommendations"
-	index, err := f.NewSheet(sheetName)
-	if err != nil {
-		return fmt.Errorf("failed to create sheet: %w", err)
+	if err := e.addRecommendationSheet(f, recommendationsSheet, "Recommendations", recommendations); err != nil {
+		return err
 	}
 
-	// Set headers
-	headers := []string{
-		"Namespace", "Deployment", "Container",
-		"Current Request (MB)", "Current Limit (MB)",
-		"Recommended Request (MB)", "Recommended Limit (MB)",
-		"Request Optimization (MB)", "Limit Optimization (MB)",
-		"Request Optimization (%)", "Limit Optimization (%)",
+	// Drop the default sheet of new workbooks
+	if err := f.DeleteSheet("Sheet1"); err != nil {
+		return fmt.Errorf("failed to delete default sheet: %w", err)
 	}
-	for i, header := range headers {
-		col := string(rune('A' + i))
-		if i >= 26 {
-			col = string(rune('A'+i/26-1)) + string(rune('A'+i%26))
+
+	if namespaces := groupByNamespace(recommendations); len(namespaces) > 1 {
+		if err := e.addNamespaceRollup(f, namespaces); err != nil {
+			return err
 		}
-		f.SetCellValue(sheetName, col+"1", header)
+		used := map[string]bool{recommendationsSheet: true, namespaceRollupSheet: true}
+		for i, ns := range namespaces {
+			sheetName := namespaceSheetName(ns.namespace, used)
+			if err := e.addRecommendationSheet(f, sheetName, fmt.Sprintf("Namespace%d", i+1), ns.recommendations); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Add the daily and hourly usage behind the recommendations
+	if err := e.addUsageSheets(f, recommendations); err != nil {
+		return err
+	}
+
+	index, _ := f.GetSheetIndex(recommendationsSheet)
+	f.SetActiveSheet(index)
+
+	if e.filename == Stdout {
+		return writeOutput(e.filename, e.stdout, func(w io.Writer) error {
+			if _, err := f.WriteTo(w); err != nil {
+				return fmt.Errorf("failed to write Excel file: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := f.SaveAs(e.filename); err != nil {
+		return fmt.Errorf("failed to save Excel file: %w", err)
+	}
+
+	return nil
+}
+
+// addRecommendationSheet writes recommendations to a new sheet as an Excel
+// Table named tableName, with a frozen header row, followed by their summary
+// statistics
+func (e *ExcelExporter) addRecommendationSheet(f *excelize.File, sheetName, tableName string, recommendations []types.RecommendationResult) error {
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return fmt.Errorf("failed to create sheet: %w", err)
+	}
+
+	columns := reportColumns(recommendations)
+	lastCol := columnName(len(columns))
+
+	// Set headers
+	for i, column := range columns {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetName, cell, column.header)
 	}
 
 	// Style headers
@@ -53,27 +103,19 @@ func (e *ExcelExporter) Export(recommendations []types.RecommendationResult) err
 		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E0E0E0"}, Pattern: 1},
 	})
 	if err == nil {
-		lastCol := string(rune('A' + len(headers) - 1))
-		if len(headers) > 26 {
-			lastCol = string(rune('A'+(len(headers)-1)/26-1)) + string(rune('A'+(len(headers)-1)%26))
-		}
 		f.SetCellStyle(sheetName, "A1", lastCol+"1", headerStyle)
 	}
 
-	// Add data
+	// Add data, amounts of money as numbers shown with cents
+	amountStyle, _ := f.NewStyle(&excelize.Style{NumFmt: amountNumFmt})
 	for i, rec := range recommendations {
-		row := i + 2
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), rec.Namespace)
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), rec.Deployment)
-		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), rec.Container)
-		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), rec.CurrentRequestMB)
-		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), rec.CurrentLimitMB)
-		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), rec.RecommendedRequestMB)
-		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), rec.RecommendedLimitMB)
-		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), rec.RequestOptimizationMB)
-		f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), rec.LimitOptimizationMB)
-		f.SetCellValue(sheetName, fmt.Sprintf("J%d", row), fmt.Sprintf("%.1f%%", rec.RequestOptimizationPct))
-		f.SetCellValue(sheetName, fmt.Sprintf("K%d", row), fmt.Sprintf("%.1f%%", rec.LimitOptimizationPct))
+		for j, column := range columns {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			f.SetCellValue(sheetName, cell, column.value(rec))
+			if column.amount {
+				f.SetCellStyle(sheetName, cell, cell, amountStyle)
+			}
+		}
 	}
 
 	// Add conditional formatting for optimization columns
@@ -86,47 +128,315 @@ func (e *ExcelExporter) Export(recommendations []types.RecommendationResult) err
 		Font: &excelize.Font{Color: "#9C0006"},
 		Fill: excelize.Fill{Type: "pattern", Color: []string{"#FFC7CE"}, Pattern: 1},
 	})
+	optimizationAmountStyle, _ := f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Color: "#006100"},
+		Fill:   excelize.Fill{Type: "pattern", Color: []string{"#C6EFCE"}, Pattern: 1},
+		NumFmt: amountNumFmt,
+	})
+	increaseAmountStyle, _ := f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Color: "#9C0006"},
+		Fill:   excelize.Fill{Type: "pattern", Color: []string{"#FFC7CE"}, Pattern: 1},
+		NumFmt: amountNumFmt,
+	})
 
 	// Apply conditional formatting
 	for i, rec := range recommendations {
-		row := i + 2
 		// Color code optimization columns based on savings/increase
-		if rec.RequestOptimizationMB > 0 {
-			f.SetCellStyle(sheetName, fmt.Sprintf("H%d", row), fmt.Sprintf("H%d", row), optimizationStyle)
-			f.SetCellStyle(sheetName, fmt.Sprintf("J%d", row), fmt.Sprintf("J%d", row), optimizationStyle)
-		} else if rec.RequestOptimizationMB < 0 {
-			f.SetCellStyle(sheetName, fmt.Sprintf("H%d", row), fmt.Sprintf("H%d", row), increaseStyle)
-			f.SetCellStyle(sheetName, fmt.Sprintf("J%d", row), fmt.Sprintf("J%d", row), increaseStyle)
+		for j, column := range columns {
+			if column.optimization == nil {
+				continue
+			}
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			saving, increase := optimizationStyle, increaseStyle
+			if column.amount {
+				saving, increase = optimizationAmountStyle, increaseAmountStyle
+			}
+			if optimization := column.optimization(rec); optimization > 0 {
+				f.SetCellStyle(sheetName, cell, cell, saving)
+			} else if optimization < 0 {
+				f.SetCellStyle(sheetName, cell, cell, increase)
+			}
 		}
+	}
 
-		if rec.LimitOptimizationMB > 0 {
-			f.SetCellStyle(sheetName, fmt.Sprintf("I%d", row), fmt.Sprintf("I%d", row), optimizationStyle)
-			f.SetCellStyle(sheetName, fmt.Sprintf("K%d", row), fmt.Sprintf("K%d", row), optimizationStyle)
-		} else if rec.LimitOptimizationMB < 0 {
-			f.SetCellStyle(sheetName, fmt.Sprintf("I%d", row), fmt.Sprintf("I%d", row), increaseStyle)
-			f.SetCellStyle(sheetName, fmt.Sprintf("K%d", row), fmt.Sprintf("K%d", row), increaseStyle)
+	// Turn the data into a filterable table, keeping the header in view
+	if len(recommendations) > 0 {
+		if err := addTable(f, sheetName, tableName, len(columns), len(recommendations)+1); err != nil {
+			return err
 		}
 	}
+	f.SetPanes(sheetName, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
 
 	// Add summary statistics
-	e.addSummarySection(f, sheetName, recommendations, len(recommendations)+4)
+	e.addSummarySection(f, sheetName, recommendations, len(recommendations)+4, len(columns))
 
 	// Auto-fit columns
-	for i := 0; i < len(headers); i++ {
-		col := string(rune('A' + i))
-		if i >= 26 {
-			col = string(rune('A'+i/26-1)) + string(rune('A'+i%26))
+	f.SetColWidth(sheetName, "A", lastCol, 20)
+	return nil
+}
+
+// addTable formats rows 1 to lastRow of the first columns of a sheet as an
+// Excel Table, with autofilter buttons on its header row
+func addTable(f *excelize.File, sheetName, tableName string, columns, lastRow int) error {
+	showRowStripes := true
+	err := f.AddTable(sheetName, &excelize.Table{
+		Range:          "A1:" + columnName(columns) + fmt.Sprint(lastRow),
+		Name:           tableName,
+		StyleName:      "TableStyleLight9",
+		ShowRowStripes: &showRowStripes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add table to %s: %w", sheetName, err)
+	}
+	return nil
+}
+
+// column describes a single column of the recommendations sheet
+type column struct {
+	header string
+	value  func(rec types.RecommendationResult) interface{}
+
+	// optimization returns the value deciding the savings/increase color, nil for plain columns
+	optimization func(rec types.RecommendationResult) int64
+
+	// amount shows the numeric value as an amount of money with cents
+	amount bool
+}
+
+// text renders the value of a column for text reports, amounts with cents
+func (c column) text(rec types.RecommendationResult) string {
+	value := c.value(rec)
+	if amount, ok := value.(float64); ok && c.amount {
+		return formatAmount(amount)
+	}
+	return fmt.Sprint(value)
+}
+
+// amountNumFmt is the built-in Excel number format 0.00
+const amountNumFmt = 2
+
+// reportColumns returns the columns of the report of recommendations: the
+// recommendations sheet layout, followed by the cost, backtest and
+// verification columns when any recommendation carries them
+func reportColumns(recommendations []types.RecommendationResult) []column {
+	columns := recommendationColumns(hasClusters(recommendations))
+	if currency, ok := costCurrency(recommendations); ok {
+		columns = append(columns, costColumns(currency)...)
+	}
+	for _, rec := range recommendations {
+		if rec.Backtest != nil {
+			columns = append(columns, backtestColumns()...)
+			break
+		}
+	}
+	for _, rec := range recommendations {
+		if rec.Verification != nil {
+			columns = append(columns, verificationColumns()...)
+			break
 		}
-		f.SetColWidth(sheetName, col, col, 20)
 	}
+	return columns
+}
 
-	f.SetActiveSheet(index)
+// recommendationColumns returns the recommendations sheet layout
+func recommendationColumns(withCluster bool) []column {
+	requestOptimization := func(rec types.RecommendationResult) int64 { return rec.RequestOptimizationMB }
+	limitOptimization := func(rec types.RecommendationResult) int64 { return rec.LimitOptimizationMB }
 
-	if err := f.SaveAs(e.filename); err != nil {
-		return fmt.Errorf("failed to save Excel file: %w", err)
+	// Verification-only results leave the request columns empty
+	requests := func(value func(rec types.RecommendationResult) interface{}) func(rec types.RecommendationResult) interface{} {
+		return func(rec types.RecommendationResult) interface{} {
+			if rec.VerificationOnly {
+				return ""
+			}
+			return value(rec)
+		}
 	}
 
-	return nil
+	var columns []column
+	if withCluster {
+		columns = append(columns, column{header: "Cluster", value: func(rec types.RecommendationResult) interface{} { return rec.Cluster }})
+	}
+	columns = append(columns,
+		column{header: "Namespace", value: func(rec types.RecommendationResult) interface{} { return rec.Namespace }},
+		column{header: "Deployment", value: func(rec types.RecommendationResult) interface{} { return rec.Deployment }},
+		column{header: "Container", value: func(rec types.RecommendationResult) interface{} { return rec.Container }},
+		column{header: "Current Request (MB)", value: requests(func(rec types.RecommendationResult) interface{} { return rec.CurrentRequestMB })},
+		column{header: "Current Limit (MB)", value: requests(func(rec types.RecommendationResult) interface{} { return rec.CurrentLimitMB })},
+		column{header: "Recommended Request (MB)", value: requests(func(rec types.RecommendationResult) interface{} { return rec.RecommendedRequestMB })},
+		column{header: "Recommended Limit (MB)", value: requests(func(rec types.RecommendationResult) interface{} { return rec.RecommendedLimitMB })},
+		column{
+			header:       "Request Optimization (MB)",
+			value:        requests(func(rec types.RecommendationResult) interface{} { return rec.RequestOptimizationMB }),
+			optimization: requestOptimization,
+		},
+		column{
+			header:       "Limit Optimization (MB)",
+			value:        requests(func(rec types.RecommendationResult) interface{} { return rec.LimitOptimizationMB }),
+			optimization: limitOptimization,
+		},
+		column{
+			header: "Request Optimization (%)",
+			value: requests(func(rec types.RecommendationResult) interface{} {
+				return fmt.Sprintf("%.1f%%", rec.RequestOptimizationPct)
+			}),
+			optimization: requestOptimization,
+		},
+		column{
+			header: "Limit Optimization (%)",
+			value: requests(func(rec types.RecommendationResult) interface{} {
+				return fmt.Sprintf("%.1f%%", rec.LimitOptimizationPct)
+			}),
+			optimization: limitOptimization,
+		},
+	)
+	return columns
+}
+
+// costColumns returns the columns pricing the requests in currency, left empty
+// for recommendations without a cost and colored by the savings
+func costColumns(currency string) []column {
+	cost := func(field func(c *types.Cost) interface{}) func(rec types.RecommendationResult) interface{} {
+		return func(rec types.RecommendationResult) interface{} {
+			if rec.Cost == nil {
+				return ""
+			}
+			return field(rec.Cost)
+		}
+	}
+	savings := func(rec types.RecommendationResult) int64 {
+		if rec.Cost == nil {
+			return 0
+		}
+		return int64(math.Round(rec.Cost.AnnualSavings * 100))
+	}
+
+	return []column{
+		{header: "Replicas", value: cost(func(c *types.Cost) interface{} { return c.Replicas })},
+		{header: fmt.Sprintf("Price (%s/GiB-hour)", currency), value: cost(func(c *types.Cost) interface{} { return c.PricePerGiBHour })},
+		{header: "Price Source", value: cost(func(c *types.Cost) interface{} { return c.PriceSource })},
+		{header: fmt.Sprintf("Current Cost (%s/month)", currency), value: cost(func(c *types.Cost) interface{} { return c.CurrentMonthly }), amount: true},
+		{header: fmt.Sprintf("Recommended Cost (%s/month)", currency), value: cost(func(c *types.Cost) interface{} { return c.RecommendedMonthly }), amount: true},
+		{
+			header:       fmt.Sprintf("Annual Savings (%s)", currency),
+			value:        cost(func(c *types.Cost) interface{} { return c.AnnualSavings }),
+			optimization: savings,
+			amount:       true,
+		},
+	}
+}
+
+// costCurrency returns the currency of the first priced recommendation, false when none is priced
+func costCurrency(recommendations []types.RecommendationResult) (string, bool) {
+	for _, rec := range recommendations {
+		if rec.Cost != nil {
+			return rec.Cost.Currency, true
+		}
+	}
+	return "", false
+}
+
+// formatAmount renders an amount of money with cents
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// backtestColumns returns the columns of the backtest results, left empty for
+// recommendations without a backtest and colored as increases when exceeded
+func backtestColumns() []column {
+	backtest := func(field func(b *types.Backtest) interface{}) func(rec types.RecommendationResult) interface{} {
+		return func(rec types.RecommendationResult) interface{} {
+			if rec.Backtest == nil {
+				return ""
+			}
+			return field(rec.Backtest)
+		}
+	}
+	exceeded := func(pods func(b *types.Backtest) int) func(rec types.RecommendationResult) int64 {
+		return func(rec types.RecommendationResult) int64 {
+			if rec.Backtest == nil {
+				return 0
+			}
+			return -int64(pods(rec.Backtest))
+		}
+	}
+	overRequest := exceeded(func(b *types.Backtest) int { return b.PodsOverRequest })
+	overLimit := exceeded(func(b *types.Backtest) int { return b.PodsOverLimit })
+
+	return []column{
+		{header: "Backtest Pods", value: backtest(func(b *types.Backtest) interface{} { return b.Pods })},
+		{header: "Pods Over Request", value: backtest(func(b *types.Backtest) interface{} { return b.PodsOverRequest }), optimization: overRequest},
+		{header: "Minutes Over Request", value: backtest(func(b *types.Backtest) interface{} { return b.MinutesOverRequest }), optimization: overRequest},
+		{header: "Pods Over Limit", value: backtest(func(b *types.Backtest) interface{} { return b.PodsOverLimit }), optimization: overLimit},
+		{header: "Minutes Over Limit", value: backtest(func(b *types.Backtest) interface{} { return b.MinutesOverLimit }), optimization: overLimit},
+		{header: "Backtest Peak (MB)", value: backtest(func(b *types.Backtest) interface{} { return int64(b.PeakBytes) / 1024 / 1024 })},
+	}
+}
+
+// verificationColumns returns the columns comparing the windows before and
+// after a change, left empty for recommendations not verified and colored as
+// increases when a regression was found
+func verificationColumns() []column {
+	verification := func(field func(v *types.Verification) interface{}) func(rec types.RecommendationResult) interface{} {
+		return func(rec types.RecommendationResult) interface{} {
+			if rec.Verification == nil {
+				return ""
+			}
+			return field(rec.Verification)
+		}
+	}
+	// The evictions of a deployment are shown on its first container only
+	evictions := func(field func(e *types.VerificationEvictions) int) func(rec types.RecommendationResult) interface{} {
+		return verification(func(v *types.Verification) interface{} {
+			if v.Evictions == nil {
+				return ""
+			}
+			return field(v.Evictions)
+		})
+	}
+	regressions := func(rec types.RecommendationResult) int64 {
+		if rec.Verification == nil {
+			return 0
+		}
+		return -int64(len(rec.Verification.Regressions))
+	}
+
+	return []column{
+		{header: "Usage Before (MB)", value: verification(func(v *types.Verification) interface{} { return int64(v.Before.UsageBytes) / 1024 / 1024 })},
+		{header: "Usage After (MB)", value: verification(func(v *types.Verification) interface{} { return int64(v.After.UsageBytes) / 1024 / 1024 })},
+		{header: "OOM Kills Before", value: verification(func(v *types.Verification) interface{} { return v.Before.OOMKills })},
+		{header: "OOM Kills After", value: verification(func(v *types.Verification) interface{} { return v.After.OOMKills })},
+		{header: "Restarts Before", value: verification(func(v *types.Verification) interface{} { return v.Before.Restarts })},
+		{header: "Restarts After", value: verification(func(v *types.Verification) interface{} { return v.After.Restarts })},
+		{header: "Deployment Evictions Before", value: evictions(func(e *types.VerificationEvictions) int { return e.Before })},
+		{header: "Deployment Evictions After", value: evictions(func(e *types.VerificationEvictions) int { return e.After })},
+		{
+			header: "Regressions",
+			value: verification(func(v *types.Verification) interface{} {
+				if len(v.Regressions) == 0 {
+					return "none"
+				}
+				return strings.Join(v.Regressions, "; ")
+			}),
+			optimization: regressions,
+		},
+	}
+}
+
+// columnName returns the letters of a 1-based column number
+func columnName(col int) string {
+	name, _ := excelize.ColumnNumberToName(col)
+	return name
+}
+
+// hasClusters reports whether any recommendation carries a cluster
+func hasClusters(recommendations []types.RecommendationResult) bool {
+	for _, rec := range recommendations {
+		if rec.Cluster != "" {
+			return true
+		}
+	}
+	return false
 }
 
 // GetFilename returns the filename that will be used for export
@@ -135,14 +445,14 @@ func (e *ExcelExporter) GetFilename() string {
 }
 
 // addSummarySection adds a summary statistics section to the Excel file
-func (e *ExcelExporter) addSummarySection(f *excelize.File, sheetName string, recommendations []types.RecommendationResult, startRow int) {
+func (e *ExcelExporter) addSummarySection(f *excelize.File, sheetName string, recommendations []types.RecommendationResult, startRow, width int) {
 	// Calculate summary statistics
 	var totalCurrentRequestMB, totalCurrentLimitMB int64
 	var totalRecommendedRequestMB, totalRecommendedLimitMB int64
 	var totalRequestOptimizationMB, totalLimitOptimizationMB int64
 	var containerCount int
 
-	for _, rec := range recommendations {
+	for _, rec := range recommended(recommendations) {
 		totalCurrentRequestMB += rec.CurrentRequestMB
 		totalCurrentLimitMB += rec.CurrentLimitMB
 		totalRecommendedRequestMB += rec.RecommendedRequestMB
@@ -167,9 +477,11 @@ func (e *ExcelExporter) addSummarySection(f *excelize.File, sheetName string, re
 		Fill: excelize.Fill{Type: "pattern", Color: []string{"#4F81BD"}, Pattern: 1},
 	})
 
+	// The title spans the recommendation columns, and at least the summary table
+	titleEnd, _ := excelize.CoordinatesToCellName(max(width, 5), startRow)
 	f.SetCellValue(sheetName, fmt.Sprintf("A%d", startRow), "📊 Optimization Summary Statistics")
-	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", startRow), fmt.Sprintf("K%d", startRow), summaryTitleStyle)
-	f.MergeCell(sheetName, fmt.Sprintf("A%d", startRow), fmt.Sprintf("K%d", startRow))
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", startRow), titleEnd, summaryTitleStyle)
+	f.MergeCell(sheetName, fmt.Sprintf("A%d", startRow), titleEnd)
 
 	// Add summary data
 	summaryData := [][]interface{}{
@@ -178,6 +490,11 @@ func (e *ExcelExporter) addSummarySection(f *excelize.File, sheetName string, re
 		{"Memory Request (MB)", totalCurrentRequestMB, totalRecommendedRequestMB, totalRequestOptimizationMB, fmt.Sprintf("%.1f%%", totalRequestOptimizationPct)},
 		{"Memory Limit (MB)", totalCurrentLimitMB, totalRecommendedLimitMB, totalLimitOptimizationMB, fmt.Sprintf("%.1f%%", totalLimitOptimizationPct)},
 	}
+	var totals summaryTotals
+	for _, rec := range recommendations {
+		totals.add(rec)
+	}
+	summaryData = append(summaryData, totals.costCells()...)
 
 	// Style for summary headers
 	summaryHeaderStyle, _ := f.NewStyle(&excelize.Style{
@@ -201,17 +518,29 @@ func (e *ExcelExporter) addSummarySection(f *excelize.File, sheetName string, re
 		},
 	})
 
+	summaryAmountStyle, _ := f.NewStyle(&excelize.Style{
+		Border: []excelize.Border{
+			{Type: "left", Color: "000000", Style: 1},
+			{Type: "top", Color: "000000", Style: 1},
+			{Type: "bottom", Color: "000000", Style: 1},
+			{Type: "right", Color: "000000", Style: 1},
+		},
+		NumFmt: amountNumFmt,
+	})
+
 	// Add summary data rows
 	for i, row := range summaryData {
 		rowNum := startRow + 2 + i
 		for j, value := range row {
-			col := string(rune('A' + j))
-			f.SetCellValue(sheetName, col+fmt.Sprintf("%d", rowNum), value)
+			cell, _ := excelize.CoordinatesToCellName(j+1, rowNum)
+			f.SetCellValue(sheetName, cell, value)
 
 			if i == 0 {
-				f.SetCellStyle(sheetName, col+fmt.Sprintf("%d", rowNum), col+fmt.Sprintf("%d", rowNum), summaryHeaderStyle)
+				f.SetCellStyle(sheetName, cell, cell, summaryHeaderStyle)
+			} else if _, amount := value.(float64); amount {
+				f.SetCellStyle(sheetName, cell, cell, summaryAmountStyle)
 			} else {
-				f.SetCellStyle(sheetName, col+fmt.Sprintf("%d", rowNum), col+fmt.Sprintf("%d", rowNum), summaryDataStyle)
+				f.SetCellStyle(sheetName, cell, cell, summaryDataStyle)
 			}
 		}
 	}
@@ -235,4 +564,154 @@ func (e *ExcelExporter) addSummarySection(f *excelize.File, sheetName string, re
 	if totalLimitOptimizationMB > 0 {
 		f.SetCellStyle(sheetName, fmt.Sprintf("D%d", startRow+5), fmt.Sprintf("E%d", startRow+5), optimizationStyle)
 	}
+	if totals.pricedContainers > 0 && totals.annualSavings > 0 {
+		costOptimizationStyle, _ := f.NewStyle(&excelize.Style{
+			Font: &excelize.Font{Color: "#006100", Bold: true},
+			Fill: excelize.Fill{Type: "pattern", Color: []string{"#C6EFCE"}, Pattern: 1},
+			Border: []excelize.Border{
+				{Type: "left", Color: "000000", Style: 1},
+				{Type: "top", Color: "000000", Style: 1},
+				{Type: "bottom", Color: "000000", Style: 1},
+				{Type: "right", Color: "000000", Style: 1},
+			},
+			NumFmt: amountNumFmt,
+		})
+		f.SetCellStyle(sheetName, fmt.Sprintf("D%d", startRow+6), fmt.Sprintf("E%d", startRow+7), costOptimizationStyle)
+	}
+
+	// Break the totals down per cluster when analysing several clusters
+	if hasClusters(recommendations) {
+		e.addClusterSummary(f, sheetName, recommendations, startRow+3+len(summaryData), summaryHeaderStyle, summaryDataStyle)
+	}
+}
+
+// summaryTotals accumulates memory totals over a group of recommendations
+type summaryTotals struct {
+	containers            int
+	currentRequestMB      int64
+	currentLimitMB        int64
+	recommendedRequestMB  int64
+	recommendedLimitMB    int64
+	requestOptimizationMB int64
+	limitOptimizationMB   int64
+
+	// Costs of the priced recommendations, in the currency of the first
+	pricedContainers       int
+	currency               string
+	currentMonthlyCost     float64
+	recommendedMonthlyCost float64
+	annualSavings          float64
+}
+
+// add accumulates a single recommendation, skipping the verification-only results
+func (t *summaryTotals) add(rec types.RecommendationResult) {
+	if rec.VerificationOnly {
+		return
+	}
+	if rec.Cost != nil {
+		if t.pricedContainers == 0 {
+			t.currency = rec.Cost.Currency
+		}
+		t.pricedContainers++
+		t.currentMonthlyCost += rec.Cost.CurrentMonthly
+		t.recommendedMonthlyCost += rec.Cost.RecommendedMonthly
+		t.annualSavings += rec.Cost.AnnualSavings
+	}
+	t.containers++
+	t.currentRequestMB += rec.CurrentRequestMB
+	t.currentLimitMB += rec.CurrentLimitMB
+	t.recommendedRequestMB += rec.RecommendedRequestMB
+	t.recommendedLimitMB += rec.RecommendedLimitMB
+	t.requestOptimizationMB += rec.RequestOptimizationMB
+	t.limitOptimizationMB += rec.LimitOptimizationMB
+}
+
+// requestOptimizationPct returns the request savings relative to the current requests
+func (t *summaryTotals) requestOptimizationPct() float64 {
+	if t.currentRequestMB <= 0 {
+		return 0
+	}
+	return float64(t.requestOptimizationMB) / float64(t.currentRequestMB) * 100
+}
+
+// limitOptimizationPct returns the limit savings relative to the current limits
+func (t *summaryTotals) limitOptimizationPct() float64 {
+	if t.currentLimitMB <= 0 {
+		return 0
+	}
+	return float64(t.limitOptimizationMB) / float64(t.currentLimitMB) * 100
+}
+
+// costCells returns the summary rows of the monthly cost and annual savings of
+// the priced recommendations, the amounts as numbers, none when no
+// recommendation is priced
+func (t *summaryTotals) costCells() [][]interface{} {
+	if t.pricedContainers == 0 {
+		return nil
+	}
+	monthlySavings := t.currentMonthlyCost - t.recommendedMonthlyCost
+	var savingsPct float64
+	if t.currentMonthlyCost > 0 {
+		savingsPct = monthlySavings / t.currentMonthlyCost * 100
+	}
+	return [][]interface{}{
+		{fmt.Sprintf("Memory Cost (%s/month)", t.currency), t.currentMonthlyCost, t.recommendedMonthlyCost,
+			monthlySavings, fmt.Sprintf("%.1f%%", savingsPct)},
+		{fmt.Sprintf("Annual Savings (%s)", t.currency), "", "", t.annualSavings, ""},
+	}
+}
+
+// costRows returns the cost summary rows as text, the amounts with cents
+func (t *summaryTotals) costRows() [][]string {
+	var rows [][]string
+	for _, cells := range t.costCells() {
+		row := make([]string, len(cells))
+		for i, cell := range cells {
+			if amount, ok := cell.(float64); ok {
+				row[i] = formatAmount(amount)
+			} else {
+				row[i] = fmt.Sprint(cell)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// addClusterSummary adds a per-cluster breakdown of the summary statistics
+func (e *ExcelExporter) addClusterSummary(f *excelize.File, sheetName string, recommendations []types.RecommendationResult, startRow int, headerStyle, dataStyle int) {
+	var clusters []string
+	totals := make(map[string]*summaryTotals)
+	for _, rec := range recommendations {
+		if _, ok := totals[rec.Cluster]; !ok {
+			clusters = append(clusters, rec.Cluster)
+			totals[rec.Cluster] = &summaryTotals{}
+		}
+		totals[rec.Cluster].add(rec)
+	}
+	sort.Strings(clusters)
+
+	rows := [][]interface{}{
+		{"Cluster", "Containers",
+			"Current Request (MB)", "Recommended Request (MB)", "Request Optimization (MB)", "Request Optimization %",
+			"Current Limit (MB)", "Recommended Limit (MB)", "Limit Optimization (MB)", "Limit Optimization %"},
+	}
+	for _, cluster := range clusters {
+		t := totals[cluster]
+		rows = append(rows, []interface{}{cluster, t.containers,
+			t.currentRequestMB, t.recommendedRequestMB, t.requestOptimizationMB, fmt.Sprintf("%.1f%%", t.requestOptimizationPct()),
+			t.currentLimitMB, t.recommendedLimitMB, t.limitOptimizationMB, fmt.Sprintf("%.1f%%", t.limitOptimizationPct())})
+	}
+
+	for i, row := range rows {
+		style := dataStyle
+		if i == 0 {
+			style = headerStyle
+		}
+		for j, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, startRow+i)
+			f.SetCellValue(sheetName, cell, value)
+			f.SetCellStyle(sheetName, cell, cell, style)
+		}
+	}
 }
@@ -0,0 +1,151 @@
+package exporter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"kubernetes-resources-recommend/internal/types"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// namespaceRollupSheet ranks the namespaces of a workbook by savings
+const namespaceRollupSheet = "Namespace Summary"
+
+// maxSheetNameLength is the longest sheet name Excel accepts
+const maxSheetNameLength = 31
+
+// namespaceGroup holds the recommendations of one namespace, across clusters
+type namespaceGroup struct {
+	namespace       string
+	recommendations []types.RecommendationResult
+	totals          summaryTotals
+}
+
+// groupByNamespace groups recommendations by namespace, sorted by name,
+// keeping their order within each namespace
+func groupByNamespace(recommendations []types.RecommendationResult) []*namespaceGroup {
+	var groups []*namespaceGroup
+	byName := make(map[string]*namespaceGroup)
+	for _, rec := range recommendations {
+		group, ok := byName[rec.Namespace]
+		if !ok {
+			group = &namespaceGroup{namespace: rec.Namespace}
+			byName[rec.Namespace] = group
+			groups = append(groups, group)
+		}
+		group.recommendations = append(group.recommendations, rec)
+		group.totals.add(rec)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].namespace < groups[j].namespace })
+	return groups
+}
+
+// addNamespaceRollup adds the sheet of per-namespace totals, ranked by request
+// savings, as an Excel Table followed by the totals of every namespace
+func (e *ExcelExporter) addNamespaceRollup(f *excelize.File, namespaces []*namespaceGroup) error {
+	if _, err := f.NewSheet(namespaceRollupSheet); err != nil {
+		return fmt.Errorf("failed to create sheet: %w", err)
+	}
+
+	ranked := append([]*namespaceGroup(nil), namespaces...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].totals.requestOptimizationMB > ranked[j].totals.requestOptimizationMB
+	})
+
+	headers := []interface{}{"Rank", "Namespace", "Containers",
+		"Current Request (MB)", "Recommended Request (MB)", "Request Optimization (MB)", "Request Optimization (%)",
+		"Current Limit (MB)", "Recommended Limit (MB)", "Limit Optimization (MB)", "Limit Optimization (%)"}
+	rows := [][]interface{}{headers}
+
+	var total summaryTotals
+	for i, ns := range ranked {
+		t := ns.totals
+		rows = append(rows, append([]interface{}{i + 1, ns.namespace}, namespaceTotalsRow(t)...))
+		for _, rec := range ns.recommendations {
+			total.add(rec)
+		}
+	}
+
+	for i, row := range rows {
+		for j, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+1)
+			f.SetCellValue(namespaceRollupSheet, cell, value)
+		}
+	}
+	if err := addTable(f, namespaceRollupSheet, "NamespaceSummary", len(headers), len(rows)); err != nil {
+		return err
+	}
+	f.SetPanes(namespaceRollupSheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+
+	// Totals of every namespace, below the table so filtering leaves them alone
+	totalStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Border: []excelize.Border{
+			{Type: "top", Color: "000000", Style: 1},
+		},
+	})
+	totalRow := len(rows) + 2
+	for j, value := range append([]interface{}{"", "Total"}, namespaceTotalsRow(total)...) {
+		cell, _ := excelize.CoordinatesToCellName(j+1, totalRow)
+		f.SetCellValue(namespaceRollupSheet, cell, value)
+		f.SetCellStyle(namespaceRollupSheet, cell, cell, totalStyle)
+	}
+
+	f.SetColWidth(namespaceRollupSheet, "A", "A", 8)
+	f.SetColWidth(namespaceRollupSheet, "B", columnName(len(headers)), 20)
+	return nil
+}
+
+// namespaceTotalsRow returns the rollup columns following the rank and namespace
+func namespaceTotalsRow(t summaryTotals) []interface{} {
+	return []interface{}{t.containers,
+		t.currentRequestMB, t.recommendedRequestMB, t.requestOptimizationMB, fmt.Sprintf("%.1f%%", t.requestOptimizationPct()),
+		t.currentLimitMB, t.recommendedLimitMB, t.limitOptimizationMB, fmt.Sprintf("%.1f%%", t.limitOptimizationPct())}
+}
+
+// namespaceSheetName returns a valid sheet name for a namespace that is not
+// yet in used, and records it. Excel limits names to 31 characters and
+// forbids some punctuation, so long names are truncated and suffixed when
+// they collide.
+func namespaceSheetName(namespace string, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '_'
+		}
+		return r
+	}, namespace)
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "(none)"
+	}
+	name = truncateRunes(name, maxSheetNameLength)
+
+	candidate := name
+	for i := 2; usedFold(used, candidate); i++ {
+		suffix := fmt.Sprintf("~%d", i)
+		candidate = truncateRunes(name, maxSheetNameLength-len(suffix)) + suffix
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// usedFold reports whether name is in used, ignoring case like Excel does
+func usedFold(used map[string]bool, name string) bool {
+	for existing := range used {
+		if strings.EqualFold(existing, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateRunes returns the first n characters of s
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// multiNamespaceRecommendations returns recommendations over three namespaces,
+// the batch namespace saving the most request memory
+func multiNamespaceRecommendations() []types.RecommendationResult {
+	return []types.RecommendationResult{
+		{Namespace: "shop", Deployment: "api", Container: "app", CurrentRequestMB: 1000, RecommendedRequestMB: 800, RequestOptimizationMB: 200},
+		{Namespace: "batch", Deployment: "worker", Container: "job", CurrentRequestMB: 4000, RecommendedRequestMB: 1000, RequestOptimizationMB: 3000},
+		{Namespace: "shop", Deployment: "web", Container: "nginx", CurrentRequestMB: 500, RecommendedRequestMB: 400, RequestOptimizationMB: 100},
+		{Namespace: "auth", Deployment: "sso", Container: "app", CurrentRequestMB: 200, RecommendedRequestMB: 300, RequestOptimizationMB: -100},
+	}
+}
+
+func TestExcelExporter_Export_Namespaces(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "namespaces.xlsx")
+	if err := NewExcelExporter(filename).Export(multiNamespaceRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to open Excel file: %v", err)
+	}
+	defer f.Close()
+
+	expectedSheets := []string{recommendationsSheet, namespaceRollupSheet, "auth", "batch", "shop"}
+	if sheets := f.GetSheetList(); strings.Join(sheets, ",") != strings.Join(expectedSheets, ",") {
+		t.Errorf("Expected sheets %v, got %v", expectedSheets, sheets)
+	}
+	if f.GetSheetName(f.GetActiveSheetIndex()) != recommendationsSheet {
+		t.Errorf("Expected the recommendations sheet to be active, got %s", f.GetSheetName(f.GetActiveSheetIndex()))
+	}
+
+	// Namespaces are ranked by request savings
+	rows, _ := f.GetRows(namespaceRollupSheet)
+	expected := [][]string{
+		{"1", "batch", "1", "4000", "1000", "3000", "75.0%"},
+		{"2", "shop", "2", "1500", "1200", "300", "20.0%"},
+		{"3", "auth", "1", "200", "300", "-100", "-50.0%"},
+	}
+	for i, row := range expected {
+		if got := strings.Join(rows[i+1][:len(row)], ","); got != strings.Join(row, ",") {
+			t.Errorf("Expected rollup row %v, got %v", row, rows[i+1])
+		}
+	}
+	if rows[5][1] != "Total" || rows[5][2] != "4" || rows[5][5] != "3200" {
+		t.Errorf("Unexpected totals row: %v", rows[5])
+	}
+
+	// Each namespace sheet holds its own recommendations and summary
+	shop, _ := f.GetRows("shop")
+	if shop[1][1] != "api" || shop[2][1] != "web" {
+		t.Errorf("Unexpected shop rows: %v", shop[:3])
+	}
+	if count, _ := f.GetCellValue("shop", "B9"); count != "2" {
+		t.Errorf("Expected 2 containers in the shop summary, got '%s'", count)
+	}
+
+	for _, sheet := range []string{recommendationsSheet, namespaceRollupSheet, "shop"} {
+		tables, err := f.GetTables(sheet)
+		if err != nil || len(tables) != 1 {
+			t.Errorf("Expected a table on %s, got %v (%v)", sheet, tables, err)
+		}
+		panes, _ := f.GetPanes(sheet)
+		if !panes.Freeze || panes.YSplit != 1 {
+			t.Errorf("Expected a frozen header row on %s, got %+v", sheet, panes)
+		}
+	}
+	if tables, _ := f.GetTables(recommendationsSheet); len(tables) == 1 && tables[0].Range != "A1:K5" {
+		t.Errorf("Expected the table to cover the recommendations, got %s", tables[0].Range)
+	}
+}
+
+func TestExcelExporter_Export_SingleNamespace(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "single.xlsx")
+	if err := NewExcelExporter(filename).Export(sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to open Excel file: %v", err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); len(sheets) != 1 || sheets[0] != recommendationsSheet {
+		t.Errorf("Expected only the recommendations sheet, got %v", sheets)
+	}
+}
+
+func TestExcelExporter_addSummarySection_WideSheet(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	(&ExcelExporter{}).addSummarySection(f, "Sheet1", sampleRecommendations(), 4, 30)
+
+	merged, err := f.GetMergeCells("Sheet1")
+	if err != nil || len(merged) != 1 {
+		t.Fatalf("Expected a merged title, got %v (%v)", merged, err)
+	}
+	if merged[0].GetStartAxis() != "A4" || merged[0].GetEndAxis() != "AD4" {
+		t.Errorf("Expected the title to span A4:AD4, got %s:%s", merged[0].GetStartAxis(), merged[0].GetEndAxis())
+	}
+}
+
+func TestNamespaceSheetName(t *testing.T) {
+	used := map[string]bool{recommendationsSheet: true}
+
+	tests := []struct {
+		namespace string
+		expected  string
+	}{
+		{"shop", "shop"},
+		{"team/a:b", "team_a_b"},
+		{"a-very-long-namespace-name-exceeding-the-limit", "a-very-long-namespace-name-exce"},
+		{"a-very-long-namespace-name-exceeding-the-limit-too", "a-very-long-namespace-name-ex~2"},
+		{"Resource Recommendations", "Resource Recommendations~2"},
+		{"SHOP", "SHOP~2"},
+		{"", "(none)"},
+	}
+	for _, tt := range tests {
+		if name := namespaceSheetName(tt.namespace, used); name != tt.expected {
+			t.Errorf("Expected sheet name %q for %q, got %q", tt.expected, tt.namespace, name)
+		}
+	}
+}
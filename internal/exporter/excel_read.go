@@ -0,0 +1,99 @@
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ReadExcel loads the recommendations of a workbook written by ExcelExporter.
+// Only the columns of the recommendations sheet are restored: the byte
+// values are derived from the MB columns, and the usage sheets are ignored.
+func ReadExcel(filename string) ([]types.RecommendationResult, error) {
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Excel file: %w", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(recommendationsSheet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheet %q of %s: %w", recommendationsSheet, filename, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("sheet %q of %s has no header row", recommendationsSheet, filename)
+	}
+
+	index := make(map[string]int, len(rows[0]))
+	for i, header := range rows[0] {
+		index[header] = i
+	}
+	for _, column := range recommendationColumns(false) {
+		if _, ok := index[column.header]; !ok {
+			return nil, fmt.Errorf("sheet %q of %s has no %q column", recommendationsSheet, filename, column.header)
+		}
+	}
+
+	recommendations := []types.RecommendationResult{}
+	for i, row := range rows[1:] {
+		cell := func(header string) string {
+			if col, ok := index[header]; ok && col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		// The data rows end at the blank row before the summary section
+		if cell("Namespace") == "" {
+			break
+		}
+		// Verification-only rows carry no recommendation to read
+		if cell("Current Request (MB)") == "" && cell("Recommended Request (MB)") == "" {
+			continue
+		}
+
+		rec := types.RecommendationResult{
+			Cluster:    cell("Cluster"),
+			Namespace:  cell("Namespace"),
+			Deployment: cell("Deployment"),
+			Container:  cell("Container"),
+		}
+		line := i + 2
+		for _, field := range []struct {
+			header string
+			value  *int64
+		}{
+			{"Current Request (MB)", &rec.CurrentRequestMB},
+			{"Current Limit (MB)", &rec.CurrentLimitMB},
+			{"Recommended Request (MB)", &rec.RecommendedRequestMB},
+			{"Recommended Limit (MB)", &rec.RecommendedLimitMB},
+			{"Request Optimization (MB)", &rec.RequestOptimizationMB},
+			{"Limit Optimization (MB)", &rec.LimitOptimizationMB},
+		} {
+			if *field.value, err = strconv.ParseInt(cell(field.header), 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid %s in row %d of %s: %q", field.header, line, filename, cell(field.header))
+			}
+		}
+		for _, field := range []struct {
+			header string
+			value  *float64
+		}{
+			{"Request Optimization (%)", &rec.RequestOptimizationPct},
+			{"Limit Optimization (%)", &rec.LimitOptimizationPct},
+		} {
+			if *field.value, err = strconv.ParseFloat(strings.TrimSuffix(cell(field.header), "%"), 64); err != nil {
+				return nil, fmt.Errorf("invalid %s in row %d of %s: %q", field.header, line, filename, cell(field.header))
+			}
+		}
+
+		rec.CurrentRequestBytes = float64(rec.CurrentRequestMB) * 1024 * 1024
+		rec.CurrentLimitBytes = float64(rec.CurrentLimitMB) * 1024 * 1024
+		rec.RecommendedRequestBytes = float64(rec.RecommendedRequestMB) * 1024 * 1024
+		rec.RecommendedLimitBytes = float64(rec.RecommendedLimitMB) * 1024 * 1024
+		recommendations = append(recommendations, rec)
+	}
+	return recommendations, nil
+}
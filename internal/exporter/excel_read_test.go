@@ -0,0 +1,85 @@
+package exporter
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadExcel(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "report.xlsx")
+	recommendations := []types.RecommendationResult{
+		{
+			Cluster: "prod-eu", Namespace: "production", Deployment: "web-app", Container: "nginx",
+			CurrentRequestMB: 512, CurrentLimitMB: 1024, RecommendedRequestMB: 256, RecommendedLimitMB: 384,
+			RequestOptimizationMB: 256, LimitOptimizationMB: 640, RequestOptimizationPct: 50, LimitOptimizationPct: 62.5,
+		},
+		{
+			Namespace: "staging", Deployment: "api", Container: "app",
+			CurrentRequestMB: 128, CurrentLimitMB: 256, RecommendedRequestMB: 192, RecommendedLimitMB: 288,
+			RequestOptimizationMB: -64, LimitOptimizationMB: -32, RequestOptimizationPct: -50, LimitOptimizationPct: -12.5,
+		},
+	}
+	verified := append(recommendations, types.RecommendationResult{
+		Namespace: "staging", Deployment: "api", Container: "sidecar",
+		Verification: &types.Verification{}, VerificationOnly: true,
+	})
+	if err := NewExcelExporter(filename).Export(verified); err != nil {
+		t.Fatal(err)
+	}
+
+	// The verification-only row is left out
+	read, err := ReadExcel(filename)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(read) != len(recommendations) {
+		t.Fatalf("Expected %d recommendations, got %d: %+v", len(recommendations), len(read), read)
+	}
+	for i, expected := range recommendations {
+		expected.CurrentRequestBytes = float64(expected.CurrentRequestMB) * 1024 * 1024
+		expected.CurrentLimitBytes = float64(expected.CurrentLimitMB) * 1024 * 1024
+		expected.RecommendedRequestBytes = float64(expected.RecommendedRequestMB) * 1024 * 1024
+		expected.RecommendedLimitBytes = float64(expected.RecommendedLimitMB) * 1024 * 1024
+		if !reflect.DeepEqual(read[i], expected) {
+			t.Errorf("Recommendation %d:\nexpected %+v\ngot      %+v", i, expected, read[i])
+		}
+	}
+}
+
+func TestReadExcel_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := ReadExcel(filepath.Join(dir, "missing.xlsx")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	other := filepath.Join(dir, "other.xlsx")
+	f := excelize.NewFile()
+	f.SetCellValue("Sheet1", "A1", "Namespace")
+	if err := f.SaveAs(other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadExcel(other); err == nil {
+		t.Error("Expected an error for a workbook without the recommendations sheet")
+	}
+
+	invalid := filepath.Join(dir, "invalid.xlsx")
+	f = excelize.NewFile()
+	f.NewSheet(recommendationsSheet)
+	for i, column := range recommendationColumns(false) {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(recommendationsSheet, cell, column.header)
+	}
+	f.SetSheetRow(recommendationsSheet, "A2", &[]interface{}{"production", "web-app", "nginx", "lots"})
+	if err := f.SaveAs(invalid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadExcel(invalid); err == nil {
+		t.Error("Expected an error for a non-numeric cell")
+	}
+}
@@ -2,6 +2,7 @@ package exporter
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"kubernetes-resources-recommend/internal/types"
@@ -250,6 +251,121 @@ func TestExcelExporter_Export_SummarySection(t *testing.T) {
 	}
 }
 
+func TestExcelExporter_Export_Clusters(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test-clusters.xlsx")
+	exporter := NewExcelExporter(filename)
+
+	recommendations := []types.RecommendationResult{
+		{Cluster: "prod-us", Namespace: "shop", Deployment: "api", Container: "app", CurrentRequestMB: 1000, RecommendedRequestMB: 600, RequestOptimizationMB: 400},
+		{Cluster: "prod-eu", Namespace: "shop", Deployment: "api", Container: "app", CurrentRequestMB: 800, RecommendedRequestMB: 500, RequestOptimizationMB: 300},
+		{Cluster: "prod-eu", Namespace: "shop", Deployment: "web", Container: "nginx", CurrentRequestMB: 200, RecommendedRequestMB: 100, RequestOptimizationMB: 100},
+	}
+
+	if err := exporter.Export(recommendations); err != nil {
+		t.Fatalf("Unexpected error exporting recommendations: %v", err)
+	}
+
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to open Excel file: %v", err)
+	}
+	defer f.Close()
+
+	sheetName := "Resource Recommendations"
+	if header, _ := f.GetCellValue(sheetName, "A1"); header != "Cluster" {
+		t.Errorf("Expected first header 'Cluster', got '%s'", header)
+	}
+	if header, _ := f.GetCellValue(sheetName, "B1"); header != "Namespace" {
+		t.Errorf("Expected second header 'Namespace', got '%s'", header)
+	}
+	if cluster, _ := f.GetCellValue(sheetName, "A2"); cluster != "prod-us" {
+		t.Errorf("Expected cluster 'prod-us' in A2, got '%s'", cluster)
+	}
+
+	// Per-cluster summary starts 7 rows below the totals title, sorted by cluster
+	clusterSummaryRow := len(recommendations) + 4 + 7
+	expected := [][]string{
+		{"Cluster", "Containers", "Current Request (MB)", "Recommended Request (MB)", "Request Optimization (MB)"},
+		{"prod-eu", "2", "1000", "600", "400"},
+		{"prod-us", "1", "1000", "600", "400"},
+	}
+	for i, row := range expected {
+		for j, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, clusterSummaryRow+i)
+			if got, _ := f.GetCellValue(sheetName, cell); got != value {
+				t.Errorf("Expected '%s' in %s, got '%s'", value, cell, got)
+			}
+		}
+	}
+}
+
+func TestExcelExporter_Export_Costs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test-costs.xlsx")
+	exporter := NewExcelExporter(filename)
+
+	recommendations := []types.RecommendationResult{
+		{Cluster: "prod-us", Namespace: "shop", Deployment: "api", Container: "app", CurrentRequestMB: 1000, RecommendedRequestMB: 600, RequestOptimizationMB: 400,
+			Cost: &types.Cost{Currency: "EUR", Replicas: 2, CurrentMonthly: 100, RecommendedMonthly: 60, AnnualSavings: 480}},
+		{Cluster: "prod-eu", Namespace: "shop", Deployment: "web", Container: "nginx", CurrentRequestMB: 200, RecommendedRequestMB: 100, RequestOptimizationMB: 100},
+	}
+
+	if err := exporter.Export(recommendations); err != nil {
+		t.Fatalf("Unexpected error exporting recommendations: %v", err)
+	}
+
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to open Excel file: %v", err)
+	}
+	defer f.Close()
+
+	sheetName := "Resource Recommendations"
+	rows, err := f.GetRows(sheetName)
+	if err != nil {
+		t.Fatalf("Failed to read rows: %v", err)
+	}
+	header := rows[0]
+	if last := header[len(header)-1]; last != "Annual Savings (EUR)" {
+		t.Errorf("Expected the annual savings as last column, got '%s'", last)
+	}
+	if savings := rows[1][len(header)-1]; savings != "480.00" {
+		t.Errorf("Expected annual savings '480.00', got '%s'", savings)
+	}
+
+	// Amounts are numbers the Table can sum, sort and filter
+	for _, cell := range []string{cellName(len(header), 2), cellName(2, len(recommendations)+4+6), cellName(4, len(recommendations)+4+7)} {
+		if cellType, _ := f.GetCellType(sheetName, cell); cellType == excelize.CellTypeSharedString || cellType == excelize.CellTypeInlineString {
+			t.Errorf("Expected a number in %s, got a string", cell)
+		}
+	}
+	if raw, _ := f.GetCellValue(sheetName, cellName(len(header), 2), excelize.Options{RawCellValue: true}); raw != "480" {
+		t.Errorf("Expected the raw annual savings 480, got '%s'", raw)
+	}
+
+	// The cost rows follow the memory totals, pushing the per-cluster summary down
+	summaryRow := len(recommendations) + 4
+	expected := map[string]string{
+		cellName(1, summaryRow+6): "Memory Cost (EUR/month)",
+		cellName(2, summaryRow+6): "100.00",
+		cellName(4, summaryRow+6): "40.00",
+		cellName(5, summaryRow+6): "40.0%",
+		cellName(1, summaryRow+7): "Annual Savings (EUR)",
+		cellName(4, summaryRow+7): "480.00",
+		cellName(1, summaryRow+9): "Cluster",
+	}
+	for cell, value := range expected {
+		if got, _ := f.GetCellValue(sheetName, cell); got != value {
+			t.Errorf("Expected '%s' in %s, got '%s'", value, cell, got)
+		}
+	}
+}
+
+// cellName returns the name of the cell at a 1-based column and row
+func cellName(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col, row)
+	return name
+}
+
 func TestExcelExporter_Export_InvalidPath(t *testing.T) {
 	// Use an invalid path that should cause an error
 	filename := "/invalid/path/test.xlsx"
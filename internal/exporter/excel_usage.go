@@ -0,0 +1,209 @@
+package exporter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"kubernetes-resources-recommend/internal/types"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Sheets detailing the usage behind the recommendations
+const (
+	dailyUsageSheet  = "Daily Usage"
+	hourlyUsageSheet = "Hourly Usage"
+	usageChartsSheet = "Usage Charts"
+)
+
+// Layout of the usage charts, one row of charts per container
+const (
+	usageChartRows   = 18 // sheet rows taken by a row of charts
+	usageChartWidth  = 640
+	usageChartHeight = 320
+)
+
+// usageRange locates the rows of one container in a usage sheet
+type usageRange struct {
+	rec        types.RecommendationResult
+	first      int
+	last       int
+	hasHourly  bool
+	hourlyFrom int
+	hourlyTo   int
+}
+
+// addUsageSheets adds the daily and hourly usage behind every recommendation,
+// oldest first, and a sheet of line charts per container. Nothing is added
+// when no recommendation carries a daily series.
+func (e *ExcelExporter) addUsageSheets(f *excelize.File, recommendations []types.RecommendationResult) error {
+	var recorded []types.RecommendationResult
+	for _, rec := range recommendations {
+		if len(rec.Daily) > 0 {
+			recorded = append(recorded, rec)
+		}
+	}
+	if len(recorded) == 0 {
+		return nil
+	}
+
+	for _, sheet := range []string{dailyUsageSheet, hourlyUsageSheet, usageChartsSheet} {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return fmt.Errorf("failed to create sheet: %w", err)
+		}
+	}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E0E0E0"}, Pattern: 1},
+	})
+
+	withCluster := hasClusters(recorded)
+	identity := func(rec types.RecommendationResult) []interface{} {
+		values := []interface{}{rec.Namespace, rec.Deployment, rec.Container}
+		if withCluster {
+			values = append([]interface{}{rec.Cluster}, values...)
+		}
+		return values
+	}
+	identityHeaders := []string{"Namespace", "Deployment", "Container"}
+	if withCluster {
+		identityHeaders = append([]string{"Cluster"}, identityHeaders...)
+	}
+
+	dailyHeaders := append(append([]string{}, identityHeaders...),
+		"Date", "Day", "Percentile (MB)", "Weight", "Weighted (MB)", "Samples",
+		"Current Request (MB)", "Recommended Request (MB)", "Recommended Limit (MB)")
+	hourlyHeaders := append(append([]string{}, identityHeaders...),
+		"Hour", "Replicas", "Min (MB)", "Mean (MB)", "Max (MB)")
+	writeUsageHeaders(f, dailyUsageSheet, dailyHeaders, headerStyle)
+	writeUsageHeaders(f, hourlyUsageSheet, hourlyHeaders, headerStyle)
+
+	var ranges []usageRange
+	dailyRow, hourlyRow := 2, 2
+	for _, rec := range recorded {
+		days := make([]types.DailyUsage, len(rec.Daily))
+		copy(days, rec.Daily)
+		sort.Slice(days, func(i, j int) bool { return days[i].Day > days[j].Day })
+
+		r := usageRange{rec: rec, first: dailyRow, hourlyFrom: hourlyRow}
+		for _, day := range days {
+			values := append(identity(rec),
+				day.End.Format("2006-01-02"), day.Day, megabytes(day.PercentileBytes), day.Weight,
+				megabytes(day.PercentileBytes*day.Weight), day.Samples,
+				rec.CurrentRequestMB, rec.RecommendedRequestMB, rec.RecommendedLimitMB)
+			writeUsageRow(f, dailyUsageSheet, dailyRow, values)
+			dailyRow++
+
+			for i := len(day.Hourly) - 1; i >= 0; i-- {
+				hour := day.Hourly[i]
+				values := append(identity(rec),
+					hour.End.Format("2006-01-02 15:04"), hour.Replicas,
+					megabytes(hour.MinBytes), megabytes(hour.MeanBytes), megabytes(hour.MaxBytes))
+				writeUsageRow(f, hourlyUsageSheet, hourlyRow, values)
+				hourlyRow++
+			}
+		}
+		r.last = dailyRow - 1
+		r.hourlyTo = hourlyRow - 1
+		r.hasHourly = r.hourlyTo >= r.hourlyFrom
+		ranges = append(ranges, r)
+	}
+
+	identityColumns := len(identityHeaders)
+	f.SetColWidth(dailyUsageSheet, "A", columnName(len(dailyHeaders)), 18)
+	f.SetColWidth(hourlyUsageSheet, "A", columnName(len(hourlyHeaders)), 18)
+	f.SetPanes(dailyUsageSheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+	f.SetPanes(hourlyUsageSheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+
+	for i, r := range ranges {
+		row := 1 + i*usageChartRows
+		if err := f.AddChart(usageChartsSheet, fmt.Sprintf("A%d", row), dailyUsageChart(r, identityColumns)); err != nil {
+			return fmt.Errorf("failed to add daily usage chart: %w", err)
+		}
+		if !r.hasHourly {
+			continue
+		}
+		if err := f.AddChart(usageChartsSheet, fmt.Sprintf("L%d", row), hourlyUsageChart(r, identityColumns)); err != nil {
+			return fmt.Errorf("failed to add hourly usage chart: %w", err)
+		}
+	}
+	return nil
+}
+
+// dailyUsageChart plots the daily percentile of a container against its
+// current request and recommended request and limit
+func dailyUsageChart(r usageRange, identityColumns int) *excelize.Chart {
+	// Columns following the identity: Date, Day, Percentile, Weight, Weighted,
+	// Samples, Current Request, Recommended Request, Recommended Limit
+	categories := usageColumnRange(dailyUsageSheet, identityColumns+1, r.first, r.last)
+	var series []excelize.ChartSeries
+	for _, offset := range []int{3, 7, 8, 9} {
+		series = append(series, excelize.ChartSeries{
+			Name:       usageColumnRange(dailyUsageSheet, identityColumns+offset, 1, 1),
+			Categories: categories,
+			Values:     usageColumnRange(dailyUsageSheet, identityColumns+offset, r.first, r.last),
+		})
+	}
+	return &excelize.Chart{
+		Type:      excelize.Line,
+		Series:    series,
+		Title:     []excelize.RichTextRun{{Text: "Daily usage of " + containerTitle(r.rec)}},
+		Legend:    excelize.ChartLegend{Position: "bottom"},
+		YAxis:     excelize.ChartAxis{Title: []excelize.RichTextRun{{Text: "MB"}}},
+		Dimension: excelize.ChartDimension{Width: usageChartWidth, Height: usageChartHeight},
+	}
+}
+
+// hourlyUsageChart plots the mean and maximum hourly usage across the pods of a container
+func hourlyUsageChart(r usageRange, identityColumns int) *excelize.Chart {
+	// Columns following the identity: Hour, Replicas, Min, Mean, Max
+	categories := usageColumnRange(hourlyUsageSheet, identityColumns+1, r.hourlyFrom, r.hourlyTo)
+	var series []excelize.ChartSeries
+	for _, offset := range []int{4, 5} {
+		series = append(series, excelize.ChartSeries{
+			Name:       usageColumnRange(hourlyUsageSheet, identityColumns+offset, 1, 1),
+			Categories: categories,
+			Values:     usageColumnRange(hourlyUsageSheet, identityColumns+offset, r.hourlyFrom, r.hourlyTo),
+		})
+	}
+	return &excelize.Chart{
+		Type:      excelize.Line,
+		Series:    series,
+		Title:     []excelize.RichTextRun{{Text: "Hourly usage of " + containerTitle(r.rec)}},
+		Legend:    excelize.ChartLegend{Position: "bottom"},
+		YAxis:     excelize.ChartAxis{Title: []excelize.RichTextRun{{Text: "MB"}}},
+		Dimension: excelize.ChartDimension{Width: usageChartWidth, Height: usageChartHeight},
+	}
+}
+
+// writeUsageHeaders writes the header row of a usage sheet
+func writeUsageHeaders(f *excelize.File, sheet string, headers []string, style int) {
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+	f.SetCellStyle(sheet, "A1", columnName(len(headers))+"1", style)
+}
+
+// writeUsageRow writes the values of a usage sheet row
+func writeUsageRow(f *excelize.File, sheet string, row int, values []interface{}) {
+	for i, value := range values {
+		cell, _ := excelize.CoordinatesToCellName(i+1, row)
+		f.SetCellValue(sheet, cell, value)
+	}
+}
+
+// usageColumnRange returns the absolute reference of rows first to last of a column
+func usageColumnRange(sheet string, col, first, last int) string {
+	name := columnName(col)
+	if first == last {
+		return fmt.Sprintf("'%s'!$%s$%d", sheet, name, first)
+	}
+	return fmt.Sprintf("'%s'!$%s$%d:$%s$%d", sheet, name, first, name, last)
+}
+
+// megabytes converts bytes to MB rounded to one decimal
+func megabytes(bytes float64) float64 {
+	return math.Round(bytes/1024/1024*10) / 10
+}
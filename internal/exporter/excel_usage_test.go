@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExcelExporter_Export_UsageSheets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.xlsx")
+	end := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	recs := sampleRecommendations()
+	recs[0].Daily = sampleDailyUsage(end)
+	recs[0].Daily[0].Hourly = []types.HourlyUsage{
+		{End: end, Replicas: 3, MinBytes: 200 << 20, MeanBytes: 220 << 20, MaxBytes: 240 << 20},
+		{End: end.Add(-time.Hour), Replicas: 2, MinBytes: 190 << 20, MeanBytes: 195 << 20, MaxBytes: 200 << 20},
+	}
+
+	if err := NewExcelExporter(path).Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open workbook: %v", err)
+	}
+	defer f.Close()
+
+	sheets := strings.Join(f.GetSheetList(), ",")
+	for _, sheet := range []string{dailyUsageSheet, hourlyUsageSheet, usageChartsSheet} {
+		if !strings.Contains(sheets, sheet) {
+			t.Errorf("Expected sheet %s, got %s", sheet, sheets)
+		}
+	}
+
+	// Only the container with a series is detailed, oldest day first
+	daily, _ := f.GetRows(dailyUsageSheet)
+	if len(daily) != 4 {
+		t.Fatalf("Expected a header and 3 days, got %d rows", len(daily))
+	}
+	expected := []string{"production", "web-app", "nginx", "2025-03-08", "2", "180", "0.125", "22.5", "20", "512", "256", "384"}
+	if strings.Join(daily[1], ",") != strings.Join(expected, ",") {
+		t.Errorf("Unexpected first day row: %v", daily[1])
+	}
+
+	hourly, _ := f.GetRows(hourlyUsageSheet)
+	if len(hourly) != 3 || hourly[1][3] != "2025-03-10 11:00" || hourly[1][4] != "2" || hourly[2][7] != "240" {
+		t.Errorf("Unexpected hourly rows: %v", hourly)
+	}
+
+	// One daily and one hourly chart for the container
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open workbook archive: %v", err)
+	}
+	defer archive.Close()
+	charts := 0
+	for _, file := range archive.File {
+		if strings.HasPrefix(file.Name, "xl/charts/chart") {
+			charts++
+		}
+	}
+	if charts != 2 {
+		t.Errorf("Expected 2 charts, got %d", charts)
+	}
+}
+
+func TestExcelExporter_Export_NoUsageSheets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.xlsx")
+	if err := NewExcelExporter(path).Export(sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open workbook: %v", err)
+	}
+	defer f.Close()
+	if index, _ := f.GetSheetIndex(dailyUsageSheet); index != -1 {
+		t.Error("Expected no usage sheets without a daily series")
+	}
+}
+
+func TestUsageColumnRange(t *testing.T) {
+	if ref := usageColumnRange(dailyUsageSheet, 6, 2, 8); ref != "'Daily Usage'!$F$2:$F$8" {
+		t.Errorf("Unexpected range: %s", ref)
+	}
+	if ref := usageColumnRange(dailyUsageSheet, 27, 1, 1); ref != "'Daily Usage'!$AA$1" {
+		t.Errorf("Unexpected cell: %s", ref)
+	}
+}
@@ -0,0 +1,170 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// Exporter writes recommendations to a destination
+type Exporter interface {
+	Export(recommendations []types.RecommendationResult) error
+}
+
+// Stdout is the destination writing to standard output instead of a file
+const Stdout = "-"
+
+// Supported export formats
+const (
+	FormatExcel    = "xlsx"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+	FormatPatch    = "patch"
+	FormatHelm     = "helm"
+	FormatVPA      = "vpa"
+	FormatHTML     = "html"
+	FormatMetrics  = "prom"
+)
+
+// formatAliases maps alternative format names to the supported formats
+var formatAliases = map[string]string{
+	"excel":      FormatExcel,
+	"markdown":   FormatMarkdown,
+	"patches":    FormatPatch,
+	"values":     FormatHelm,
+	"htm":        FormatHTML,
+	"prometheus": FormatMetrics,
+	"metrics":    FormatMetrics,
+}
+
+// Formats returns the supported export formats
+func Formats() []string {
+	formats := []string{FormatExcel, FormatJSON, FormatCSV, FormatMarkdown, FormatPatch, FormatHelm, FormatVPA, FormatHTML, FormatMetrics}
+	sort.Strings(formats)
+	return formats
+}
+
+// NormalizeFormat returns the supported format named by format, which may be
+// an alias such as "markdown", or an error if the format is unknown
+func NormalizeFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if alias, ok := formatAliases[format]; ok {
+		format = alias
+	}
+	for _, supported := range Formats() {
+		if format == supported {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+}
+
+// Extension returns the file name extension of format
+func Extension(format string) string {
+	switch format {
+	case FormatPatch:
+		return "patch.yaml"
+	case FormatHelm:
+		return "values.yaml"
+	case FormatVPA:
+		return "vpa.yaml"
+	}
+	return format
+}
+
+// MediaType returns the MIME type of the reports of format
+func MediaType(format string) string {
+	switch format {
+	case FormatExcel:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatJSON:
+		return "application/json"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatMetrics:
+		return MetricsMediaType
+	default:
+		return "application/yaml"
+	}
+}
+
+// New returns the exporter of format writing to filename, or to stdout when
+// filename is Stdout
+func New(format, filename string, stdout io.Writer) (Exporter, error) {
+	format, err := NormalizeFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatExcel:
+		e := NewExcelExporter(filename)
+		e.stdout = stdout
+		return e, nil
+	case FormatJSON:
+		e := NewJSONExporter(filename)
+		e.stdout = stdout
+		return e, nil
+	case FormatCSV:
+		e := NewCSVExporter(filename)
+		e.stdout = stdout
+		return e, nil
+	case FormatPatch:
+		e := NewPatchExporter(filename)
+		e.stdout = stdout
+		return e, nil
+	case FormatHelm:
+		e := NewHelmValuesExporter(filename)
+		e.stdout = stdout
+		return e, nil
+	case FormatVPA:
+		e := NewVPAExporter(filename)
+		e.stdout = stdout
+		return e, nil
+	case FormatHTML:
+		e := NewHTMLExporter(filename)
+		e.stdout = stdout
+		return e, nil
+	case FormatMetrics:
+		e := NewMetricsExporter(filename)
+		e.stdout = stdout
+		return e, nil
+	default:
+		e := NewMarkdownExporter(filename)
+		e.stdout = stdout
+		return e, nil
+	}
+}
+
+// writeOutput calls write with filename opened for writing, or with stdout
+// when filename is Stdout
+func writeOutput(filename string, stdout io.Writer, write func(w io.Writer) error) error {
+	if filename == Stdout {
+		if stdout == nil {
+			stdout = os.Stdout
+		}
+		return write(stdout)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filename, err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	return nil
+}
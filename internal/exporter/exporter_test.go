@@ -0,0 +1,149 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// sampleRecommendations returns recommendations shared by the exporter tests
+func sampleRecommendations() []types.RecommendationResult {
+	return []types.RecommendationResult{
+		{
+			Namespace:              "production",
+			Deployment:             "web-app",
+			Container:              "nginx",
+			CurrentRequestMB:       512,
+			CurrentLimitMB:         1024,
+			RecommendedRequestMB:   256,
+			RecommendedLimitMB:     384,
+			RequestOptimizationMB:  256,
+			LimitOptimizationMB:    640,
+			RequestOptimizationPct: 50.0,
+			LimitOptimizationPct:   62.5,
+			MemoryLimitMultiplier:  1.5,
+		},
+		{
+			Namespace:              "production",
+			Deployment:             "api|server",
+			Container:              "app",
+			CurrentRequestMB:       256,
+			CurrentLimitMB:         512,
+			RecommendedRequestMB:   384,
+			RecommendedLimitMB:     576,
+			RequestOptimizationMB:  -128,
+			LimitOptimizationMB:    -64,
+			RequestOptimizationPct: -50.0,
+			LimitOptimizationPct:   -12.5,
+			MemoryLimitMultiplier:  1.5,
+		},
+	}
+}
+
+func TestNormalizeFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"xlsx", FormatExcel, false},
+		{"Excel", FormatExcel, false},
+		{"json", FormatJSON, false},
+		{" CSV ", FormatCSV, false},
+		{"markdown", FormatMarkdown, false},
+		{"md", FormatMarkdown, false},
+		{"values", FormatHelm, false},
+		{"metrics", FormatMetrics, false},
+		{"yaml", "", true},
+	}
+
+	for _, tt := range tests {
+		format, err := NormalizeFormat(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for format %q", tt.input)
+			}
+			continue
+		}
+		if err != nil || format != tt.expected {
+			t.Errorf("Expected %q for %q, got %q (%v)", tt.expected, tt.input, format, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{FormatExcel, "*exporter.ExcelExporter"},
+		{FormatJSON, "*exporter.JSONExporter"},
+		{FormatCSV, "*exporter.CSVExporter"},
+		{"markdown", "*exporter.MarkdownExporter"},
+		{FormatPatch, "*exporter.PatchExporter"},
+		{FormatHelm, "*exporter.HelmValuesExporter"},
+		{FormatVPA, "*exporter.VPAExporter"},
+		{FormatHTML, "*exporter.HTMLExporter"},
+		{"prometheus", "*exporter.MetricsExporter"},
+	}
+
+	for _, tt := range tests {
+		exporter, err := New(tt.format, "out", nil)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tt.format, err)
+		}
+		if got := fmt.Sprintf("%T", exporter); got != tt.expected {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.format, got)
+		}
+	}
+
+	if _, err := New("pdf", "out", nil); err == nil {
+		t.Error("Expected error for an unknown format")
+	}
+}
+
+func TestNew_Stdout(t *testing.T) {
+	for _, format := range Formats() {
+		var stdout bytes.Buffer
+		exporter, err := New(format, Stdout, &stdout)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", format, err)
+		}
+		if err := exporter.Export(sampleRecommendations()); err != nil {
+			t.Fatalf("Unexpected error exporting %s: %v", format, err)
+		}
+		if stdout.Len() == 0 {
+			t.Errorf("Expected %s output on stdout", format)
+		}
+	}
+}
+
+func TestWriteOutput_InvalidPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "out.json")
+	err := NewJSONExporter(path).Export(sampleRecommendations())
+	if err == nil {
+		t.Fatal("Expected error writing to a missing directory")
+	}
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Error("Expected no file to be created")
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	tests := map[string]string{
+		FormatExcel:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		FormatCSV:     "text/csv; charset=utf-8",
+		FormatPatch:   "application/yaml",
+		FormatHTML:    "text/html; charset=utf-8",
+		FormatMetrics: "text/plain; version=0.0.4; charset=utf-8",
+	}
+	for format, expected := range tests {
+		if mediaType := MediaType(format); mediaType != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, format, mediaType)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHelmKeyPath is the values key path of the memory quantities when none is configured
+const DefaultHelmKeyPath = "resources.{container}.{kind}.memory"
+
+// helmPlaceholders lists the placeholders of a key path template
+var helmPlaceholders = []string{"{cluster}", "{namespace}", "{deployment}", "{container}", "{kind}"}
+
+// HelmKeyPath is a parsed key path template, one template per key
+type HelmKeyPath []string
+
+// ParseHelmKeyPath parses a dot-separated key path template such as
+// "resources.{container}.{kind}.memory". The placeholders {cluster},
+// {namespace}, {deployment} and {container} are replaced by the names of the
+// recommended container, {kind} by "requests" or "limits", which is why it is
+// required.
+func ParseHelmKeyPath(template string) (HelmKeyPath, error) {
+	template = strings.TrimSpace(template)
+	if !strings.Contains(template, "{kind}") {
+		return nil, fmt.Errorf("helm key path %q must contain {kind} to separate requests from limits", template)
+	}
+
+	keys := strings.Split(template, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("helm key path %q has an empty key", template)
+		}
+		rest := key
+		for _, placeholder := range helmPlaceholders {
+			rest = strings.ReplaceAll(rest, placeholder, "")
+		}
+		if strings.ContainsAny(rest, "{}") {
+			return nil, fmt.Errorf("helm key path %q has an unknown placeholder in %q (supported: %s)",
+				template, key, strings.Join(helmPlaceholders, ", "))
+		}
+	}
+	return HelmKeyPath(keys), nil
+}
+
+// Keys returns the values keys receiving the memory quantity of kind, requests
+// or limits, for rec
+func (p HelmKeyPath) Keys(rec types.RecommendationResult, kind string) []string {
+	replacer := strings.NewReplacer(
+		"{cluster}", rec.Cluster,
+		"{namespace}", rec.Namespace,
+		"{deployment}", rec.Deployment,
+		"{container}", rec.Container,
+		"{kind}", kind,
+	)
+	keys := make([]string, len(p))
+	for i, key := range p {
+		keys[i] = replacer.Replace(key)
+	}
+	return keys
+}
+
+// HelmValuesExporter exports recommendations as a Helm values overlay, setting
+// the memory request and limit of each container under a configurable key
+// path. With merge enabled, the quantities are written into the existing
+// values file, keeping its other keys and comments.
+//
+// The recommendations are written to a single values file, or to one file per
+// deployment when filename is a directory (an existing one or a path ending
+// with a separator).
+type HelmValuesExporter struct {
+	filename string
+	stdout   io.Writer
+	keyPath  HelmKeyPath
+	merge    bool
+}
+
+// NewHelmValuesExporter creates a new Helm values exporter using the
+// DefaultHelmKeyPath, writing to stdout when filename is Stdout
+func NewHelmValuesExporter(filename string) *HelmValuesExporter {
+	keyPath, _ := ParseHelmKeyPath(DefaultHelmKeyPath)
+	return &HelmValuesExporter{
+		filename: filename,
+		keyPath:  keyPath,
+	}
+}
+
+// SetKeyPath sets the key path template of the memory quantities
+func (e *HelmValuesExporter) SetKeyPath(keyPath HelmKeyPath) {
+	e.keyPath = keyPath
+}
+
+// SetMerge sets whether the quantities are merged into an existing values file
+func (e *HelmValuesExporter) SetMerge(merge bool) {
+	e.merge = merge
+}
+
+// GetFilename returns the filename that will be used for export
+func (e *HelmValuesExporter) GetFilename() string {
+	return e.filename
+}
+
+// Export writes the values of every recommended container
+func (e *HelmValuesExporter) Export(recommendations []types.RecommendationResult) error {
+	recommendations = sortedRecommendations(recommended(recommendations))
+
+	if !isDirectory(e.filename) {
+		return e.exportFile(e.filename, e.stdout, recommendations)
+	}
+
+	if err := os.MkdirAll(e.filename, 0o755); err != nil {
+		return fmt.Errorf("failed to create values directory: %w", err)
+	}
+	var names []string
+	byFile := make(map[string][]types.RecommendationResult)
+	for _, rec := range recommendations {
+		name := workloadFileName(rec.Cluster, rec.Namespace, rec.Deployment, "values.yaml")
+		if _, ok := byFile[name]; !ok {
+			names = append(names, name)
+		}
+		byFile[name] = append(byFile[name], rec)
+	}
+	for _, name := range names {
+		if err := e.exportFile(filepath.Join(e.filename, name), nil, byFile[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportFile writes the values of recommendations to a single file, merged
+// into its current content when merging is enabled
+func (e *HelmValuesExporter) exportFile(filename string, stdout io.Writer, recommendations []types.RecommendationResult) error {
+	doc, err := e.baseDocument(filename)
+	if err != nil {
+		return err
+	}
+
+	// owners records the container setting each key, to report collisions
+	owners := make(map[string]string)
+	for _, rec := range recommendations {
+		owner := rec.Namespace + "/" + rec.Deployment + "/" + rec.Container
+		if rec.Cluster != "" {
+			owner = rec.Cluster + "/" + owner
+		}
+		quantities := []struct {
+			kind string
+			mb   int64
+		}{
+			{"requests", rec.RecommendedRequestMB},
+			{"limits", rec.RecommendedLimitMB},
+		}
+		for _, q := range quantities {
+			keys := e.keyPath.Keys(rec, q.kind)
+			path := strings.Join(keys, ".")
+			if previous, ok := owners[path]; ok {
+				return fmt.Errorf("helm key %s is set by both %s and %s, add {deployment} or {namespace} to the key path",
+					path, previous, owner)
+			}
+			owners[path] = owner
+
+			if err := setValue(doc.Content[0], keys, MemoryQuantity(q.mb)); err != nil {
+				return fmt.Errorf("%s: %w", filename, err)
+			}
+		}
+	}
+
+	return writeOutput(filename, stdout, func(w io.Writer) error {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode helm values: %w", err)
+		}
+		return encoder.Close()
+	})
+}
+
+// baseDocument returns the document the values are written into: the
+// existing values file when merging, an empty mapping otherwise
+func (e *HelmValuesExporter) baseDocument(filename string) (*yaml.Node, error) {
+	empty := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	if !e.merge || filename == Stdout {
+		return empty, nil
+	}
+
+	content, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return empty, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return empty, nil
+		}
+		return nil, fmt.Errorf("failed to parse values file %s: %w", filename, err)
+	}
+	if len(doc.Content) == 0 {
+		return empty, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("values file %s must hold a mapping", filename)
+	}
+	return &doc, nil
+}
+
+// setValue sets the string value at the key path under a mapping node,
+// creating the missing mappings. An existing value keeps its quoting style.
+func setValue(node *yaml.Node, keys []string, value string) error {
+	for i, key := range keys {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("key %s is not a mapping", strings.Join(keys[:i], "."))
+		}
+
+		var child *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				child = node.Content[j+1]
+				break
+			}
+		}
+
+		last := i == len(keys)-1
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if last {
+				child = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+
+		if last {
+			if child.Kind != yaml.ScalarNode {
+				return fmt.Errorf("key %s is not a scalar value", strings.Join(keys, "."))
+			}
+			child.Tag = "!!str"
+			child.Value = value
+			return nil
+		}
+		node = child
+	}
+	return nil
+}
+
+// recommended returns the recommendations leaving out the verification-only
+// results, which carry no requests to write
+func recommended(recommendations []types.RecommendationResult) []types.RecommendationResult {
+	var results []types.RecommendationResult
+	for _, rec := range recommendations {
+		if !rec.VerificationOnly {
+			results = append(results, rec)
+		}
+	}
+	return results
+}
+
+// sortedRecommendations returns a copy of recommendations sorted by cluster,
+// namespace, deployment and container
+func sortedRecommendations(recommendations []types.RecommendationResult) []types.RecommendationResult {
+	sorted := append([]types.RecommendationResult(nil), recommendations...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Deployment != b.Deployment {
+			return a.Deployment < b.Deployment
+		}
+		return a.Container < b.Container
+	})
+	return sorted
+}
@@ -0,0 +1,160 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseHelmKeyPath(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{DefaultHelmKeyPath, ""},
+		{"{deployment}.containers.{container}.resources.{kind}.memory", ""},
+		{"{cluster}-{namespace}.{deployment}.{kind}", ""},
+		{"resources.{container}.requests.memory", "must contain {kind}"},
+		{"resources..{kind}.memory", "empty key"},
+		{"resources.{image}.{kind}.memory", "unknown placeholder in \"{image}\""},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseHelmKeyPath(tt.template)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Unexpected error for %q: %v", tt.template, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Expected error containing '%s' for %q, got %v", tt.wantErr, tt.template, err)
+		}
+	}
+}
+
+func TestHelmValuesExporter_Export(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewHelmValuesExporter(Stdout)
+	exporter.stdout = &stdout
+	keyPath, _ := ParseHelmKeyPath("{deployment}.resources.{container}.{kind}.memory")
+	exporter.SetKeyPath(keyPath)
+
+	if err := exporter.Export(sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `api|server:
+  resources:
+    app:
+      requests:
+        memory: 384Mi
+      limits:
+        memory: 576Mi
+web-app:
+  resources:
+    nginx:
+      requests:
+        memory: 256Mi
+      limits:
+        memory: 384Mi
+`
+	if stdout.String() != expected {
+		t.Errorf("Unexpected values:\n%s", stdout.String())
+	}
+}
+
+func TestHelmValuesExporter_Export_Collision(t *testing.T) {
+	recs := sampleRecommendations()
+	recs[1].Container = recs[0].Container
+
+	exporter := NewHelmValuesExporter(Stdout)
+	exporter.stdout = &bytes.Buffer{}
+	err := exporter.Export(recs)
+	if err == nil || !strings.Contains(err.Error(), "resources.nginx.requests.memory is set by both") {
+		t.Errorf("Expected a collision error, got %v", err)
+	}
+}
+
+func TestHelmValuesExporter_Export_Merge(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "values.yaml")
+	existing := `# Shared chart values
+image:
+  repository: nginx
+  tag: "1.25"
+resources:
+  nginx:
+    requests:
+      cpu: 100m
+      memory: "1Gi" # set by hand
+replicaCount: 3
+`
+	if err := os.WriteFile(filename, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := NewHelmValuesExporter(filename)
+	exporter.SetMerge(true)
+	if err := exporter.Export(sampleRecommendations()[:1]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, _ := os.ReadFile(filename)
+	expected := `# Shared chart values
+image:
+  repository: nginx
+  tag: "1.25"
+resources:
+  nginx:
+    requests:
+      cpu: 100m
+      memory: "256Mi" # set by hand
+    limits:
+      memory: 384Mi
+replicaCount: 3
+`
+	if string(content) != expected {
+		t.Errorf("Unexpected merged values:\n%s", content)
+	}
+
+	// Without merge the file is replaced by the overlay
+	if err := NewHelmValuesExporter(filename).Export(sampleRecommendations()[:1]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(filename)
+	if strings.Contains(string(content), "replicaCount") {
+		t.Errorf("Expected an overlay without the existing keys, got:\n%s", content)
+	}
+}
+
+func TestHelmValuesExporter_Export_MergeConflict(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(filename, []byte("resources:\n  nginx: small\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := NewHelmValuesExporter(filename)
+	exporter.SetMerge(true)
+	err := exporter.Export(sampleRecommendations()[:1])
+	if err == nil || !strings.Contains(err.Error(), "key resources.nginx is not a mapping") {
+		t.Errorf("Expected a conflict error, got %v", err)
+	}
+}
+
+func TestHelmValuesExporter_Export_Directory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "values") + string(filepath.Separator)
+	recs := sampleRecommendations()
+	recs[0].Cluster = "prod-eu"
+
+	if err := NewHelmValuesExporter(dir).Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"prod-eu.production.web-app.values.yaml", "production.api|server.values.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected values file %s: %v", name, err)
+		}
+	}
+}
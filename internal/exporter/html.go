@@ -0,0 +1,348 @@
+package exporter
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// HTMLExporter exports recommendations as a self-contained HTML page: a
+// sortable recommendations table, the summary statistics of the Excel report
+// and, for every container with a recorded daily series, an inline SVG chart
+// of its daily usage against the current and recommended request and limit
+type HTMLExporter struct {
+	filename string
+	stdout   io.Writer
+}
+
+// NewHTMLExporter creates a new HTML exporter, writing to stdout when filename is Stdout
+func NewHTMLExporter(filename string) *HTMLExporter {
+	return &HTMLExporter{
+		filename: filename,
+	}
+}
+
+// GetFilename returns the filename that will be used for export
+func (e *HTMLExporter) GetFilename() string {
+	return e.filename
+}
+
+// htmlPage is the data rendered by htmlTemplate
+type htmlPage struct {
+	Headers  []string
+	Rows     [][]htmlCell
+	Summary  [][]string
+	Clusters [][]string
+	Charts   []htmlChart
+}
+
+// htmlCell is a cell of the recommendations table
+type htmlCell struct {
+	Value string
+	Class string // savings or increase for optimization columns
+	Link  string // anchor of the chart of the container
+}
+
+// htmlChart is the usage chart of a single container
+type htmlChart struct {
+	ID    string
+	Title string
+	SVG   template.HTML
+}
+
+// Export writes the HTML report
+func (e *HTMLExporter) Export(recommendations []types.RecommendationResult) error {
+	page := buildHTMLPage(recommendations)
+	return writeOutput(e.filename, e.stdout, func(w io.Writer) error {
+		if err := htmlTemplate.Execute(w, page); err != nil {
+			return fmt.Errorf("failed to render HTML report: %w", err)
+		}
+		return nil
+	})
+}
+
+// buildHTMLPage lays out the recommendations, with the columns and summary of the Excel report
+func buildHTMLPage(recommendations []types.RecommendationResult) *htmlPage {
+	page := &htmlPage{}
+	withCluster := hasClusters(recommendations)
+	columns := reportColumns(recommendations)
+	for _, column := range columns {
+		page.Headers = append(page.Headers, column.header)
+	}
+
+	var totals summaryTotals
+	clusterTotals := make(map[string]*summaryTotals)
+	for i, rec := range recommendations {
+		totals.add(rec)
+		if clusterTotals[rec.Cluster] == nil {
+			clusterTotals[rec.Cluster] = &summaryTotals{}
+		}
+		clusterTotals[rec.Cluster].add(rec)
+
+		var chartID string
+		if len(rec.Daily) > 0 {
+			chartID = fmt.Sprintf("chart-%d", i+1)
+			page.Charts = append(page.Charts, htmlChart{
+				ID:    chartID,
+				Title: containerTitle(rec),
+				SVG:   template.HTML(renderUsageChart(rec)),
+			})
+		}
+
+		row := make([]htmlCell, len(columns))
+		for j, column := range columns {
+			row[j] = htmlCell{Value: column.text(rec)}
+			if column.optimization != nil {
+				switch optimization := column.optimization(rec); {
+				case optimization > 0:
+					row[j].Class = "savings"
+				case optimization < 0:
+					row[j].Class = "increase"
+				}
+			}
+			if column.header == "Container" {
+				row[j].Link = chartID
+			}
+		}
+		page.Rows = append(page.Rows, row)
+	}
+
+	page.Summary = [][]string{
+		{"Total Containers", fmt.Sprint(totals.containers), "", "", ""},
+		{"Memory Request (MB)", fmt.Sprint(totals.currentRequestMB), fmt.Sprint(totals.recommendedRequestMB),
+			fmt.Sprint(totals.requestOptimizationMB), fmt.Sprintf("%.1f%%", totals.requestOptimizationPct())},
+		{"Memory Limit (MB)", fmt.Sprint(totals.currentLimitMB), fmt.Sprint(totals.recommendedLimitMB),
+			fmt.Sprint(totals.limitOptimizationMB), fmt.Sprintf("%.1f%%", totals.limitOptimizationPct())},
+	}
+	page.Summary = append(page.Summary, totals.costRows()...)
+
+	if withCluster {
+		clusters := make([]string, 0, len(clusterTotals))
+		for cluster := range clusterTotals {
+			clusters = append(clusters, cluster)
+		}
+		sort.Strings(clusters)
+		for _, cluster := range clusters {
+			t := clusterTotals[cluster]
+			page.Clusters = append(page.Clusters, []string{cluster, fmt.Sprint(t.containers),
+				fmt.Sprint(t.currentRequestMB), fmt.Sprint(t.recommendedRequestMB), fmt.Sprint(t.requestOptimizationMB),
+				fmt.Sprintf("%.1f%%", t.requestOptimizationPct()),
+				fmt.Sprint(t.currentLimitMB), fmt.Sprint(t.recommendedLimitMB), fmt.Sprint(t.limitOptimizationMB),
+				fmt.Sprintf("%.1f%%", t.limitOptimizationPct())})
+		}
+	}
+	return page
+}
+
+// containerTitle identifies the container of a recommendation
+func containerTitle(rec types.RecommendationResult) string {
+	title := rec.Namespace + "/" + rec.Deployment + "/" + rec.Container
+	if rec.Cluster != "" {
+		title = rec.Cluster + "/" + title
+	}
+	return title
+}
+
+// Layout of the usage charts, in pixels
+const (
+	chartWidth  = 640
+	chartHeight = 280
+	chartLeft   = 56
+	chartRight  = 16
+	chartTop    = 16
+	chartBottom = 64
+)
+
+// chartLine is a horizontal reference line of a usage chart
+type chartLine struct {
+	label string
+	color string
+	mb    float64
+	dash  bool
+}
+
+// renderUsageChart draws the daily usage of a container, oldest day first,
+// against its current and recommended request and limit
+func renderUsageChart(rec types.RecommendationResult) string {
+	days := make([]types.DailyUsage, len(rec.Daily))
+	copy(days, rec.Daily)
+	sort.Slice(days, func(i, j int) bool { return days[i].Day > days[j].Day })
+
+	lines := []chartLine{
+		{"Current request", "#7f7f7f", float64(rec.CurrentRequestMB), true},
+		{"Current limit", "#c00000", float64(rec.CurrentLimitMB), true},
+		{"Recommended request", "#2e7d32", float64(rec.RecommendedRequestMB), false},
+		{"Recommended limit", "#ed7d31", float64(rec.RecommendedLimitMB), false},
+	}
+
+	top := 0.0
+	for _, day := range days {
+		top = math.Max(top, day.PercentileBytes/1024/1024)
+	}
+	for _, line := range lines {
+		top = math.Max(top, line.mb)
+	}
+	step := chartStep(top * 1.1 / 4)
+	// An idle container without requests or limits still gets a scale
+	top = math.Max(step*math.Ceil(top*1.1/step), step)
+
+	plotWidth := float64(chartWidth - chartLeft - chartRight)
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+	y := func(mb float64) float64 { return float64(chartTop) + plotHeight*(1-mb/top) }
+	x := func(i int) float64 {
+		if len(days) == 1 {
+			return float64(chartLeft) + plotWidth/2
+		}
+		return float64(chartLeft) + plotWidth*float64(i)/float64(len(days)-1)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" role="img" aria-label="%s">`,
+		chartWidth, chartHeight, chartWidth, chartHeight, template.HTMLEscapeString("Daily memory usage of "+containerTitle(rec)))
+
+	// Grid and axis labels
+	for value := 0.0; value <= top+step/2; value += step {
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e0e0e0"/>`, chartLeft, y(value), chartWidth-chartRight, y(value))
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" font-size="11" fill="#555">%.0f</text>`, chartLeft-6, y(value)+4, value)
+	}
+	fmt.Fprintf(&b, `<text x="12" y="%d" font-size="11" fill="#555" transform="rotate(-90 12 %d)" text-anchor="middle">MB</text>`,
+		chartTop+int(plotHeight)/2, chartTop+int(plotHeight)/2)
+
+	labelEvery := (len(days) + 9) / 10
+	for i, day := range days {
+		if i%labelEvery == 0 || i == len(days)-1 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" font-size="11" fill="#555">%s</text>`,
+				x(i), chartHeight-chartBottom+16, day.End.Format("01-02"))
+		}
+	}
+
+	// Reference lines, then the daily usage on top
+	for _, line := range lines {
+		if line.mb <= 0 {
+			continue
+		}
+		dash := ""
+		if line.dash {
+			dash = ` stroke-dasharray="6 4"`
+		}
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="%s" stroke-width="1.5"%s/>`,
+			chartLeft, y(line.mb), chartWidth-chartRight, y(line.mb), line.color, dash)
+	}
+
+	points := make([]string, len(days))
+	for i, day := range days {
+		points[i] = fmt.Sprintf("%.1f,%.1f", x(i), y(day.PercentileBytes/1024/1024))
+	}
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="#4472c4" stroke-width="2"/>`, strings.Join(points, " "))
+	for i, day := range days {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="#4472c4"><title>%s: %.0f MB (%d samples, weight %g)</title></circle>`,
+			x(i), y(day.PercentileBytes/1024/1024), day.End.Format("2006-01-02"), day.PercentileBytes/1024/1024, day.Samples, day.Weight)
+	}
+
+	// Legend
+	legend := append([]chartLine{{label: "Daily usage", color: "#4472c4"}}, lines...)
+	for i, item := range legend {
+		lx := chartLeft + i*118
+		ly := chartHeight - 20
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="4" fill="%s"/>`, lx, ly-4, item.color)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" fill="#333">%s</text>`, lx+16, ly, item.label)
+	}
+
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// chartStep rounds a raw axis step up to 1, 2 or 5 times a power of ten
+func chartStep(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, factor := range []float64{1, 2, 5, 10} {
+		if step := factor * magnitude; step >= raw {
+			return step
+		}
+	}
+	return 10 * magnitude
+}
+
+// htmlTemplate renders an htmlPage as a standalone page, with the table
+// sorting script and styles inlined
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Memory Recommendations</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.25em; margin-top: 2em; }
+table { border-collapse: collapse; margin: 1em 0; font-size: 0.9em; }
+th, td { border: 1px solid #c8c8c8; padding: 4px 8px; text-align: left; }
+th { background: #4f81bd; color: #fff; }
+table.sortable th { cursor: pointer; user-select: none; }
+table.sortable th[aria-sort=ascending]::after { content: " \25B2"; }
+table.sortable th[aria-sort=descending]::after { content: " \25BC"; }
+tbody tr:nth-child(even) { background: #f2f6fc; }
+td.savings { background: #c6efce; color: #006100; }
+td.increase { background: #ffc7ce; color: #9c0006; }
+.chart { margin: 1.5em 0; }
+.chart h3 { font-size: 1em; margin-bottom: 0.3em; }
+</style>
+</head>
+<body>
+<h1>Memory Recommendations</h1>
+{{if not .Rows}}<p>No recommendations generated.</p>{{else}}
+<table class="sortable">
+<thead><tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td{{if .Class}} class="{{.Class}}"{{end}}>{{if .Link}}<a href="#{{.Link}}">{{.Value}}</a>{{else}}{{.Value}}{{end}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+
+<h2>Optimization Summary Statistics</h2>
+<table>
+<thead><tr><th>Metric</th><th>Current Config</th><th>Recommended</th><th>Optimization</th><th>Optimization %</th></tr></thead>
+<tbody>
+{{range .Summary}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{if .Clusters}}
+<table class="sortable">
+<thead><tr><th>Cluster</th><th>Containers</th><th>Current Request (MB)</th><th>Recommended Request (MB)</th><th>Request Optimization (MB)</th><th>Request Optimization %</th><th>Current Limit (MB)</th><th>Recommended Limit (MB)</th><th>Limit Optimization (MB)</th><th>Limit Optimization %</th></tr></thead>
+<tbody>
+{{range .Clusters}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{end}}
+{{if .Charts}}
+<h2>Daily Usage</h2>
+{{range .Charts}}<div class="chart" id="{{.ID}}">
+<h3>{{.Title}}</h3>
+{{.SVG}}
+</div>
+{{end}}{{end}}{{end}}
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), body = table.tBodies[0], index = th.cellIndex;
+    var ascending = th.getAttribute("aria-sort") !== "ascending";
+    table.querySelectorAll("th").forEach(function (other) { other.removeAttribute("aria-sort"); });
+    th.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+    Array.prototype.slice.call(body.rows).sort(function (a, b) {
+      var x = a.cells[index].textContent, y = b.cells[index].textContent;
+      var nx = parseFloat(x), ny = parseFloat(y);
+      var order = isNaN(nx) || isNaN(ny) ? x.localeCompare(y) : nx - ny;
+      return ascending ? order : -order;
+    }).forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
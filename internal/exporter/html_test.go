@@ -0,0 +1,162 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// sampleDailyUsage returns a three-day series ending at the given time
+func sampleDailyUsage(end time.Time) []types.DailyUsage {
+	const mb = 1024 * 1024
+	return []types.DailyUsage{
+		{Day: 0, End: end, PercentileBytes: 240 * mb, Weight: 0.5, Samples: 24},
+		{Day: 1, End: end.Add(-24 * time.Hour), PercentileBytes: 300 * mb, Weight: 0.25, Samples: 24},
+		{Day: 2, End: end.Add(-48 * time.Hour), PercentileBytes: 180 * mb, Weight: 0.125, Samples: 20},
+	}
+}
+
+func TestHTMLExporter_Export(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	recs := sampleRecommendations()
+	recs[0].Daily = sampleDailyUsage(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+
+	if err := NewHTMLExporter(path).Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	html := string(content)
+
+	for _, expected := range []string{
+		"<!DOCTYPE html>",
+		`<table class="sortable">`,
+		"<th>Recommended Request (MB)</th>",
+		`<a href="#chart-1">nginx</a>`,
+		`<td class="savings">256</td>`,
+		`<td class="increase">-128</td>`,
+		"<td>api|server</td>",
+		"<td>Memory Request (MB)</td><td>768</td><td>640</td><td>128</td><td>16.7%</td>",
+		`<div class="chart" id="chart-1">`,
+		"<h3>production/web-app/nginx</h3>",
+		"<polyline points=",
+		"<title>2025-03-08: 180 MB (20 samples, weight 0.125)</title>",
+		">03-10</text>",
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected report containing '%s'", expected)
+		}
+	}
+
+	// Only containers with a daily series get a chart
+	if strings.Count(html, "<svg") != 1 || strings.Contains(html, `href="#chart-2"`) {
+		t.Error("Expected a single chart")
+	}
+	if strings.Contains(html, "<th>Cluster</th>") {
+		t.Error("Expected no cluster columns without clusters")
+	}
+}
+
+func TestHTMLExporter_Export_EscapesNames(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewHTMLExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()[:1]
+	recs[0].Container = "<script>alert(1)</script>"
+	recs[0].Daily = sampleDailyUsage(time.Now())
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(stdout.String(), "<script>alert") {
+		t.Error("Expected names to be escaped")
+	}
+}
+
+func TestHTMLExporter_Export_Clusters(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewHTMLExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()
+	recs[0].Cluster = "prod-eu"
+	recs[1].Cluster = "prod-us"
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "<tr><td>prod-eu</td><td>1</td><td>512</td><td>256</td>") {
+		t.Errorf("Expected a per-cluster summary, got:\n%s", stdout.String())
+	}
+}
+
+func TestHTMLExporter_Export_Costs(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewHTMLExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()
+	recs[0].Cost = &types.Cost{Currency: "EUR", Replicas: 3, CurrentMonthly: 4.38, RecommendedMonthly: 2.19, AnnualSavings: 26.28}
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"<th>Annual Savings (EUR)</th>",
+		`<td class="savings">26.28</td>`,
+		"<td>Memory Cost (EUR/month)</td><td>4.38</td><td>2.19</td><td>2.19</td><td>50.0%</td>",
+		"<td>Annual Savings (EUR)</td><td></td><td></td><td>26.28</td>",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected report containing '%s'", expected)
+		}
+	}
+}
+
+func TestHTMLExporter_Export_Empty(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewHTMLExporter(Stdout)
+	exporter.stdout = &stdout
+	if err := exporter.Export(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "No recommendations generated.") {
+		t.Error("Expected an empty report message")
+	}
+}
+
+func TestChartStep(t *testing.T) {
+	tests := []struct{ raw, expected float64 }{
+		{0, 1},
+		{0.7, 1},
+		{13, 20},
+		{42, 50},
+		{80, 100},
+		{100, 100},
+	}
+	for _, tt := range tests {
+		if step := chartStep(tt.raw); step != tt.expected {
+			t.Errorf("Expected step %v for %v, got %v", tt.expected, tt.raw, step)
+		}
+	}
+}
+
+func TestRenderUsageChart_Idle(t *testing.T) {
+	end := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	rec := types.RecommendationResult{
+		Deployment: "idle",
+		Container:  "app",
+		Daily: []types.DailyUsage{
+			{Day: 0, End: end, Samples: 24},
+			{Day: 1, End: end.Add(-24 * time.Hour), Samples: 24},
+		},
+	}
+	if chart := renderUsageChart(rec); strings.Contains(chart, "NaN") {
+		t.Errorf("Expected finite coordinates for an idle container, got %s", chart)
+	}
+}
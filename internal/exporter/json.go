@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// JSONExporter exports recommendations as a JSON array, keyed by the struct
+// tags of RecommendationResult
+type JSONExporter struct {
+	filename string
+	stdout   io.Writer
+}
+
+// NewJSONExporter creates a new JSON exporter, writing to stdout when filename is Stdout
+func NewJSONExporter(filename string) *JSONExporter {
+	return &JSONExporter{
+		filename: filename,
+	}
+}
+
+// Export writes recommendations as indented JSON
+func (e *JSONExporter) Export(recommendations []types.RecommendationResult) error {
+	if recommendations == nil {
+		recommendations = []types.RecommendationResult{}
+	}
+
+	return writeOutput(e.filename, e.stdout, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(recommendations); err != nil {
+			return fmt.Errorf("failed to encode recommendations: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetFilename returns the filename that will be used for export
+func (e *JSONExporter) GetFilename() string {
+	return e.filename
+}
@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestJSONExporter_Export(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recommendations.json")
+	exporter := NewJSONExporter(path)
+
+	if exporter.GetFilename() != path {
+		t.Errorf("Expected filename '%s', got '%s'", path, exporter.GetFilename())
+	}
+	if err := exporter.Export(sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	// Fields are keyed by the struct tags
+	var raw []map[string]interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(raw) != 2 {
+		t.Fatalf("Expected 2 recommendations, got %d", len(raw))
+	}
+	if raw[0]["deployment"] != "web-app" || raw[0]["recommended_request_mb"] != 256.0 {
+		t.Errorf("Unexpected first recommendation: %v", raw[0])
+	}
+	if _, ok := raw[0]["cluster"]; ok {
+		t.Error("Expected the empty cluster to be omitted")
+	}
+
+	var decoded []types.RecommendationResult
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatalf("Failed to decode recommendations: %v", err)
+	}
+	if !reflect.DeepEqual(decoded[1], sampleRecommendations()[1]) {
+		t.Errorf("Expected a lossless round trip, got %+v", decoded[1])
+	}
+}
+
+func TestJSONExporter_Export_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	if err := NewJSONExporter(path).Export(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "[]\n" {
+		t.Errorf("Expected an empty array, got %q", content)
+	}
+}
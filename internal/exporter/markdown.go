@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// MarkdownExporter exports recommendations as a Markdown table followed by
+// the summary totals, suitable for pull request comments
+type MarkdownExporter struct {
+	filename string
+	stdout   io.Writer
+}
+
+// NewMarkdownExporter creates a new Markdown exporter, writing to stdout when filename is Stdout
+func NewMarkdownExporter(filename string) *MarkdownExporter {
+	return &MarkdownExporter{
+		filename: filename,
+	}
+}
+
+// Export writes the recommendations table, with the columns of the Excel report
+func (e *MarkdownExporter) Export(recommendations []types.RecommendationResult) error {
+	return writeOutput(e.filename, e.stdout, func(w io.Writer) error {
+		var b strings.Builder
+		b.WriteString("## Memory Recommendations\n\n")
+
+		if len(recommendations) == 0 {
+			b.WriteString("No recommendations generated.\n")
+			_, err := io.WriteString(w, b.String())
+			return err
+		}
+
+		columns := reportColumns(recommendations)
+		headers := make([]string, len(columns))
+		separators := make([]string, len(columns))
+		for i, column := range columns {
+			headers[i] = column.header
+			separators[i] = "---"
+		}
+		WriteMarkdownRow(&b, headers)
+		WriteMarkdownRow(&b, separators)
+
+		var totals summaryTotals
+		for _, rec := range recommendations {
+			totals.add(rec)
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = column.text(rec)
+			}
+			WriteMarkdownRow(&b, cells)
+		}
+
+		b.WriteString("\n### Summary\n\n")
+		WriteMarkdownRow(&b, []string{"Metric", "Current", "Recommended", "Optimization", "Optimization %"})
+		WriteMarkdownRow(&b, []string{"---", "---:", "---:", "---:", "---:"})
+		WriteMarkdownRow(&b, []string{"Total Containers", fmt.Sprint(totals.containers), "", "", ""})
+		WriteMarkdownRow(&b, []string{"Memory Request (MB)", fmt.Sprint(totals.currentRequestMB), fmt.Sprint(totals.recommendedRequestMB),
+			fmt.Sprint(totals.requestOptimizationMB), fmt.Sprintf("%.1f%%", totals.requestOptimizationPct())})
+		WriteMarkdownRow(&b, []string{"Memory Limit (MB)", fmt.Sprint(totals.currentLimitMB), fmt.Sprint(totals.recommendedLimitMB),
+			fmt.Sprint(totals.limitOptimizationMB), fmt.Sprintf("%.1f%%", totals.limitOptimizationPct())})
+		for _, row := range totals.costRows() {
+			WriteMarkdownRow(&b, row)
+		}
+
+		_, err := io.WriteString(w, b.String())
+		return err
+	})
+}
+
+// WriteMarkdownRow writes a Markdown table row, escaping the cell separators
+func WriteMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, cell := range cells {
+		b.WriteString(" ")
+		b.WriteString(strings.ReplaceAll(cell, "|", `\|`))
+		b.WriteString(" |")
+	}
+	b.WriteString("\n")
+}
+
+// GetFilename returns the filename that will be used for export
+func (e *MarkdownExporter) GetFilename() string {
+	return e.filename
+}
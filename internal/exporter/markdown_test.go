@@ -0,0 +1,165 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestMarkdownExporter_Export(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewMarkdownExporter(Stdout)
+	exporter.stdout = &stdout
+
+	if err := exporter.Export(sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	output := stdout.String()
+
+	expected := []string{
+		"## Memory Recommendations",
+		"| Namespace | Deployment | Container | Current Request (MB) |",
+		"| production | web-app | nginx | 512 | 1024 | 256 | 384 | 256 | 640 | 50.0% | 62.5% |",
+		`| production | api\|server | app |`,
+		"### Summary",
+		"| Memory Request (MB) | 768 | 640 | 128 | 16.7% |",
+	}
+	for _, s := range expected {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output containing %q, got:\n%s", s, output)
+		}
+	}
+	if strings.Contains(output, "Cluster") {
+		t.Error("Expected no cluster column without clusters")
+	}
+}
+
+func TestMarkdownExporter_Export_Clusters(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewMarkdownExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()
+	recs[0].Cluster = "prod-eu"
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "| Cluster | Namespace |") {
+		t.Errorf("Expected a cluster column, got:\n%s", stdout.String())
+	}
+}
+
+func TestMarkdownExporter_Export_Backtest(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewMarkdownExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()
+	recs[0].Backtest = &types.Backtest{Pods: 3, PodsOverRequest: 2, MinutesOverRequest: 45, PodsOverLimit: 1, MinutesOverLimit: 2, PeakBytes: 400 * 1024 * 1024}
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"| Limit Optimization (%) | Backtest Pods | Pods Over Request | Minutes Over Request | Pods Over Limit | Minutes Over Limit | Backtest Peak (MB) |",
+		"| 50.0% | 62.5% | 3 | 2 | 45 | 1 | 2 | 400 |",
+		"| -50.0% | -12.5% |  |  |  |  |  |  |",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected output containing %q, got:\n%s", expected, stdout.String())
+		}
+	}
+}
+
+func TestMarkdownExporter_Export_Verification(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewMarkdownExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()
+	recs[0].Verification = &types.Verification{
+		Before:      types.VerificationWindow{UsageBytes: 200 * 1024 * 1024, Restarts: 1},
+		After:       types.VerificationWindow{UsageBytes: 300 * 1024 * 1024, OOMKills: 2, Restarts: 1},
+		Evictions:   &types.VerificationEvictions{After: 1},
+		Regressions: []string{"OOM kills rose from 0 to 2"},
+	}
+	recs[1].Verification = &types.Verification{Regressions: []string{}}
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"| Limit Optimization (%) | Usage Before (MB) | Usage After (MB) | OOM Kills Before | OOM Kills After | Restarts Before | Restarts After | Deployment Evictions Before | Deployment Evictions After | Regressions |",
+		"| 50.0% | 62.5% | 200 | 300 | 0 | 2 | 1 | 1 | 0 | 1 | OOM kills rose from 0 to 2 |",
+		"| -50.0% | -12.5% | 0 | 0 | 0 | 0 | 0 | 0 |  |  | none |",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected output containing %q, got:\n%s", expected, stdout.String())
+		}
+	}
+}
+
+func TestMarkdownExporter_Export_VerificationOnly(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewMarkdownExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := append(sampleRecommendations(), types.RecommendationResult{
+		Namespace: "production", Deployment: "web-app", Container: "sidecar",
+		Verification:     &types.Verification{Regressions: []string{}},
+		VerificationOnly: true,
+	})
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The sidecar shows no requests and stays out of the totals
+	for _, expected := range []string{
+		"| production | web-app | sidecar |  |  |  |  |  |  |  |  | 0 | 0 |",
+		"| Total Containers | 2 |",
+		"| Memory Request (MB) | 768 | 640 |",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected output containing %q, got:\n%s", expected, stdout.String())
+		}
+	}
+}
+
+func TestMarkdownExporter_Export_Costs(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewMarkdownExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()
+	recs[0].Cost = &types.Cost{Currency: "EUR", PricePerGiBHour: 0.004, PriceSource: "default", Replicas: 3,
+		CurrentMonthly: 4.38, RecommendedMonthly: 2.19, AnnualSavings: 26.28}
+	recs[1].Cost = &types.Cost{Currency: "EUR", PricePerGiBHour: 0.004, PriceSource: "cluster", Replicas: 1,
+		CurrentMonthly: 0.73, RecommendedMonthly: 1.1, AnnualSavings: -4.38}
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"| Limit Optimization (%) | Replicas | Price (EUR/GiB-hour) | Price Source | Current Cost (EUR/month) | Recommended Cost (EUR/month) | Annual Savings (EUR) |",
+		"| 50.0% | 62.5% | 3 | 0.004 | default | 4.38 | 2.19 | 26.28 |",
+		"| -50.0% | -12.5% | 1 | 0.004 | cluster | 0.73 | 1.10 | -4.38 |",
+		"| Memory Cost (EUR/month) | 5.11 | 3.29 | 1.82 | 35.6% |",
+		"| Annual Savings (EUR) |  |  | 21.90 |  |",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected output containing %q, got:\n%s", expected, stdout.String())
+		}
+	}
+}
+
+func TestMarkdownExporter_Export_Empty(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewMarkdownExporter(Stdout)
+	exporter.stdout = &stdout
+
+	if err := exporter.Export(nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "No recommendations generated.") {
+		t.Errorf("Unexpected output: %s", stdout.String())
+	}
+}
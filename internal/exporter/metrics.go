@@ -0,0 +1,227 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// MetricsMediaType is the content type of the Prometheus text exposition format
+const MetricsMediaType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metricFamily is a gauge published for every container, possibly as several
+// series told apart by an extra label
+type metricFamily struct {
+	name   string
+	help   string
+	series func(rec types.RecommendationResult) []metricSample
+
+	// optional families are left out when no container has samples
+	optional bool
+}
+
+// metricSample is a value of a family, with its extra label if any
+type metricSample struct {
+	label string // extra label, name=value
+	value float64
+}
+
+// metricFamilies are the series published for every recommendation
+var metricFamilies = []metricFamily{
+	{
+		name: "resources_recommend_memory_request_bytes",
+		help: "Memory request of the container, current or recommended.",
+		series: func(rec types.RecommendationResult) []metricSample {
+			return []metricSample{
+				{`kind="current"`, rec.CurrentRequestBytes},
+				{`kind="recommended"`, rec.RecommendedRequestBytes},
+			}
+		},
+	},
+	{
+		name: "resources_recommend_memory_limit_bytes",
+		help: "Memory limit of the container, current or recommended.",
+		series: func(rec types.RecommendationResult) []metricSample {
+			return []metricSample{
+				{`kind="current"`, rec.CurrentLimitBytes},
+				{`kind="recommended"`, rec.RecommendedLimitBytes},
+			}
+		},
+	},
+	{
+		name: "resources_recommend_memory_savings_bytes",
+		help: "Memory saved by applying the recommendation, negative when it grows.",
+		series: func(rec types.RecommendationResult) []metricSample {
+			return []metricSample{
+				{`resource="request"`, rec.CurrentRequestBytes - rec.RecommendedRequestBytes},
+				{`resource="limit"`, rec.CurrentLimitBytes - rec.RecommendedLimitBytes},
+			}
+		},
+	},
+	{
+		name: "resources_recommend_memory_savings_ratio",
+		help: "Share of the current memory saved by applying the recommendation.",
+		series: func(rec types.RecommendationResult) []metricSample {
+			return []metricSample{
+				{`resource="request"`, rec.RequestOptimizationPct / 100},
+				{`resource="limit"`, rec.LimitOptimizationPct / 100},
+			}
+		},
+	},
+	{
+		name: "resources_recommend_confidence_ratio",
+		help: "Share of the decay-weighted analysis window backed by usage samples.",
+		series: func(rec types.RecommendationResult) []metricSample {
+			return []metricSample{{"", rec.Confidence}}
+		},
+	},
+	{
+		name: "resources_recommend_memory_monthly_cost",
+		help: "Monthly cost of the memory requests of the container across its replicas, current or recommended.",
+		series: func(rec types.RecommendationResult) []metricSample {
+			if rec.Cost == nil {
+				return nil
+			}
+			currency := metricLabel("currency", rec.Cost.Currency)
+			return []metricSample{
+				{`kind="current",` + currency, rec.Cost.CurrentMonthly},
+				{`kind="recommended",` + currency, rec.Cost.RecommendedMonthly},
+			}
+		},
+		optional: true,
+	},
+	{
+		name: "resources_recommend_memory_annual_savings",
+		help: "Annual cost saved by applying the recommendation, negative when it grows.",
+		series: func(rec types.RecommendationResult) []metricSample {
+			if rec.Cost == nil {
+				return nil
+			}
+			return []metricSample{{metricLabel("currency", rec.Cost.Currency), rec.Cost.AnnualSavings}}
+		},
+		optional: true,
+	},
+}
+
+// MetricsExporter exports recommendations in the Prometheus text exposition
+// format, for the textfile collector of node_exporter. Files are replaced
+// atomically so that the collector never reads a partial file.
+type MetricsExporter struct {
+	filename string
+	stdout   io.Writer
+}
+
+// NewMetricsExporter creates a new metrics exporter, writing to stdout when filename is Stdout
+func NewMetricsExporter(filename string) *MetricsExporter {
+	return &MetricsExporter{
+		filename: filename,
+	}
+}
+
+// GetFilename returns the filename that will be used for export
+func (e *MetricsExporter) GetFilename() string {
+	return e.filename
+}
+
+// Export writes the metrics of recommendations
+func (e *MetricsExporter) Export(recommendations []types.RecommendationResult) error {
+	if e.filename == Stdout {
+		return writeOutput(e.filename, e.stdout, func(w io.Writer) error {
+			return WriteMetrics(w, recommendations)
+		})
+	}
+
+	// Write next to the destination, under a name the collector ignores,
+	// then move the complete file into place
+	temp, err := os.CreateTemp(filepath.Dir(e.filename), "."+filepath.Base(e.filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", e.filename, err)
+	}
+	defer os.Remove(temp.Name())
+
+	if err := WriteMetrics(temp, recommendations); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", e.filename, err)
+	}
+	if err := os.Chmod(temp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", e.filename, err)
+	}
+	if err := os.Rename(temp.Name(), e.filename); err != nil {
+		return fmt.Errorf("failed to write %s: %w", e.filename, err)
+	}
+	return nil
+}
+
+// WriteMetrics writes the gauges of recommendations in the Prometheus text
+// exposition format, labelled by cluster, namespace, workload and container
+func WriteMetrics(w io.Writer, recommendations []types.RecommendationResult) error {
+	sorted := sortedRecommendations(recommended(recommendations))
+	buffered := bufio.NewWriter(w)
+	for _, family := range metricFamilies {
+		if family.optional && !hasSamples(family, sorted) {
+			continue
+		}
+		fmt.Fprintf(buffered, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(buffered, "# TYPE %s gauge\n", family.name)
+		for _, rec := range sorted {
+			labels := metricLabels(rec)
+			for _, sample := range family.series(rec) {
+				sampleLabels := labels
+				if sample.label != "" {
+					sampleLabels += "," + sample.label
+				}
+				fmt.Fprintf(buffered, "%s{%s} %s\n", family.name, sampleLabels, formatMetricValue(sample.value))
+			}
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// hasSamples reports whether family has samples for any of recommendations
+func hasSamples(family metricFamily, recommendations []types.RecommendationResult) bool {
+	for _, rec := range recommendations {
+		if len(family.series(rec)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// metricLabels returns the labels identifying the container of rec, the
+// cluster being left out when unset
+func metricLabels(rec types.RecommendationResult) string {
+	var labels []string
+	if rec.Cluster != "" {
+		labels = append(labels, metricLabel("cluster", rec.Cluster))
+	}
+	labels = append(labels,
+		metricLabel("namespace", rec.Namespace),
+		metricLabel("workload", rec.Deployment),
+		metricLabel("container", rec.Container))
+	return strings.Join(labels, ",")
+}
+
+// metricLabelEscaper escapes label values as the text exposition format requires
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricLabel returns the name="value" pair of a label
+func metricLabel(name, value string) string {
+	return name + `="` + metricLabelEscaper.Replace(value) + `"`
+}
+
+// formatMetricValue formats a sample value with the shortest exact representation
+func formatMetricValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	const mb = 1024 * 1024
+	recs := []types.RecommendationResult{
+		{
+			Cluster:                 "prod-eu",
+			Namespace:               "shop",
+			Deployment:              "web-app",
+			Container:               `nginx"edge`,
+			CurrentRequestBytes:     512 * mb,
+			CurrentLimitBytes:       1024 * mb,
+			RecommendedRequestBytes: 256 * mb,
+			RecommendedLimitBytes:   384 * mb,
+			RequestOptimizationPct:  50,
+			LimitOptimizationPct:    62.5,
+			Confidence:              0.75,
+		},
+	}
+
+	var out bytes.Buffer
+	if err := WriteMetrics(&out, recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	labels := `cluster="prod-eu",namespace="shop",workload="web-app",container="nginx\"edge"`
+	for _, line := range []string{
+		"# TYPE resources_recommend_memory_request_bytes gauge",
+		"resources_recommend_memory_request_bytes{" + labels + `,kind="current"} 536870912`,
+		"resources_recommend_memory_request_bytes{" + labels + `,kind="recommended"} 268435456`,
+		"resources_recommend_memory_limit_bytes{" + labels + `,kind="recommended"} 402653184`,
+		"resources_recommend_memory_savings_bytes{" + labels + `,resource="limit"} 671088640`,
+		"resources_recommend_memory_savings_ratio{" + labels + `,resource="request"} 0.5`,
+		"resources_recommend_confidence_ratio{" + labels + "} 0.75",
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestWriteMetrics_WithoutCluster(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteMetrics(&out, sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "cluster=") {
+		t.Error("Expected no cluster label without clusters")
+	}
+	expected := `resources_recommend_memory_savings_ratio{namespace="production",workload="api|server",container="app",resource="limit"} -0.125`
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("Expected line %q in:\n%s", expected, out.String())
+	}
+}
+
+func TestWriteMetrics_Costs(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteMetrics(&out, sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "cost") || strings.Contains(out.String(), "annual_savings") {
+		t.Errorf("Expected no cost families without prices, got:\n%s", out.String())
+	}
+
+	recs := sampleRecommendations()
+	recs[0].Cost = &types.Cost{Currency: "EUR", CurrentMonthly: 4.38, RecommendedMonthly: 2.19, AnnualSavings: 26.28}
+	out.Reset()
+	if err := WriteMetrics(&out, recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	labels := `namespace="production",workload="web-app",container="nginx"`
+	for _, line := range []string{
+		"# TYPE resources_recommend_memory_monthly_cost gauge",
+		"resources_recommend_memory_monthly_cost{" + labels + `,kind="current",currency="EUR"} 4.38`,
+		"resources_recommend_memory_monthly_cost{" + labels + `,kind="recommended",currency="EUR"} 2.19`,
+		"resources_recommend_memory_annual_savings{" + labels + `,currency="EUR"} 26.28`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, out.String())
+		}
+	}
+	if strings.Contains(out.String(), `workload="api|server",container="app",currency`) {
+		t.Error("Expected no cost series for the unpriced container")
+	}
+}
+
+func TestMetricsExporter_Export(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "recommendations.prom")
+	if err := os.WriteFile(filename, []byte("stale\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewMetricsExporter(filename).Export(sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "stale") || !strings.HasPrefix(string(content), "# HELP") {
+		t.Errorf("Expected the file to be replaced, got:\n%s", content)
+	}
+	info, _ := os.Stat(filename)
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("Expected mode 0644, got %v", info.Mode().Perm())
+	}
+
+	// The temporary file is moved into place
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the metrics file, got %d entries", len(entries))
+	}
+}
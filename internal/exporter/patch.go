@@ -0,0 +1,229 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PatchExporter exports one strategic-merge patch per deployment, setting the
+// recommended memory request and limit of each container. Container entries
+// are merged by name, which is why no JSON patch is generated: it would have
+// to address containers by their index, which the metrics do not expose.
+//
+// The patches are written as a single multi-document YAML file, or as one file
+// per deployment when filename is a directory (an existing one or a path
+// ending with a separator).
+type PatchExporter struct {
+	filename string
+	stdout   io.Writer
+}
+
+// NewPatchExporter creates a new patch exporter, writing to stdout when filename is Stdout
+func NewPatchExporter(filename string) *PatchExporter {
+	return &PatchExporter{
+		filename: filename,
+	}
+}
+
+// workloadPatch is a strategic-merge patch of a deployment
+type workloadPatch struct {
+	APIVersion string        `yaml:"apiVersion"`
+	Kind       string        `yaml:"kind"`
+	Metadata   patchMetadata `yaml:"metadata"`
+	Spec       patchSpec     `yaml:"spec"`
+
+	cluster string
+}
+
+type patchMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+type patchSpec struct {
+	Template struct {
+		Spec struct {
+			Containers []containerPatch `yaml:"containers"`
+		} `yaml:"spec"`
+	} `yaml:"template"`
+}
+
+type containerPatch struct {
+	Name      string `yaml:"name"`
+	Resources struct {
+		Requests memoryResource `yaml:"requests"`
+		Limits   memoryResource `yaml:"limits"`
+	} `yaml:"resources"`
+}
+
+type memoryResource struct {
+	Memory string `yaml:"memory"`
+}
+
+// Export writes the patches of every deployment with recommendations
+func (e *PatchExporter) Export(recommendations []types.RecommendationResult) error {
+	patches := buildPatches(recommended(recommendations))
+
+	docs := make([]workloadDocument, len(patches))
+	for i, patch := range patches {
+		docs[i] = workloadDocument{
+			cluster:    patch.cluster,
+			namespace:  patch.Metadata.Namespace,
+			deployment: patch.Metadata.Name,
+			value:      patch,
+		}
+	}
+	return writeWorkloadDocuments(e.filename, e.stdout, "yaml", docs)
+}
+
+// isDirectory reports whether filename is a directory receiving one file per
+// deployment: an existing directory or a path ending with a separator
+func isDirectory(filename string) bool {
+	if filename == Stdout {
+		return false
+	}
+	if strings.HasSuffix(filename, "/") || strings.HasSuffix(filename, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(filename)
+	return err == nil && info.IsDir()
+}
+
+// GetFilename returns the filename that will be used for export
+func (e *PatchExporter) GetFilename() string {
+	return e.filename
+}
+
+// buildPatches groups recommendations by deployment, sorted by cluster,
+// namespace and name, with containers sorted by name
+func buildPatches(recommendations []types.RecommendationResult) []*workloadPatch {
+	type workloadKey struct{ cluster, namespace, deployment string }
+
+	var keys []workloadKey
+	patches := make(map[workloadKey]*workloadPatch)
+	for _, rec := range recommendations {
+		key := workloadKey{rec.Cluster, rec.Namespace, rec.Deployment}
+		patch, ok := patches[key]
+		if !ok {
+			patch = &workloadPatch{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Metadata:   patchMetadata{Name: rec.Deployment, Namespace: rec.Namespace},
+				cluster:    rec.Cluster,
+			}
+			patches[key] = patch
+			keys = append(keys, key)
+		}
+
+		container := containerPatch{Name: rec.Container}
+		container.Resources.Requests.Memory = MemoryQuantity(rec.RecommendedRequestMB)
+		container.Resources.Limits.Memory = MemoryQuantity(rec.RecommendedLimitMB)
+		containers := &patch.Spec.Template.Spec.Containers
+		*containers = append(*containers, container)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.cluster != b.cluster {
+			return a.cluster < b.cluster
+		}
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		return a.deployment < b.deployment
+	})
+
+	result := make([]*workloadPatch, len(keys))
+	for i, key := range keys {
+		patch := patches[key]
+		containers := patch.Spec.Template.Spec.Containers
+		sort.Slice(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+		result[i] = patch
+	}
+	return result
+}
+
+// MemoryQuantity renders a memory size in MiB as a Kubernetes quantity, at least 1Mi
+func MemoryQuantity(mb int64) string {
+	if mb < 1 {
+		mb = 1
+	}
+	return fmt.Sprintf("%dMi", mb)
+}
+
+// workloadDocument is a YAML document generated for a deployment
+type workloadDocument struct {
+	cluster    string
+	namespace  string
+	deployment string
+	value      interface{}
+}
+
+// writeWorkloadDocuments writes documents as a single multi-document YAML
+// file, or as one file per deployment named after extension when filename is
+// a directory
+func writeWorkloadDocuments(filename string, stdout io.Writer, extension string, docs []workloadDocument) error {
+	if isDirectory(filename) {
+		if err := os.MkdirAll(filename, 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		for _, doc := range docs {
+			path := filepath.Join(filename, workloadFileName(doc.cluster, doc.namespace, doc.deployment, extension))
+			err := writeOutput(path, nil, func(w io.Writer) error {
+				return writeWorkloadDocument(w, doc)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return writeOutput(filename, stdout, func(w io.Writer) error {
+		for i, doc := range docs {
+			if i > 0 {
+				if _, err := io.WriteString(w, "---\n"); err != nil {
+					return err
+				}
+			}
+			if err := writeWorkloadDocument(w, doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// workloadFileName returns the name of the file of a deployment in directory
+// output, [cluster.]namespace.deployment.extension
+func workloadFileName(cluster, namespace, deployment, extension string) string {
+	parts := []string{namespace, deployment, extension}
+	if cluster != "" {
+		parts = append([]string{cluster}, parts...)
+	}
+	return strings.Join(parts, ".")
+}
+
+// writeWorkloadDocument writes a single document, preceded by its cluster if any
+func writeWorkloadDocument(w io.Writer, doc workloadDocument) error {
+	if doc.cluster != "" {
+		if _, err := fmt.Fprintf(w, "# cluster: %s\n", doc.cluster); err != nil {
+			return err
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc.value); err != nil {
+		return fmt.Errorf("failed to encode document for %s/%s: %w", doc.namespace, doc.deployment, err)
+	}
+	return encoder.Close()
+}
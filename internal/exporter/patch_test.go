@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestPatchExporter_Export_MultiDocument(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewPatchExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := sampleRecommendations()
+	sidecar := recs[0]
+	sidecar.Container = "envoy"
+	sidecar.RecommendedRequestMB, sidecar.RecommendedLimitMB = 0, 0
+	recs = append(recs, sidecar)
+
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(&stdout)
+	var patches []workloadPatch
+	for {
+		var patch workloadPatch
+		if err := decoder.Decode(&patch); err != nil {
+			break
+		}
+		patches = append(patches, patch)
+	}
+	if len(patches) != 2 {
+		t.Fatalf("Expected 2 patch documents, got %d", len(patches))
+	}
+
+	// Patches are sorted by deployment, containers by name
+	api, web := patches[0], patches[1]
+	if api.Metadata.Name != "api|server" || web.Metadata.Name != "web-app" {
+		t.Fatalf("Unexpected patch order: %s, %s", api.Metadata.Name, web.Metadata.Name)
+	}
+	if web.Kind != "Deployment" || web.APIVersion != "apps/v1" || web.Metadata.Namespace != "production" {
+		t.Errorf("Unexpected patch header: %+v", web)
+	}
+
+	containers := web.Spec.Template.Spec.Containers
+	if len(containers) != 2 || containers[0].Name != "envoy" || containers[1].Name != "nginx" {
+		t.Fatalf("Unexpected containers: %+v", containers)
+	}
+	if containers[1].Resources.Requests.Memory != "256Mi" || containers[1].Resources.Limits.Memory != "384Mi" {
+		t.Errorf("Unexpected nginx resources: %+v", containers[1].Resources)
+	}
+	if containers[0].Resources.Requests.Memory != "1Mi" {
+		t.Errorf("Expected sub-MiB recommendations to round to 1Mi, got %s", containers[0].Resources.Requests.Memory)
+	}
+}
+
+func TestPatchExporter_Export_VerificationOnly(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewPatchExporter(Stdout)
+	exporter.stdout = &stdout
+
+	recs := []types.RecommendationResult{{Namespace: "shop", Deployment: "web", Container: "app", Verification: &types.Verification{}, VerificationOnly: true}}
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(stdout.String(), "web") {
+		t.Errorf("Expected no patch for a verification-only result, got:\n%s", stdout.String())
+	}
+}
+
+func TestPatchExporter_Export_Directory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "patches") + string(filepath.Separator)
+	recs := sampleRecommendations()
+	recs[0].Cluster = "prod-eu"
+
+	if err := NewPatchExporter(dir).Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read patch directory: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	expected := []string{"prod-eu.production.web-app.yaml", "production.api|server.yaml"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected files %v, got %v", expected, names)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, expected[0]))
+	if !strings.HasPrefix(string(content), "# cluster: prod-eu\napiVersion: apps/v1\nkind: Deployment\n") {
+		t.Errorf("Unexpected patch file:\n%s", content)
+	}
+	if !strings.Contains(string(content), "        - name: nginx\n          resources:\n            requests:\n              memory: 256Mi\n") {
+		t.Errorf("Expected nginx resources in the patch, got:\n%s", content)
+	}
+}
+
+func TestPatchExporter_Export_ExistingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewPatchExporter(dir).Export(sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "production.web-app.yaml")); err != nil {
+		t.Errorf("Expected a patch file in the existing directory: %v", err)
+	}
+}
+
+func TestMemoryQuantity(t *testing.T) {
+	if q := MemoryQuantity(384); q != "384Mi" {
+		t.Errorf("Expected 384Mi, got %s", q)
+	}
+	if q := MemoryQuantity(0); q != "1Mi" {
+		t.Errorf("Expected 1Mi for sub-MiB values, got %s", q)
+	}
+}
@@ -0,0 +1,179 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// VPA update modes, from recommendations only to evicting pods as needed
+const (
+	VPAUpdateModeOff               = "Off"
+	VPAUpdateModeInitial           = "Initial"
+	VPAUpdateModeRecreate          = "Recreate"
+	VPAUpdateModeInPlaceOrRecreate = "InPlaceOrRecreate"
+	VPAUpdateModeAuto              = "Auto"
+)
+
+// vpaUpdateModes lists the supported VPA update modes
+var vpaUpdateModes = []string{
+	VPAUpdateModeOff,
+	VPAUpdateModeInitial,
+	VPAUpdateModeRecreate,
+	VPAUpdateModeInPlaceOrRecreate,
+	VPAUpdateModeAuto,
+}
+
+// ParseVPAUpdateMode returns the VPA update mode named by mode, ignoring
+// case, or an error if the mode is unknown
+func ParseVPAUpdateMode(mode string) (string, error) {
+	for _, supported := range vpaUpdateModes {
+		if strings.EqualFold(strings.TrimSpace(mode), supported) {
+			return supported, nil
+		}
+	}
+	return "", fmt.Errorf("unknown VPA update mode %q (supported: %s)", mode, strings.Join(vpaUpdateModes, ", "))
+}
+
+// VPAExporter exports one VerticalPodAutoscaler per deployment, controlling
+// the memory of every recommended container. The VPA may move the request of
+// a container within the band the analysis deems safe: from the recommended
+// request divided by the limit multiplier up to the recommended limit,
+// narrowed to the configured request bounds.
+//
+// The objects are written as a single multi-document YAML file, or as one file
+// per deployment when filename is a directory (an existing one or a path
+// ending with a separator).
+type VPAExporter struct {
+	filename     string
+	stdout       io.Writer
+	updateMode   string
+	minRequestMB int64
+	maxRequestMB int64
+}
+
+// NewVPAExporter creates a new VPA exporter in recommendation-only mode,
+// writing to stdout when filename is Stdout
+func NewVPAExporter(filename string) *VPAExporter {
+	return &VPAExporter{
+		filename:   filename,
+		updateMode: VPAUpdateModeOff,
+	}
+}
+
+// SetUpdateMode sets the update mode of the generated VPAs
+func (e *VPAExporter) SetUpdateMode(mode string) {
+	e.updateMode = mode
+}
+
+// SetRequestBounds sets the bounds of the memory request, 0 leaving a bound open
+func (e *VPAExporter) SetRequestBounds(minMB, maxMB int64) {
+	e.minRequestMB = minMB
+	e.maxRequestMB = maxMB
+}
+
+// GetFilename returns the filename that will be used for export
+func (e *VPAExporter) GetFilename() string {
+	return e.filename
+}
+
+// verticalPodAutoscaler is an autoscaling.k8s.io/v1 VerticalPodAutoscaler
+type verticalPodAutoscaler struct {
+	APIVersion string        `yaml:"apiVersion"`
+	Kind       string        `yaml:"kind"`
+	Metadata   patchMetadata `yaml:"metadata"`
+	Spec       vpaSpec       `yaml:"spec"`
+}
+
+type vpaSpec struct {
+	TargetRef struct {
+		APIVersion string `yaml:"apiVersion"`
+		Kind       string `yaml:"kind"`
+		Name       string `yaml:"name"`
+	} `yaml:"targetRef"`
+	UpdatePolicy struct {
+		UpdateMode string `yaml:"updateMode"`
+	} `yaml:"updatePolicy"`
+	ResourcePolicy struct {
+		ContainerPolicies []vpaContainerPolicy `yaml:"containerPolicies"`
+	} `yaml:"resourcePolicy"`
+}
+
+type vpaContainerPolicy struct {
+	ContainerName       string         `yaml:"containerName"`
+	ControlledResources []string       `yaml:"controlledResources"`
+	ControlledValues    string         `yaml:"controlledValues"`
+	MinAllowed          memoryResource `yaml:"minAllowed"`
+	MaxAllowed          memoryResource `yaml:"maxAllowed"`
+}
+
+// Export writes the VPA of every deployment with recommendations
+func (e *VPAExporter) Export(recommendations []types.RecommendationResult) error {
+	var docs []workloadDocument
+	var vpa *verticalPodAutoscaler
+	for _, rec := range sortedRecommendations(recommended(recommendations)) {
+		if n := len(docs); n == 0 || docs[n-1].cluster != rec.Cluster ||
+			docs[n-1].namespace != rec.Namespace || docs[n-1].deployment != rec.Deployment {
+			vpa = e.newVPA(rec)
+			docs = append(docs, workloadDocument{
+				cluster:    rec.Cluster,
+				namespace:  rec.Namespace,
+				deployment: rec.Deployment,
+				value:      vpa,
+			})
+		}
+		policies := &vpa.Spec.ResourcePolicy.ContainerPolicies
+		*policies = append(*policies, e.containerPolicy(rec))
+	}
+
+	return writeWorkloadDocuments(e.filename, e.stdout, "vpa.yaml", docs)
+}
+
+// newVPA returns the VPA targeting the deployment of rec, without container policies
+func (e *VPAExporter) newVPA(rec types.RecommendationResult) *verticalPodAutoscaler {
+	vpa := &verticalPodAutoscaler{
+		APIVersion: "autoscaling.k8s.io/v1",
+		Kind:       "VerticalPodAutoscaler",
+		Metadata:   patchMetadata{Name: rec.Deployment, Namespace: rec.Namespace},
+	}
+	vpa.Spec.TargetRef.APIVersion = "apps/v1"
+	vpa.Spec.TargetRef.Kind = "Deployment"
+	vpa.Spec.TargetRef.Name = rec.Deployment
+	vpa.Spec.UpdatePolicy.UpdateMode = e.updateMode
+	return vpa
+}
+
+// containerPolicy returns the policy letting the VPA control the memory of a container
+func (e *VPAExporter) containerPolicy(rec types.RecommendationResult) vpaContainerPolicy {
+	minMB, maxMB := e.allowedRange(rec)
+	return vpaContainerPolicy{
+		ContainerName:       rec.Container,
+		ControlledResources: []string{"memory"},
+		ControlledValues:    "RequestsAndLimits",
+		MinAllowed:          memoryResource{Memory: MemoryQuantity(minMB)},
+		MaxAllowed:          memoryResource{Memory: MemoryQuantity(maxMB)},
+	}
+}
+
+// allowedRange returns the memory request range of a container in MiB: the
+// recommended request divided by the limit multiplier up to the recommended
+// limit, narrowed to the request bounds
+func (e *VPAExporter) allowedRange(rec types.RecommendationResult) (int64, int64) {
+	multiplier := rec.MemoryLimitMultiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	minMB := int64(math.Floor(float64(rec.RecommendedRequestMB) / multiplier))
+	maxMB := max(rec.RecommendedLimitMB, rec.RecommendedRequestMB)
+
+	if e.minRequestMB > 0 {
+		minMB = max(minMB, e.minRequestMB)
+	}
+	if e.maxRequestMB > 0 {
+		maxMB = min(maxMB, e.maxRequestMB)
+	}
+	return min(minMB, maxMB), maxMB
+}
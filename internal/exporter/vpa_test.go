@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseVPAUpdateMode(t *testing.T) {
+	if mode, err := ParseVPAUpdateMode("recreate"); err != nil || mode != VPAUpdateModeRecreate {
+		t.Errorf("Expected Recreate, got %q (%v)", mode, err)
+	}
+	if mode, err := ParseVPAUpdateMode(" Off "); err != nil || mode != VPAUpdateModeOff {
+		t.Errorf("Expected Off, got %q (%v)", mode, err)
+	}
+	if _, err := ParseVPAUpdateMode("sometimes"); err == nil {
+		t.Error("Expected error for an unknown update mode")
+	}
+}
+
+func TestVPAExporter_Export(t *testing.T) {
+	var stdout bytes.Buffer
+	exporter := NewVPAExporter(Stdout)
+	exporter.stdout = &stdout
+	exporter.SetUpdateMode(VPAUpdateModeInitial)
+
+	recs := sampleRecommendations()
+	sidecar := recs[0]
+	sidecar.Container = "envoy"
+	recs = append(recs, sidecar)
+
+	if err := exporter.Export(recs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	decoder := yaml.NewDecoder(&stdout)
+	var vpas []verticalPodAutoscaler
+	for {
+		var vpa verticalPodAutoscaler
+		if err := decoder.Decode(&vpa); err != nil {
+			break
+		}
+		vpas = append(vpas, vpa)
+	}
+	if len(vpas) != 2 {
+		t.Fatalf("Expected 2 VPA documents, got %d", len(vpas))
+	}
+
+	web := vpas[1]
+	if web.APIVersion != "autoscaling.k8s.io/v1" || web.Kind != "VerticalPodAutoscaler" || web.Metadata.Name != "web-app" {
+		t.Errorf("Unexpected VPA header: %+v", web)
+	}
+	if web.Spec.TargetRef.Kind != "Deployment" || web.Spec.TargetRef.Name != "web-app" {
+		t.Errorf("Unexpected target: %+v", web.Spec.TargetRef)
+	}
+	if web.Spec.UpdatePolicy.UpdateMode != VPAUpdateModeInitial {
+		t.Errorf("Expected update mode Initial, got %s", web.Spec.UpdatePolicy.UpdateMode)
+	}
+
+	policies := web.Spec.ResourcePolicy.ContainerPolicies
+	if len(policies) != 2 || policies[0].ContainerName != "envoy" || policies[1].ContainerName != "nginx" {
+		t.Fatalf("Unexpected container policies: %+v", policies)
+	}
+	// 256Mi recommended with a 1.5 multiplier allows 170Mi up to the 384Mi limit
+	nginx := policies[1]
+	if nginx.MinAllowed.Memory != "170Mi" || nginx.MaxAllowed.Memory != "384Mi" {
+		t.Errorf("Unexpected nginx bounds: %+v - %+v", nginx.MinAllowed, nginx.MaxAllowed)
+	}
+	if len(nginx.ControlledResources) != 1 || nginx.ControlledResources[0] != "memory" || nginx.ControlledValues != "RequestsAndLimits" {
+		t.Errorf("Unexpected controlled resources: %+v", nginx)
+	}
+}
+
+func TestVPAExporter_allowedRange(t *testing.T) {
+	rec := sampleRecommendations()[0] // 256Mi request, 384Mi limit, 1.5 multiplier
+
+	tests := []struct {
+		name                     string
+		minMB, maxMB             int64
+		expectedMin, expectedMax int64
+	}{
+		{"Open bounds", 0, 0, 170, 384},
+		{"Minimum bound", 200, 0, 200, 384},
+		{"Maximum bound", 0, 300, 170, 300},
+		{"Bounds below the band", 0, 100, 100, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := NewVPAExporter(Stdout)
+			exporter.SetRequestBounds(tt.minMB, tt.maxMB)
+			minMB, maxMB := exporter.allowedRange(rec)
+			if minMB != tt.expectedMin || maxMB != tt.expectedMax {
+				t.Errorf("Expected %d-%d, got %d-%d", tt.expectedMin, tt.expectedMax, minMB, maxMB)
+			}
+		})
+	}
+}
+
+func TestVPAExporter_Export_Directory(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewVPAExporter(dir).Export(sampleRecommendations()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, name := range []string{"production.web-app.vpa.yaml", "production.api|server.vpa.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected VPA file %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package history
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// ContainerKey identifies the container of a recommendation across runs
+type ContainerKey struct {
+	Cluster    string `json:"cluster,omitempty"`
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+	Container  string `json:"container"`
+}
+
+// KeyOf returns the key of the container of rec
+func KeyOf(rec types.RecommendationResult) ContainerKey {
+	return ContainerKey{Cluster: rec.Cluster, Namespace: rec.Namespace, Deployment: rec.Deployment, Container: rec.Container}
+}
+
+// String returns the key as [cluster/]namespace/deployment/container
+func (k ContainerKey) String() string {
+	name := k.Namespace + "/" + k.Deployment + "/" + k.Container
+	if k.Cluster != "" {
+		name = k.Cluster + "/" + name
+	}
+	return name
+}
+
+// less orders keys by cluster, namespace, deployment and container
+func (k ContainerKey) less(other ContainerKey) bool {
+	if k.Cluster != other.Cluster {
+		return k.Cluster < other.Cluster
+	}
+	if k.Namespace != other.Namespace {
+		return k.Namespace < other.Namespace
+	}
+	if k.Deployment != other.Deployment {
+		return k.Deployment < other.Deployment
+	}
+	return k.Container < other.Container
+}
+
+// Change is a container whose recommendation moved between two runs
+type Change struct {
+	ContainerKey
+	PreviousRequestMB int64 `json:"previous_request_mb"`
+	RequestMB         int64 `json:"request_mb"`
+	PreviousLimitMB   int64 `json:"previous_limit_mb"`
+	LimitMB           int64 `json:"limit_mb"`
+}
+
+// RequestDeltaMB returns the move of the recommended request, positive when it grows
+func (c Change) RequestDeltaMB() int64 {
+	return c.RequestMB - c.PreviousRequestMB
+}
+
+// RequestDeltaPct returns the move of the recommended request in percent of
+// the previous request, 0 when there was none
+func (c Change) RequestDeltaPct() float64 {
+	return deltaPct(c.PreviousRequestMB, c.RequestMB)
+}
+
+// LimitDeltaPct returns the move of the recommended limit in percent of the
+// previous limit, 0 when there was none
+func (c Change) LimitDeltaPct() float64 {
+	return deltaPct(c.PreviousLimitMB, c.LimitMB)
+}
+
+// LimitDeltaMB returns the move of the recommended limit, positive when it grows
+func (c Change) LimitDeltaMB() int64 {
+	return c.LimitMB - c.PreviousLimitMB
+}
+
+// Threshold is the move above which a recommendation counts as changed, in
+// MB or in percent of the previous value; the zero value counts any move
+type Threshold struct {
+	MB      int64
+	Percent float64
+}
+
+// ParseThreshold parses a threshold in MB such as 64, or in percent such as 10%
+func ParseThreshold(value string) (Threshold, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Threshold{}, nil
+	}
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || p < 0 {
+			return Threshold{}, fmt.Errorf("invalid threshold %q, expected MB such as 64 or a percentage such as 10%%", value)
+		}
+		return Threshold{Percent: p}, nil
+	}
+	mb, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || mb < 0 {
+		return Threshold{}, fmt.Errorf("invalid threshold %q, expected MB such as 64 or a percentage such as 10%%", value)
+	}
+	return Threshold{MB: mb}, nil
+}
+
+// String returns the threshold as it is parsed
+func (t Threshold) String() string {
+	if t.Percent > 0 {
+		return strconv.FormatFloat(t.Percent, 'f', -1, 64) + "%"
+	}
+	return fmt.Sprintf("%d MB", t.MB)
+}
+
+// exceeded reports whether the move from previous to current MB passes the threshold
+func (t Threshold) exceeded(previous, current int64) bool {
+	delta := abs(current - previous)
+	if t.Percent > 0 {
+		if previous == 0 {
+			return delta > 0
+		}
+		return float64(delta)/float64(previous)*100 > t.Percent
+	}
+	return delta > t.MB
+}
+
+// Changes lists the containers added, removed and changed between two runs,
+// each sorted by container key
+type Changes struct {
+	Added   []types.RecommendationResult `json:"added"`
+	Removed []types.RecommendationResult `json:"removed"`
+	Changed []Change                     `json:"changed"`
+}
+
+// Compare returns the changes from the previous to the current
+// recommendations. A recommendation counts as changed when its request or
+// limit moved beyond threshold.
+func Compare(previous, current []types.RecommendationResult, threshold Threshold) Changes {
+	before := make(map[ContainerKey]types.RecommendationResult, len(previous))
+	for _, rec := range previous {
+		before[KeyOf(rec)] = rec
+	}
+
+	changes := Changes{
+		Added:   []types.RecommendationResult{},
+		Removed: []types.RecommendationResult{},
+		Changed: []Change{},
+	}
+	seen := make(map[ContainerKey]bool, len(current))
+	for _, rec := range current {
+		key := KeyOf(rec)
+		seen[key] = true
+		old, ok := before[key]
+		if !ok {
+			changes.Added = append(changes.Added, rec)
+			continue
+		}
+		change := Change{
+			ContainerKey:      key,
+			PreviousRequestMB: old.RecommendedRequestMB,
+			RequestMB:         rec.RecommendedRequestMB,
+			PreviousLimitMB:   old.RecommendedLimitMB,
+			LimitMB:           rec.RecommendedLimitMB,
+		}
+		if threshold.exceeded(change.PreviousRequestMB, change.RequestMB) || threshold.exceeded(change.PreviousLimitMB, change.LimitMB) {
+			changes.Changed = append(changes.Changed, change)
+		}
+	}
+	for _, rec := range previous {
+		if !seen[KeyOf(rec)] {
+			changes.Removed = append(changes.Removed, rec)
+		}
+	}
+
+	byKey := func(recs []types.RecommendationResult) func(i, j int) bool {
+		return func(i, j int) bool { return KeyOf(recs[i]).less(KeyOf(recs[j])) }
+	}
+	sort.Slice(changes.Added, byKey(changes.Added))
+	sort.Slice(changes.Removed, byKey(changes.Removed))
+	sort.Slice(changes.Changed, func(i, j int) bool { return changes.Changed[i].ContainerKey.less(changes.Changed[j].ContainerKey) })
+	return changes
+}
+
+// Empty reports whether nothing changed
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// Summary returns a one-line count of the changes
+func (c Changes) Summary() string {
+	return fmt.Sprintf("%d added, %d removed, %d changed", len(c.Added), len(c.Removed), len(c.Changed))
+}
+
+// deltaPct returns the move from previous to current in percent of previous
+func deltaPct(previous, current int64) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return math.Round(float64(current-previous)/float64(previous)*1000) / 10
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,71 @@
+package history
+
+import (
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestCompare(t *testing.T) {
+	previous := []types.RecommendationResult{
+		{Namespace: "shop", Deployment: "web", Container: "nginx", RecommendedRequestMB: 256, RecommendedLimitMB: 384},
+		{Namespace: "shop", Deployment: "web", Container: "sidecar", RecommendedRequestMB: 64, RecommendedLimitMB: 96},
+		{Namespace: "shop", Deployment: "worker", Container: "app", RecommendedRequestMB: 512, RecommendedLimitMB: 768},
+		{Namespace: "shop", Deployment: "legacy", Container: "app", RecommendedRequestMB: 128, RecommendedLimitMB: 192},
+	}
+	current := []types.RecommendationResult{
+		{Namespace: "shop", Deployment: "worker", Container: "app", RecommendedRequestMB: 400, RecommendedLimitMB: 600},
+		{Namespace: "shop", Deployment: "web", Container: "nginx", RecommendedRequestMB: 260, RecommendedLimitMB: 390},
+		{Namespace: "shop", Deployment: "web", Container: "sidecar", RecommendedRequestMB: 64, RecommendedLimitMB: 96},
+		{Cluster: "prod", Namespace: "shop", Deployment: "api", Container: "app", RecommendedRequestMB: 128, RecommendedLimitMB: 192},
+	}
+
+	changes := Compare(previous, current, Threshold{MB: 8})
+	if changes.Summary() != "1 added, 1 removed, 1 changed" {
+		t.Fatalf("Unexpected changes: %s", changes.Summary())
+	}
+	if key := KeyOf(changes.Added[0]).String(); key != "prod/shop/api/app" {
+		t.Errorf("Expected prod/shop/api/app added, got %s", key)
+	}
+	if key := KeyOf(changes.Removed[0]).String(); key != "shop/legacy/app" {
+		t.Errorf("Expected shop/legacy/app removed, got %s", key)
+	}
+	change := changes.Changed[0]
+	if change.String() != "shop/worker/app" || change.RequestDeltaMB() != -112 || change.LimitDeltaMB() != -168 {
+		t.Errorf("Unexpected change: %+v", change)
+	}
+
+	// Without threshold the small move of nginx counts too
+	if changes := Compare(previous, current, Threshold{}); len(changes.Changed) != 2 || changes.Changed[0].Deployment != "web" {
+		t.Errorf("Expected 2 changes sorted by key, got %+v", changes.Changed)
+	}
+	// worker moved by 22%, nginx by less than 2%
+	if changes := Compare(previous, current, Threshold{Percent: 10}); len(changes.Changed) != 1 || changes.Changed[0].RequestDeltaPct() != -21.9 {
+		t.Errorf("Expected worker to change by -21.9%%, got %+v", changes.Changed)
+	}
+	if !Compare(current, current, Threshold{}).Empty() {
+		t.Error("Expected no changes between identical runs")
+	}
+}
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Threshold
+		wantErr  bool
+	}{
+		{"", Threshold{}, false},
+		{"64", Threshold{MB: 64}, false},
+		{"12.5%", Threshold{Percent: 12.5}, false},
+		{"-1", Threshold{}, true},
+		{"ten%", Threshold{}, true},
+		{"64MB", Threshold{}, true},
+	}
+
+	for _, tt := range tests {
+		threshold, err := ParseThreshold(tt.value)
+		if (err != nil) != tt.wantErr || threshold != tt.expected {
+			t.Errorf("ParseThreshold(%q) = %+v, %v", tt.value, threshold, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// runTimeFormat names the file of a run after the time it started, sorting chronologically
+const runTimeFormat = "20060102T150405.000000000Z"
+
+// Run is the outcome of one recommendation run of a namespace
+type Run struct {
+	Namespace       string                       `json:"namespace"`
+	StartedAt       time.Time                    `json:"started_at"`
+	FinishedAt      time.Time                    `json:"finished_at"`
+	Recommendations []types.RecommendationResult `json:"recommendations"`
+}
+
+// Ring keeps the last runs of every namespace in memory and, once a directory
+// is set, as one JSON file per run under a directory per namespace
+type Ring struct {
+	limit int
+	dir   string
+
+	mu   sync.RWMutex
+	runs map[string][]Run // oldest first
+}
+
+// NewRing creates a ring keeping the last limit runs of every namespace
+func NewRing(limit int) *Ring {
+	if limit < 1 {
+		limit = 1
+	}
+	return &Ring{
+		limit: limit,
+		runs:  make(map[string][]Run),
+	}
+}
+
+// SetDirectory persists the runs under dir and loads the runs already saved
+// there, removing those beyond the limit
+func (r *Ring) SetDirectory(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	namespaces, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dir = dir
+	for _, entry := range namespaces {
+		if !entry.IsDir() {
+			continue
+		}
+		runs, err := loadRuns(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if len(runs) > r.limit {
+			for _, old := range runs[:len(runs)-r.limit] {
+				if err := os.Remove(r.runFile(old)); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("failed to remove run: %w", err)
+				}
+			}
+			runs = runs[len(runs)-r.limit:]
+		}
+		r.runs[entry.Name()] = runs
+	}
+	return nil
+}
+
+// Add records run as the latest of its namespace, dropping the oldest runs
+// beyond the limit, and returns the run it follows if any
+func (r *Ring) Add(run Run) (*Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := r.runs[run.Namespace]
+	var previous *Run
+	if len(runs) > 0 {
+		last := runs[len(runs)-1]
+		previous = &last
+	}
+
+	runs = append(runs, run)
+	var dropped []Run
+	if len(runs) > r.limit {
+		dropped = runs[:len(runs)-r.limit]
+		runs = append([]Run(nil), runs[len(runs)-r.limit:]...)
+	}
+	r.runs[run.Namespace] = runs
+
+	if r.dir == "" {
+		return previous, nil
+	}
+	if err := r.save(run); err != nil {
+		return previous, err
+	}
+	for _, old := range dropped {
+		if err := os.Remove(r.runFile(old)); err != nil && !os.IsNotExist(err) {
+			return previous, fmt.Errorf("failed to remove run: %w", err)
+		}
+	}
+	return previous, nil
+}
+
+// Runs returns the runs kept for namespace, oldest first
+func (r *Ring) Runs(namespace string) []Run {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Run(nil), r.runs[namespace]...)
+}
+
+// Latest returns the most recent run of namespace
+func (r *Ring) Latest(namespace string) (Run, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	runs := r.runs[namespace]
+	if len(runs) == 0 {
+		return Run{}, false
+	}
+	return runs[len(runs)-1], true
+}
+
+// Namespaces returns the namespaces with runs, sorted
+func (r *Ring) Namespaces() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	namespaces := make([]string, 0, len(r.runs))
+	for namespace := range r.runs {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// runFile returns the file run is saved to
+func (r *Ring) runFile(run Run) string {
+	return filepath.Join(r.dir, run.Namespace, run.StartedAt.UTC().Format(runTimeFormat)+".json")
+}
+
+// save writes run to its file
+func (r *Ring) save(run Run) error {
+	filename := r.runFile(run)
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	content, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode run: %w", err)
+	}
+	if err := os.WriteFile(filename, content, 0o644); err != nil {
+		return fmt.Errorf("failed to save run: %w", err)
+	}
+	return nil
+}
+
+// loadRuns reads the runs saved in the directory of a namespace, oldest first
+func loadRuns(dir string) ([]Run, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var runs []Run
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read run: %w", err)
+		}
+		var run Run
+		if err := json.Unmarshal(content, &run); err != nil {
+			return nil, fmt.Errorf("failed to decode run %s: %w", filepath.Join(dir, entry.Name()), err)
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+	return runs, nil
+}
@@ -0,0 +1,97 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func sampleRun(namespace string, hour int, requestMB int64) Run {
+	started := time.Date(2024, 3, 1, hour, 0, 0, 0, time.UTC)
+	return Run{
+		Namespace:  namespace,
+		StartedAt:  started,
+		FinishedAt: started.Add(time.Minute),
+		Recommendations: []types.RecommendationResult{
+			{Namespace: namespace, Deployment: "web", Container: "app", RecommendedRequestMB: requestMB},
+		},
+	}
+}
+
+func TestRing_Add(t *testing.T) {
+	ring := NewRing(2)
+
+	previous, err := ring.Add(sampleRun("shop", 1, 100))
+	if err != nil || previous != nil {
+		t.Fatalf("Expected no previous run, got %v, %v", previous, err)
+	}
+	ring.Add(sampleRun("shop", 2, 200))
+	previous, _ = ring.Add(sampleRun("shop", 3, 300))
+	if previous == nil || previous.Recommendations[0].RecommendedRequestMB != 200 {
+		t.Errorf("Expected the run of 2:00 as previous, got %+v", previous)
+	}
+
+	runs := ring.Runs("shop")
+	if len(runs) != 2 || runs[0].StartedAt.Hour() != 2 || runs[1].StartedAt.Hour() != 3 {
+		t.Errorf("Expected the last 2 runs, oldest first, got %+v", runs)
+	}
+	if latest, ok := ring.Latest("shop"); !ok || latest.StartedAt.Hour() != 3 {
+		t.Errorf("Expected the run of 3:00 as latest, got %+v", latest)
+	}
+	if _, ok := ring.Latest("billing"); ok {
+		t.Error("Expected no run for billing")
+	}
+}
+
+func TestRing_Directory(t *testing.T) {
+	dir := t.TempDir()
+	ring := NewRing(2)
+	if err := ring.SetDirectory(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for hour := 1; hour <= 3; hour++ {
+		if _, err := ring.Add(sampleRun("shop", hour, int64(hour*100))); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	ring.Add(sampleRun("billing", 1, 50))
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "shop"))
+	if len(entries) != 2 || entries[0].Name() != "20240301T020000.000000000Z.json" {
+		t.Errorf("Expected the files of the last 2 runs, got %v", entries)
+	}
+
+	// A new ring resumes from the saved runs
+	reloaded := NewRing(2)
+	if err := reloaded.SetDirectory(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if namespaces := reloaded.Namespaces(); len(namespaces) != 2 || namespaces[0] != "billing" {
+		t.Errorf("Expected billing and shop, got %v", namespaces)
+	}
+	latest, ok := reloaded.Latest("shop")
+	if !ok || latest.Recommendations[0].RecommendedRequestMB != 300 || !latest.FinishedAt.Equal(latest.StartedAt.Add(time.Minute)) {
+		t.Errorf("Expected the run of 3:00 to be reloaded, got %+v", latest)
+	}
+
+	// A smaller limit prunes the saved runs
+	if err := NewRing(1).SetDirectory(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "shop")); len(entries) != 1 {
+		t.Errorf("Expected 1 file after pruning, got %d", len(entries))
+	}
+}
+
+func TestRing_SetDirectory_InvalidRun(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "shop"), 0o755)
+	os.WriteFile(filepath.Join(dir, "shop", "20240301T010000.000000000Z.json"), []byte("{"), 0o644)
+
+	if err := NewRing(2).SetDirectory(dir); err == nil {
+		t.Error("Expected an error for a corrupt run")
+	}
+}
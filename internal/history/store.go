@@ -0,0 +1,309 @@
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// Buckets of the store
+var (
+	// runsBucket maps the start time and ID of a run to its record
+	runsBucket = []byte("runs")
+	// pointsBucket maps namespace, deployment, container, cluster, start time
+	// and run ID to the recommendation of the container in that run
+	pointsBucket = []byte("recommendations")
+)
+
+// lockTimeout bounds the wait for another process holding the store
+const lockTimeout = 5 * time.Second
+
+// RunRecord is a run saved in the store, without its recommendations
+type RunRecord struct {
+	ID              uint64                      `json:"id"`
+	Namespace       string                      `json:"namespace"`
+	StartedAt       time.Time                   `json:"started_at"`
+	FinishedAt      time.Time                   `json:"finished_at"`
+	Config          *types.RecommendationConfig `json:"config,omitempty"`
+	Recommendations int                         `json:"recommendations"`
+}
+
+// Point is the recommendation of a container in one run
+type Point struct {
+	RunID          uint64                     `json:"run_id"`
+	StartedAt      time.Time                  `json:"started_at"`
+	Recommendation types.RecommendationResult `json:"recommendation"`
+}
+
+// SeriesQuery selects the containers of a time series; empty fields match any value
+type SeriesQuery struct {
+	Cluster    string
+	Namespace  string
+	Deployment string
+	Container  string
+	Since      time.Time // zero for the whole history
+}
+
+// Store saves every run in an embedded database file. The file is opened for
+// the duration of each call only, so that a daemon writing runs does not lock
+// out the queries of other processes.
+type Store struct {
+	filename  string
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewStore creates a store saving runs to filename, created on the first save
+func NewStore(filename string) *Store {
+	return &Store{
+		filename: filename,
+		now:      time.Now,
+	}
+}
+
+// SetRetention sets the age beyond which runs are deleted on save, 0 keeping every run
+func (s *Store) SetRetention(retention time.Duration) {
+	s.retention = retention
+}
+
+// GetFilename returns the database file of the store
+func (s *Store) GetFilename() string {
+	return s.filename
+}
+
+// Save records run with the configuration it ran with, then deletes the runs
+// beyond the retention
+func (s *Store) Save(run Run, config *types.RecommendationConfig) (uint64, error) {
+	var id uint64
+	err := s.update(func(tx *bolt.Tx) error {
+		runs, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return err
+		}
+		points, err := tx.CreateBucketIfNotExists(pointsBucket)
+		if err != nil {
+			return err
+		}
+
+		if id, err = runs.NextSequence(); err != nil {
+			return err
+		}
+		record := RunRecord{
+			ID:              id,
+			Namespace:       run.Namespace,
+			StartedAt:       run.StartedAt.UTC(),
+			FinishedAt:      run.FinishedAt.UTC(),
+			Config:          config,
+			Recommendations: len(run.Recommendations),
+		}
+		if err := putJSON(runs, runKey(record.StartedAt, id), record); err != nil {
+			return err
+		}
+		for _, rec := range run.Recommendations {
+			rec.Daily = nil // the usage behind a recommendation is left out of the history
+			point := Point{RunID: id, StartedAt: record.StartedAt, Recommendation: rec}
+			if err := putJSON(points, pointKey(KeyOf(rec), record.StartedAt, id), point); err != nil {
+				return err
+			}
+		}
+
+		if s.retention > 0 {
+			_, err = prune(tx, s.now().Add(-s.retention))
+		}
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to save run in %s: %w", s.filename, err)
+	}
+	return id, nil
+}
+
+// Runs returns the saved runs of namespace, every namespace when empty, oldest first
+func (s *Store) Runs(namespace string) ([]RunRecord, error) {
+	var records []RunRecord
+	err := s.view(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(runsBucket)
+		if runs == nil {
+			return nil
+		}
+		return runs.ForEach(func(_, value []byte) error {
+			var record RunRecord
+			if err := json.Unmarshal(value, &record); err != nil {
+				return fmt.Errorf("corrupt run: %w", err)
+			}
+			if namespace == "" || record.Namespace == namespace {
+				records = append(records, record)
+			}
+			return nil
+		})
+	})
+	return records, err
+}
+
+// Series returns the recommendations of the containers selected by query,
+// sorted by container then oldest first
+func (s *Store) Series(query SeriesQuery) ([]Point, error) {
+	var series []Point
+	err := s.view(func(tx *bolt.Tx) error {
+		points := tx.Bucket(pointsBucket)
+		if points == nil {
+			return nil
+		}
+		prefix := seriesPrefix(query)
+		cursor := points.Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			var point Point
+			if err := json.Unmarshal(value, &point); err != nil {
+				return fmt.Errorf("corrupt recommendation: %w", err)
+			}
+			rec := point.Recommendation
+			if (query.Deployment != "" && rec.Deployment != query.Deployment) ||
+				(query.Container != "" && rec.Container != query.Container) ||
+				(query.Cluster != "" && rec.Cluster != query.Cluster) ||
+				point.StartedAt.Before(query.Since) {
+				continue
+			}
+			series = append(series, point)
+		}
+		return nil
+	})
+	sort.SliceStable(series, func(i, j int) bool {
+		a, b := KeyOf(series[i].Recommendation), KeyOf(series[j].Recommendation)
+		if a != b {
+			return a.less(b)
+		}
+		return series[i].StartedAt.Before(series[j].StartedAt)
+	})
+	return series, err
+}
+
+// Prune deletes the runs started before before and returns how many were deleted
+func (s *Store) Prune(before time.Time) (int, error) {
+	var deleted int
+	err := s.update(func(tx *bolt.Tx) error {
+		var err error
+		deleted, err = prune(tx, before)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune %s: %w", s.filename, err)
+	}
+	return deleted, nil
+}
+
+// prune deletes the runs started before before, with their recommendations
+func prune(tx *bolt.Tx, before time.Time) (int, error) {
+	runs, points := tx.Bucket(runsBucket), tx.Bucket(pointsBucket)
+	if runs == nil || points == nil {
+		return 0, nil
+	}
+	limit := timeKey(before)
+
+	// Keys are collected first, deleting while iterating skips entries
+	var runKeys, pointKeys [][]byte
+	cursor := runs.Cursor()
+	for key, _ := cursor.First(); key != nil && bytes.Compare(key[:8], limit) < 0; key, _ = cursor.Next() {
+		runKeys = append(runKeys, append([]byte(nil), key...))
+	}
+	err := points.ForEach(func(key, _ []byte) error {
+		if started := key[len(key)-16 : len(key)-8]; bytes.Compare(started, limit) < 0 {
+			pointKeys = append(pointKeys, append([]byte(nil), key...))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range pointKeys {
+		if err := points.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	for _, key := range runKeys {
+		if err := runs.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return len(runKeys), nil
+}
+
+// update runs fn in a read-write transaction of the store
+func (s *Store) update(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.filename, 0o644, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return openError(err)
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+// view runs fn in a read-only transaction of the store, an error being
+// returned when the store does not exist
+func (s *Store) view(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(s.filename, 0o644, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to open history %s: %w", s.filename, openError(err))
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// openError explains the timeout of a store locked by another process
+func openError(err error) error {
+	if errors.Is(err, bolt.ErrTimeout) {
+		return fmt.Errorf("locked by another process: %w", err)
+	}
+	return err
+}
+
+// putJSON stores value encoded as JSON under key
+func putJSON(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, content)
+}
+
+// timeKey encodes t so that keys sort chronologically
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// runKey returns the key of a run: its start time then its ID
+func runKey(started time.Time, id uint64) []byte {
+	return binary.BigEndian.AppendUint64(timeKey(started), id)
+}
+
+// pointKey returns the key of a recommendation: the container, then the
+// start time and ID of its run
+func pointKey(key ContainerKey, started time.Time, id uint64) []byte {
+	prefix := []byte(key.Namespace + "\x00" + key.Deployment + "\x00" + key.Container + "\x00" + key.Cluster + "\x00")
+	return binary.BigEndian.AppendUint64(append(prefix, timeKey(started)...), id)
+}
+
+// seriesPrefix returns the longest key prefix shared by the points selected by query
+func seriesPrefix(query SeriesQuery) []byte {
+	if query.Namespace == "" {
+		return nil
+	}
+	prefix := query.Namespace + "\x00"
+	if query.Deployment != "" {
+		prefix += query.Deployment + "\x00"
+		if query.Container != "" {
+			prefix += query.Container + "\x00"
+		}
+	}
+	return []byte(prefix)
+}
@@ -0,0 +1,100 @@
+package history
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestStore_SaveAndSeries(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "history.db"))
+	config := &types.RecommendationConfig{Namespace: "checkout", CountDays: 7, Percentile: 90}
+
+	for day := 1; day <= 3; day++ {
+		started := time.Date(2024, 3, day, 6, 0, 0, 0, time.UTC)
+		run := Run{
+			Namespace:  "checkout",
+			StartedAt:  started,
+			FinishedAt: started.Add(time.Minute),
+			Recommendations: []types.RecommendationResult{
+				{Namespace: "checkout", Deployment: "api", Container: "app", CurrentRequestMB: 512, RecommendedRequestMB: int64(200 + day*10),
+					Daily: []types.DailyUsage{{Day: 0}}},
+				{Namespace: "checkout", Deployment: "api", Container: "envoy", RecommendedRequestMB: 64},
+				{Namespace: "checkout", Deployment: "api-gateway", Container: "app", RecommendedRequestMB: 128},
+			},
+		}
+		if id, err := store.Save(run, config); err != nil || id != uint64(day) {
+			t.Fatalf("Expected run %d to be saved, got %d, %v", day, id, err)
+		}
+	}
+
+	series, err := store.Series(SeriesQuery{Namespace: "checkout", Deployment: "api", Container: "app"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(series) != 3 {
+		t.Fatalf("Expected 3 points, got %d", len(series))
+	}
+	for i, point := range series {
+		if got := point.Recommendation.RecommendedRequestMB; got != int64(210+i*10) {
+			t.Errorf("Expected point %d at %d MB, got %d", i, 210+i*10, got)
+		}
+		if point.RunID != uint64(i+1) || point.Recommendation.Daily != nil {
+			t.Errorf("Unexpected point %d: %+v", i, point)
+		}
+	}
+
+	// A deployment selects its containers only, not those of api-gateway
+	series, _ = store.Series(SeriesQuery{Namespace: "checkout", Deployment: "api", Since: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)})
+	if len(series) != 4 || series[0].Recommendation.Container != "app" || series[3].Recommendation.Container != "envoy" {
+		t.Errorf("Expected 2 points of app then envoy, got %+v", series)
+	}
+
+	runs, err := store.Runs("checkout")
+	if err != nil || len(runs) != 3 {
+		t.Fatalf("Expected 3 runs, got %d, %v", len(runs), err)
+	}
+	if runs[0].Config == nil || runs[0].Config.CountDays != 7 || runs[0].Recommendations != 3 {
+		t.Errorf("Expected the run configuration to be saved, got %+v", runs[0])
+	}
+}
+
+func TestStore_Retention(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "history.db"))
+	store.now = func() time.Time { return time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC) }
+
+	for _, day := range []int{1, 5, 9} {
+		started := time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+		store.Save(Run{Namespace: "shop", StartedAt: started, Recommendations: []types.RecommendationResult{
+			{Namespace: "shop", Deployment: "web", Container: "app"},
+		}}, nil)
+	}
+
+	deleted, err := store.Prune(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil || deleted != 1 {
+		t.Fatalf("Expected 1 run pruned, got %d, %v", deleted, err)
+	}
+
+	// Saving applies the retention
+	store.SetRetention(72 * time.Hour)
+	store.Save(Run{Namespace: "shop", StartedAt: store.now()}, nil)
+
+	runs, _ := store.Runs("")
+	if len(runs) != 2 || runs[0].StartedAt.Day() != 9 {
+		t.Errorf("Expected the runs of the 9th and 10th, got %+v", runs)
+	}
+	series, _ := store.Series(SeriesQuery{Namespace: "shop"})
+	if len(series) != 1 || series[0].StartedAt.Day() != 9 {
+		t.Errorf("Expected the recommendation of the 9th only, got %+v", series)
+	}
+}
+
+func TestStore_Missing(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "missing.db"))
+	if _, err := store.Series(SeriesQuery{Namespace: "shop"}); err == nil || !strings.Contains(err.Error(), "failed to open history") {
+		t.Errorf("Expected an error for a missing store, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff_Equal(t *testing.T) {
+	if diff := UnifiedDiff("a.yaml", []byte("a\nb\n"), []byte("a\nb\n")); diff != "" {
+		t.Errorf("Expected no diff, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_SingleHunk(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n"
+	after := "1\n2\n3\n4\nfive\n6\n7\n8\n"
+
+	expected := `--- a/deploy.yaml
++++ b/deploy.yaml
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`
+	if diff := UnifiedDiff("deploy.yaml", []byte(before), []byte(after)); diff != expected {
+		t.Errorf("Unexpected diff:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_SeparateHunks(t *testing.T) {
+	var before, after []string
+	for i := 1; i <= 20; i++ {
+		before = append(before, fmt.Sprint(i))
+		after = append(after, fmt.Sprint(i))
+	}
+	after[1] = "two"
+	after[17] = "eighteen"
+
+	diff := UnifiedDiff("f", []byte(strings.Join(before, "\n")+"\n"), []byte(strings.Join(after, "\n")+"\n"))
+	if strings.Count(diff, "@@ -") != 2 {
+		t.Fatalf("Expected 2 hunks, got:\n%s", diff)
+	}
+	if !strings.Contains(diff, "@@ -1,5 +1,5 @@\n 1\n-2\n+two\n") {
+		t.Errorf("Unexpected first hunk:\n%s", diff)
+	}
+	if !strings.Contains(diff, "@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n") {
+		t.Errorf("Unexpected second hunk:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_MergesCloseChanges(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\n"
+	after := "A\nb\nc\nd\ne\nf\nG\n"
+
+	diff := UnifiedDiff("f", []byte(before), []byte(after))
+	if strings.Count(diff, "@@ -") != 1 || !strings.Contains(diff, "@@ -1,7 +1,7 @@") {
+		t.Errorf("Expected a single hunk, got:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiff_AddedLines(t *testing.T) {
+	diff := UnifiedDiff("f", nil, []byte("a\nb\n"))
+	if !strings.Contains(diff, "@@ -0,0 +1,2 @@\n+a\n+b\n") {
+		t.Errorf("Unexpected diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,372 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Change is a resource quantity rewritten in a manifest
+type Change struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Container string
+	Field     string // requests.memory or limits.memory
+	Line      int
+	Old       string
+	New       string
+}
+
+// FileResult holds the rewrite of a single manifest file
+type FileResult struct {
+	Path     string // relative to the walked directory
+	Original []byte
+	Updated  []byte
+	Changes  []Change
+}
+
+// Diff returns the unified diff of the rewrite
+func (f *FileResult) Diff() string {
+	return UnifiedDiff(f.Path, f.Original, f.Updated)
+}
+
+// SkippedFile is a YAML file that could not be parsed, such as a Helm
+// template, or a values file whose key path is ambiguous
+type SkippedFile struct {
+	Path   string
+	Reason string
+}
+
+// Result summarises the rewrite of a directory of manifests
+type Result struct {
+	Files   []*FileResult // files with at least one change
+	Skipped []SkippedFile
+
+	// Unmatched lists the recommendations with no matching workload container
+	Unmatched []types.RecommendationResult
+
+	// matched records the recommendations found in a manifest, keyed by
+	// namespace/deployment/container, even when no quantity had to change
+	matched map[string]bool
+}
+
+// workloadKind is the kind whose pod template containers are rewritten, the
+// only kind recommendations are computed for
+const workloadKind = "Deployment"
+
+// Rewrite walks dir and rewrites the memory requests and limits of every
+// Deployment container matching a recommendation, and the quantities found at
+// the helmKeyPath of each recommendation in Helm values files (values.yaml,
+// values-<environment>.yaml); a nil helmKeyPath leaves the values files out.
+// Only the quantities change: comments, ordering and formatting are kept byte
+// for byte. Files are written back only when write is set. The manifests of a
+// directory belong to one cluster, so recommendations of several clusters are
+// refused.
+func Rewrite(dir string, recommendations []types.RecommendationResult, helmKeyPath exporter.HelmKeyPath, write bool) (*Result, error) {
+	if clusters := Clusters(recommendations); len(clusters) > 1 {
+		return nil, fmt.Errorf("recommendations cover several clusters (%s), apply those of one cluster at a time",
+			strings.Join(clusters, ", "))
+	}
+	index := newRecommendationIndex(recommendations)
+	result := &Result{matched: make(map[string]bool)}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+
+		var updated []byte
+		var changes []Change
+		if helmKeyPath != nil && isValuesFile(path) {
+			updated, changes, err = rewriteValues(content, helmKeyPath, recommendations, result.matched)
+		} else {
+			updated, changes, err = rewriteContent(content, index, result.matched)
+		}
+		if err != nil {
+			result.Skipped = append(result.Skipped, SkippedFile{Path: rel, Reason: err.Error()})
+			return nil
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		if write {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, updated, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("failed to write %s: %w", rel, err)
+			}
+		}
+		result.Files = append(result.Files, &FileResult{Path: rel, Original: content, Updated: updated, Changes: changes})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range recommendations {
+		if !result.matched[recommendationKey(rec.Namespace, rec.Deployment, rec.Container)] {
+			result.Unmatched = append(result.Unmatched, rec)
+		}
+	}
+	return result, nil
+}
+
+// Clusters returns the distinct clusters of recommendations, sorted
+func Clusters(recommendations []types.RecommendationResult) []string {
+	seen := make(map[string]bool)
+	var clusters []string
+	for _, rec := range recommendations {
+		if !seen[rec.Cluster] {
+			seen[rec.Cluster] = true
+			clusters = append(clusters, rec.Cluster)
+		}
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+// recommendationIndex looks recommendations up by workload and container name
+type recommendationIndex struct {
+	byName map[string][]types.RecommendationResult // keyed by deployment/container
+}
+
+// newRecommendationIndex indexes recommendations by workload and container name
+func newRecommendationIndex(recommendations []types.RecommendationResult) *recommendationIndex {
+	index := &recommendationIndex{byName: make(map[string][]types.RecommendationResult)}
+	for _, rec := range recommendations {
+		key := rec.Deployment + "/" + rec.Container
+		index.byName[key] = append(index.byName[key], rec)
+	}
+	return index
+}
+
+// lookup returns the recommendation of a container. A manifest without a
+// namespace, as in Kustomize bases, matches a workload of any namespace
+// as long as the name is unambiguous.
+func (i *recommendationIndex) lookup(namespace, name, container string) (types.RecommendationResult, bool) {
+	candidates := i.byName[name+"/"+container]
+	if namespace == "" {
+		if len(candidates) == 1 {
+			return candidates[0], true
+		}
+		return types.RecommendationResult{}, false
+	}
+	for _, rec := range candidates {
+		if rec.Namespace == namespace {
+			return rec, true
+		}
+	}
+	return types.RecommendationResult{}, false
+}
+
+// recommendationKey identifies a recommended container
+func recommendationKey(namespace, deployment, container string) string {
+	return namespace + "/" + deployment + "/" + container
+}
+
+// edit replaces the bytes [start, end) of a file
+type edit struct {
+	start, end  int
+	replacement string
+}
+
+// rewriteContent rewrites the memory quantities of every matching container
+// in a (multi-document) YAML file. Matched recommendations are recorded in
+// matched when it is not nil.
+func rewriteContent(content []byte, index *recommendationIndex, matched map[string]bool) ([]byte, []Change, error) {
+	lines := lineOffsets(content)
+
+	var edits []edit
+	var changes []Change
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, err
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+
+		root := doc.Content[0]
+		kind := scalar(lookupPath(root, "kind"))
+		if kind != workloadKind {
+			continue
+		}
+		name := scalar(lookupPath(root, "metadata", "name"))
+		namespace := scalar(lookupPath(root, "metadata", "namespace"))
+
+		containers := lookupPath(root, "spec", "template", "spec", "containers")
+		if containers == nil || containers.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, container := range containers.Content {
+			containerName := scalar(lookupPath(container, "name"))
+			rec, ok := index.lookup(namespace, name, containerName)
+			if !ok {
+				continue
+			}
+			if matched != nil {
+				matched[recommendationKey(rec.Namespace, rec.Deployment, rec.Container)] = true
+			}
+
+			fields := []struct {
+				field string
+				path  []string
+				mb    int64
+			}{
+				{"requests.memory", []string{"resources", "requests", "memory"}, rec.RecommendedRequestMB},
+				{"limits.memory", []string{"resources", "limits", "memory"}, rec.RecommendedLimitMB},
+			}
+			for _, f := range fields {
+				node := lookupPath(container, f.path...)
+				if node == nil || node.Kind != yaml.ScalarNode {
+					continue
+				}
+				replacement := exporter.MemoryQuantity(f.mb)
+				if current, err := parseQuantity(node.Value); err == nil && current == float64(f.mb)*(1<<20) {
+					continue
+				}
+
+				e, err := scalarEdit(content, lines, node, replacement)
+				if err != nil {
+					return nil, nil, err
+				}
+				edits = append(edits, e)
+				changes = append(changes, Change{
+					Kind:      kind,
+					Namespace: rec.Namespace,
+					Name:      name,
+					Container: containerName,
+					Field:     f.field,
+					Line:      node.Line,
+					Old:       node.Value,
+					New:       replacement,
+				})
+			}
+		}
+	}
+
+	if len(edits) == 0 {
+		return content, nil, nil
+	}
+
+	return applyEdits(content, edits), changes, nil
+}
+
+// applyEdits returns content with every edit applied
+func applyEdits(content []byte, edits []edit) []byte {
+	// Apply from the end so earlier offsets stay valid
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	updated := append([]byte(nil), content...)
+	for _, e := range edits {
+		updated = append(updated[:e.start], append([]byte(e.replacement), updated[e.end:]...)...)
+	}
+	return updated
+}
+
+// scalarEdit returns the edit replacing the value of a scalar node, keeping its quoting style
+func scalarEdit(content []byte, lines []int, node *yaml.Node, value string) (edit, error) {
+	if node.Line < 1 || node.Line > len(lines) {
+		return edit{}, fmt.Errorf("line %d out of range", node.Line)
+	}
+	lineStart := lines[node.Line-1]
+
+	// Columns count characters, convert to a byte offset within the line
+	start := lineStart
+	for column := 1; column < node.Column && start < len(content); column++ {
+		_, size := utf8.DecodeRune(content[start:])
+		start += size
+	}
+
+	raw := node.Value
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		raw, value = `"`+raw+`"`, `"`+value+`"`
+	case yaml.SingleQuotedStyle:
+		raw, value = "'"+raw+"'", "'"+value+"'"
+	case 0, yaml.TaggedStyle:
+	default:
+		return edit{}, fmt.Errorf("line %d: unsupported scalar style for %q", node.Line, node.Value)
+	}
+
+	end := start + len(raw)
+	if end > len(content) || string(content[start:end]) != raw {
+		return edit{}, fmt.Errorf("line %d: could not locate %q", node.Line, node.Value)
+	}
+	return edit{start: start, end: end, replacement: value}, nil
+}
+
+// lineOffsets returns the byte offset of the start of every line
+func lineOffsets(content []byte) []int {
+	offsets := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// lookupPath follows mapping keys from node, returning nil if any is missing
+func lookupPath(node *yaml.Node, path ...string) *yaml.Node {
+	for _, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+// scalar returns the value of a scalar node, empty for any other node
+func scalar(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
@@ -0,0 +1,211 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+const deploymentManifest = `# Web frontend
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-app
+  namespace: production
+spec:
+  template:
+    spec:
+      containers:
+        - name: nginx
+          image: nginx:1.25
+          resources:
+            requests:
+              memory: 512Mi   # tuned by hand
+              cpu: 100m
+            limits:
+              memory: "1Gi"
+        - name: envoy
+          resources:
+            requests: {memory: 64Mi}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web-app
+`
+
+const deploymentPatch = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cache
+spec:
+  template:
+    spec:
+      containers:
+      - name: redis
+        resources:
+          limits:
+            memory: '2Gi'
+`
+
+// testRecommendations returns the recommendations matching the test manifests
+func testRecommendations() []types.RecommendationResult {
+	return []types.RecommendationResult{
+		{Namespace: "production", Deployment: "web-app", Container: "nginx", RecommendedRequestMB: 256, RecommendedLimitMB: 384},
+		{Namespace: "production", Deployment: "web-app", Container: "envoy", RecommendedRequestMB: 64, RecommendedLimitMB: 96},
+		{Namespace: "staging", Deployment: "cache", Container: "redis", RecommendedRequestMB: 1024, RecommendedLimitMB: 1536},
+		{Namespace: "production", Deployment: "api", Container: "app", RecommendedRequestMB: 128, RecommendedLimitMB: 192},
+	}
+}
+
+func TestRewriteContent_PreservesFormatting(t *testing.T) {
+	index := newRecommendationIndex(testRecommendations())
+
+	updated, changes, err := rewriteContent([]byte(deploymentManifest), index, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := strings.NewReplacer(
+		"memory: 512Mi   # tuned by hand", "memory: 256Mi   # tuned by hand",
+		`memory: "1Gi"`, `memory: "384Mi"`,
+	).Replace(deploymentManifest)
+	if string(updated) != expected {
+		t.Errorf("Unexpected rewrite:\n%s", updated)
+	}
+
+	// The envoy request already matches, and it has no limit to rewrite
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %d: %+v", len(changes), changes)
+	}
+	if changes[0].Field != "requests.memory" || changes[0].Old != "512Mi" || changes[0].New != "256Mi" || changes[0].Line != 15 {
+		t.Errorf("Unexpected request change: %+v", changes[0])
+	}
+	if changes[1].Field != "limits.memory" || changes[1].Old != "1Gi" || changes[1].New != "384Mi" {
+		t.Errorf("Unexpected limit change: %+v", changes[1])
+	}
+}
+
+func TestRewriteContent_NamespacelessPatch(t *testing.T) {
+	index := newRecommendationIndex(testRecommendations())
+
+	updated, changes, err := rewriteContent([]byte(deploymentPatch), index, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Kind != "Deployment" || changes[0].Namespace != "staging" {
+		t.Fatalf("Unexpected changes: %+v", changes)
+	}
+	if !strings.Contains(string(updated), "memory: '1536Mi'\n") {
+		t.Errorf("Expected the single-quoted limit to be rewritten, got:\n%s", updated)
+	}
+}
+
+func TestRewriteContent_SkipsOtherKinds(t *testing.T) {
+	index := newRecommendationIndex(testRecommendations())
+
+	// A StatefulSet sharing the name of a recommended Deployment keeps its quantities
+	statefulSet := strings.Replace(deploymentManifest, "kind: Deployment", "kind: StatefulSet", 1)
+	updated, changes, err := rewriteContent([]byte(statefulSet), index, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(changes) != 0 || string(updated) != statefulSet {
+		t.Errorf("Expected the StatefulSet to be left unchanged, got %+v", changes)
+	}
+}
+
+func TestRewrite_SeveralClusters(t *testing.T) {
+	recs := []types.RecommendationResult{
+		{Cluster: "prod-eu", Namespace: "production", Deployment: "web-app", Container: "nginx"},
+		{Cluster: "prod-us", Namespace: "production", Deployment: "web-app", Container: "nginx"},
+	}
+	if _, err := Rewrite(t.TempDir(), recs, nil, false); err == nil || !strings.Contains(err.Error(), "prod-eu, prod-us") {
+		t.Errorf("Expected an error naming both clusters, got %v", err)
+	}
+}
+
+func TestRecommendationIndex_Lookup(t *testing.T) {
+	recs := testRecommendations()
+	recs = append(recs, types.RecommendationResult{Namespace: "staging", Deployment: "web-app", Container: "nginx"})
+	index := newRecommendationIndex(recs)
+
+	if rec, ok := index.lookup("staging", "web-app", "nginx"); !ok || rec.Namespace != "staging" {
+		t.Errorf("Expected the staging recommendation, got %+v", rec)
+	}
+	if _, ok := index.lookup("", "web-app", "nginx"); ok {
+		t.Error("Expected no match for an ambiguous name without namespace")
+	}
+	if _, ok := index.lookup("", "cache", "redis"); !ok {
+		t.Error("Expected an unambiguous name to match without namespace")
+	}
+	if _, ok := index.lookup("other", "cache", "redis"); ok {
+		t.Error("Expected no match in another namespace")
+	}
+}
+
+func TestRewrite_Directory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base/deployment.yaml":            deploymentManifest,
+		"overlays/staging/cache.yml":      deploymentPatch,
+		"chart/templates/deployment.yaml": "metadata:\n  name: {{ .Release.Name }\n",
+		"README.md":                       "memory: 512Mi\n",
+		".git/config.yaml":                deploymentManifest,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// A dry run reports the changes without writing
+	result, err := Rewrite(dir, testRecommendations(), nil, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("Expected 2 changed files, got %d", len(result.Files))
+	}
+	if result.Files[0].Path != filepath.Join("base", "deployment.yaml") {
+		t.Errorf("Unexpected first file: %s", result.Files[0].Path)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "base/deployment.yaml"))
+	if string(content) != deploymentManifest {
+		t.Error("Expected a dry run to leave the file unchanged")
+	}
+
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != filepath.Join("chart", "templates", "deployment.yaml") {
+		t.Errorf("Expected the Helm template to be skipped, got %+v", result.Skipped)
+	}
+	if len(result.Unmatched) != 1 || result.Unmatched[0].Deployment != "api" {
+		t.Errorf("Expected only the api recommendation unmatched, got %+v", result.Unmatched)
+	}
+
+	// Writing updates the files in place
+	if _, err := Rewrite(dir, testRecommendations(), nil, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(dir, "base/deployment.yaml"))
+	if !strings.Contains(string(content), "memory: 256Mi   # tuned by hand") {
+		t.Errorf("Expected the file to be rewritten, got:\n%s", content)
+	}
+	content, _ = os.ReadFile(filepath.Join(dir, ".git/config.yaml"))
+	if string(content) != deploymentManifest {
+		t.Error("Expected hidden directories to be ignored")
+	}
+
+	// A second run has nothing left to change
+	result, err = Rewrite(dir, testRecommendations(), nil, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Files) != 0 {
+		t.Errorf("Expected no changes on a second run, got %d files", len(result.Files))
+	}
+}
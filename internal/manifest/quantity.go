@@ -0,0 +1,66 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// quantitySuffixes maps Kubernetes quantity suffixes to their multiplier in bytes
+var quantitySuffixes = map[string]float64{
+	"":   1,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// parseQuantity converts a memory quantity such as 512Mi, 1G, 129e6 or
+// 1048576 to bytes. An e or E followed by digits is a decimal exponent,
+// otherwise E is the exa suffix.
+func parseQuantity(quantity string) (float64, error) {
+	quantity = strings.TrimSpace(quantity)
+
+	end := 0
+	if end < len(quantity) && (quantity[end] == '+' || quantity[end] == '-') {
+		end++
+	}
+	for end < len(quantity) && (isDigit(quantity[end]) || quantity[end] == '.') {
+		end++
+	}
+	if end < len(quantity) && (quantity[end] == 'e' || quantity[end] == 'E') {
+		exponent := end + 1
+		if exponent < len(quantity) && (quantity[exponent] == '+' || quantity[exponent] == '-') {
+			exponent++
+		}
+		if exponent < len(quantity) && isDigit(quantity[exponent]) {
+			for end = exponent; end < len(quantity) && isDigit(quantity[end]); end++ {
+			}
+		}
+	}
+
+	number, suffix := quantity[:end], quantity[end:]
+	multiplier, ok := quantitySuffixes[suffix]
+	if !ok {
+		return 0, fmt.Errorf("unsupported memory quantity %q", quantity)
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory quantity %q: %w", quantity, err)
+	}
+	return value * multiplier, nil
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,40 @@
+package manifest
+
+import "testing"
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		quantity string
+		expected float64
+		wantErr  bool
+	}{
+		{"512Mi", 512 * 1024 * 1024, false},
+		{"1Gi", 1024 * 1024 * 1024, false},
+		{"1.5Gi", 1.5 * 1024 * 1024 * 1024, false},
+		{"128974848", 128974848, false},
+		{"129e6", 129e6, false},
+		{"129M", 129e6, false},
+		{"64Ki", 64 * 1024, false},
+		{"1Pi", 1 << 50, false},
+		{"2P", 2e15, false},
+		{"1E", 1e18, false},
+		{"1Ei", 1 << 60, false},
+		{"1E3", 1e3, false},
+		{"1e+3Ki", 1e3 * 1024, false},
+		{"1Zi", 0, true},
+		{"lots", 0, true},
+	}
+
+	for _, tt := range tests {
+		value, err := parseQuantity(tt.quantity)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for %q", tt.quantity)
+			}
+			continue
+		}
+		if err != nil || value != tt.expected {
+			t.Errorf("Expected %v for %q, got %v (%v)", tt.expected, tt.quantity, value, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// valuesKind is the Kind of the changes made to Helm values files
+const valuesKind = "HelmValues"
+
+// isValuesFile reports whether path is a Helm values file, named values.yaml
+// or values-<environment>.yaml as Helm charts and their overlays name them
+func isValuesFile(path string) bool {
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".yaml"), ".yml")
+	return name == "values" || strings.HasPrefix(name, "values-") || strings.HasPrefix(name, "values.")
+}
+
+// rewriteValues rewrites the memory quantities of a Helm values file found at
+// the key path of each recommendation. Only existing keys are rewritten:
+// values files set the quantities of some containers and leave the others to
+// the chart defaults. Matched recommendations are recorded in matched when it
+// is not nil.
+func rewriteValues(content []byte, keyPath exporter.HelmKeyPath, recommendations []types.RecommendationResult, matched map[string]bool) ([]byte, []Change, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return content, nil, nil
+		}
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return content, nil, nil
+	}
+	root := doc.Content[0]
+	lines := lineOffsets(content)
+
+	// owners records the container setting each key, to refuse a key path
+	// resolving several containers to the same value
+	owners := make(map[*yaml.Node]string)
+	var edits []edit
+	var changes []Change
+	for _, rec := range recommendations {
+		owner := recommendationKey(rec.Namespace, rec.Deployment, rec.Container)
+		quantities := []struct {
+			kind string
+			mb   int64
+		}{
+			{"requests", rec.RecommendedRequestMB},
+			{"limits", rec.RecommendedLimitMB},
+		}
+		for _, q := range quantities {
+			keys := keyPath.Keys(rec, q.kind)
+			node := lookupPath(root, keys...)
+			if node == nil || node.Kind != yaml.ScalarNode {
+				continue
+			}
+			if previous, ok := owners[node]; ok && previous != owner {
+				return nil, nil, fmt.Errorf("helm key %s matches both %s and %s, add {deployment} or {container} to the key path",
+					strings.Join(keys, "."), previous, owner)
+			}
+			owners[node] = owner
+
+			replacement := exporter.MemoryQuantity(q.mb)
+			if current, err := parseQuantity(node.Value); err == nil && current == float64(q.mb)*(1<<20) {
+				continue
+			}
+			e, err := scalarEdit(content, lines, node, replacement)
+			if err != nil {
+				return nil, nil, err
+			}
+			edits = append(edits, e)
+			changes = append(changes, Change{
+				Kind:      valuesKind,
+				Namespace: rec.Namespace,
+				Name:      rec.Deployment,
+				Container: rec.Container,
+				Field:     q.kind + ".memory",
+				Line:      node.Line,
+				Old:       node.Value,
+				New:       replacement,
+			})
+		}
+	}
+	if matched != nil {
+		for _, owner := range owners {
+			matched[owner] = true
+		}
+	}
+	if len(edits) == 0 {
+		return content, nil, nil
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Line < changes[j].Line })
+	return applyEdits(content, edits), changes, nil
+}
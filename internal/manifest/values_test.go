@@ -0,0 +1,122 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+const valuesFile = `# Production values
+replicaCount: 3
+resources:
+  nginx:
+    requests:
+      memory: 512Mi # tuned by hand
+      cpu: 100m
+    limits:
+      memory: "1Gi"
+  envoy:
+    requests:
+      cpu: 50m
+`
+
+// parseKeyPath parses a key path template, failing the test on error
+func parseKeyPath(t *testing.T, template string) exporter.HelmKeyPath {
+	t.Helper()
+	keyPath, err := exporter.ParseHelmKeyPath(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyPath
+}
+
+func TestIsValuesFile(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"chart/values.yaml":         true,
+		"envs/prod/values-prod.yml": true,
+		"values.production.yaml":    true,
+		"base/deployment.yaml":      false,
+		"chart/Chart.yaml":          false,
+	} {
+		if got := isValuesFile(path); got != expected {
+			t.Errorf("%s: expected %v, got %v", path, expected, got)
+		}
+	}
+}
+
+func TestRewriteValues(t *testing.T) {
+	matched := make(map[string]bool)
+	updated, changes, err := rewriteValues([]byte(valuesFile), parseKeyPath(t, exporter.DefaultHelmKeyPath), testRecommendations(), matched)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := strings.NewReplacer(
+		"memory: 512Mi # tuned by hand", "memory: 256Mi # tuned by hand",
+		`memory: "1Gi"`, `memory: "384Mi"`,
+	).Replace(valuesFile)
+	if string(updated) != expected {
+		t.Errorf("Unexpected rewrite:\n%s", updated)
+	}
+
+	// envoy sets no memory in the values file, which is left to the chart
+	if len(changes) != 2 || changes[0].Kind != valuesKind || changes[0].Field != "requests.memory" || changes[0].Line != 6 ||
+		changes[1].Field != "limits.memory" || changes[1].New != "384Mi" {
+		t.Errorf("Unexpected changes: %+v", changes)
+	}
+	if !matched["production/web-app/nginx"] || matched["production/web-app/envoy"] {
+		t.Errorf("Expected only nginx to be matched, got %v", matched)
+	}
+}
+
+func TestRewriteValues_AmbiguousKeyPath(t *testing.T) {
+	content := "resources:\n  requests:\n    memory: 512Mi\n"
+	recs := []types.RecommendationResult{
+		{Namespace: "production", Deployment: "web-app", Container: "nginx", RecommendedRequestMB: 256},
+		{Namespace: "production", Deployment: "api", Container: "app", RecommendedRequestMB: 128},
+	}
+
+	if _, _, err := rewriteValues([]byte(content), parseKeyPath(t, "resources.{kind}.memory"), recs, nil); err == nil {
+		t.Error("Expected an error for a key path matching several containers")
+	}
+
+	// A single recommendation resolves the same key path unambiguously
+	updated, changes, err := rewriteValues([]byte(content), parseKeyPath(t, "resources.{kind}.memory"), recs[1:], nil)
+	if err != nil || len(changes) != 1 || string(updated) != "resources:\n  requests:\n    memory: 128Mi\n" {
+		t.Errorf("Unexpected rewrite %q, changes %+v, error %v", updated, changes, err)
+	}
+}
+
+func TestRewrite_ValuesFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "chart", "values.yaml")
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	if err := os.WriteFile(path, []byte(valuesFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a key path the values files are left out
+	result, err := Rewrite(dir, testRecommendations(), nil, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Files) != 0 {
+		t.Errorf("Expected no changes without a key path, got %d files", len(result.Files))
+	}
+
+	result, err = Rewrite(dir, testRecommendations(), parseKeyPath(t, exporter.DefaultHelmKeyPath), true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Files) != 1 || len(result.Files[0].Changes) != 2 {
+		t.Fatalf("Expected the values file to change, got %+v", result.Files)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "memory: 256Mi # tuned by hand") {
+		t.Errorf("Expected the values file to be rewritten, got:\n%s", content)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"kubernetes-resources-recommend/internal/types"
@@ -19,8 +21,28 @@ const (
 
 // Client represents a Prometheus client
 type Client struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL      string
+	httpClient   *http.Client
+	replicaLabel string
+	recorder     func(QueryRecord)
+}
+
+// Kinds of queries
+const (
+	QueryKindInstant = "query"
+	QueryKindRange   = "query_range"
+)
+
+// QueryRecord describes a query issued by the client and its outcome
+type QueryRecord struct {
+	Kind   string `json:"kind"`
+	PromQL string `json:"promql"`
+	Time   int64  `json:"time,omitempty"` // evaluation time of an instant query, 0 for now
+	Start  int64  `json:"start,omitempty"`
+	End    int64  `json:"end,omitempty"`
+	Step   int    `json:"step,omitempty"`
+	Series int    `json:"series"`
+	Error  string `json:"error,omitempty"`
 }
 
 // NewClient creates a new Prometheus client
@@ -40,28 +62,59 @@ func NewClient(baseURL string, timeout time.Duration) *Client {
 	}
 }
 
+// SetReplicaLabel sets the label distinguishing HA Prometheus replicas behind
+// a load balancer. The label is stripped from every result and series that
+// only differed by it are returned once.
+func (c *Client) SetReplicaLabel(label string) {
+	c.replicaLabel = label
+}
+
+// ReplicaLabel returns the configured HA replica label, empty if none
+func (c *Client) ReplicaLabel() string {
+	return c.replicaLabel
+}
+
+// SetRecorder calls record after every query with its outcome, nil to stop
+// recording. record may be called from several goroutines at once.
+func (c *Client) SetRecorder(record func(QueryRecord)) {
+	c.recorder = record
+}
+
 // Query executes a Prometheus query
 func (c *Client) Query(ctx context.Context, promql string) (types.Data, error) {
 	requestURL := c.baseURL + QueryAPI + url.QueryEscape(promql)
-	return c.executeQuery(ctx, requestURL)
+	return c.executeQuery(ctx, requestURL, QueryRecord{Kind: QueryKindInstant, PromQL: promql})
 }
 
 // QueryRange executes a Prometheus range query
 func (c *Client) QueryRange(ctx context.Context, promql string, start, end int64, step int) (types.Data, error) {
 	requestURL := fmt.Sprintf("%s%s%s&start=%d&end=%d&step=%d",
 		c.baseURL, QueryRangeAPI, url.QueryEscape(promql), start, end, step)
-	return c.executeQuery(ctx, requestURL)
+	return c.executeQuery(ctx, requestURL, QueryRecord{Kind: QueryKindRange, PromQL: promql, Start: start, End: end, Step: step})
 }
 
 // QueryAtTime executes a Prometheus query at a specific time
 func (c *Client) QueryAtTime(ctx context.Context, promql string, queryTime int64) (types.Data, error) {
 	requestURL := fmt.Sprintf("%s%s%s&time=%d",
 		c.baseURL, QueryAPI, url.QueryEscape(promql), queryTime)
-	return c.executeQuery(ctx, requestURL)
+	return c.executeQuery(ctx, requestURL, QueryRecord{Kind: QueryKindInstant, PromQL: promql, Time: queryTime})
+}
+
+// executeQuery performs the request of a query, passing its outcome to the recorder if any
+func (c *Client) executeQuery(ctx context.Context, requestURL string, record QueryRecord) (types.Data, error) {
+	data, err := c.execute(ctx, requestURL)
+	if c.recorder != nil {
+		record.Series = len(data.Data.Result)
+		if err != nil {
+			record.Error = err.Error()
+		}
+		c.recorder(record)
+	}
+	return data, err
 }
 
-// executeQuery performs the actual HTTP request to Prometheus
-func (c *Client) executeQuery(ctx context.Context, requestURL string) (types.Data, error) {
+// execute performs the actual HTTP request to Prometheus
+func (c *Client) execute(ctx context.Context, requestURL string) (types.Data, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
 		return types.Data{}, fmt.Errorf("failed to create request: %w", err)
@@ -87,5 +140,47 @@ func (c *Client) executeQuery(ctx context.Context, requestURL string) (types.Dat
 		return types.Data{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	if c.replicaLabel != "" {
+		deduplicateReplicas(&data, c.replicaLabel)
+	}
+
 	return data, nil
 }
+
+// deduplicateReplicas removes the replica label from every series and keeps
+// the first of any series whose remaining labels are identical
+func deduplicateReplicas(data *types.Data, replicaLabel string) {
+	seen := make(map[string]bool, len(data.Data.Result))
+	results := data.Data.Result[:0]
+
+	for _, result := range data.Data.Result {
+		delete(result.Metric, replicaLabel)
+
+		key := seriesKey(result.Metric)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		results = append(results, result)
+	}
+
+	data.Data.Result = results
+}
+
+// seriesKey returns a canonical identity for a label set
+func seriesKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	for _, name := range names {
+		key.WriteString(name)
+		key.WriteByte('=')
+		key.WriteString(labels[name])
+		key.WriteByte(0xff)
+	}
+	return key.String()
+}
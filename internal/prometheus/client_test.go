@@ -232,3 +232,102 @@ func TestClient_ContextTimeout(t *testing.T) {
 		t.Error("Expected timeout error, got nil")
 	}
 }
+
+func TestClient_ReplicaDeduplication(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `{
+			"data": {
+				"result": [
+					{"metric": {"pod": "web-1", "prometheus_replica": "prometheus-0"}, "value": ["1234567890", "1"]},
+					{"metric": {"pod": "web-1", "prometheus_replica": "prometheus-1"}, "value": ["1234567890", "1"]},
+					{"metric": {"pod": "web-2", "prometheus_replica": "prometheus-1"}, "value": ["1234567890", "1"]}
+				]
+			}
+		}`
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 30*time.Second)
+	ctx := context.Background()
+
+	result, err := client.Query(ctx, "kube_pod_owner{}")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Data.Result) != 3 {
+		t.Errorf("Expected 3 results without a replica label, got %d", len(result.Data.Result))
+	}
+
+	client.SetReplicaLabel("prometheus_replica")
+	if client.ReplicaLabel() != "prometheus_replica" {
+		t.Errorf("Expected replica label 'prometheus_replica', got '%s'", client.ReplicaLabel())
+	}
+
+	result, err = client.QueryRange(ctx, "kube_pod_owner{}", 0, 3600, 60)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Data.Result) != 2 {
+		t.Fatalf("Expected 2 deduplicated results, got %d", len(result.Data.Result))
+	}
+	for i, pod := range []string{"web-1", "web-2"} {
+		metric := result.Data.Result[i].Metric
+		if metric["pod"] != pod {
+			t.Errorf("Expected pod '%s' at position %d, got '%s'", pod, i, metric["pod"])
+		}
+		if _, ok := metric["prometheus_replica"]; ok {
+			t.Errorf("Expected replica label to be stripped, got %v", metric)
+		}
+	}
+}
+
+func TestClient_SetRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("query") == "broken" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"result": [{"metric": {"pod": "web-1"}, "value": ["1234567890", "1"]}]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 30*time.Second)
+	var records []QueryRecord
+	client.SetRecorder(func(record QueryRecord) { records = append(records, record) })
+	ctx := context.Background()
+
+	client.QueryRange(ctx, "kube_pod_owner{}", 0, 3600, 60)
+	client.QueryAtTime(ctx, "broken", 3600)
+	client.SetRecorder(nil)
+	client.Query(ctx, "up")
+
+	expected := []QueryRecord{
+		{Kind: QueryKindRange, PromQL: "kube_pod_owner{}", Start: 0, End: 3600, Step: 60, Series: 1},
+		{Kind: QueryKindInstant, PromQL: "broken", Time: 3600, Error: "prometheus returned status 400"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %+v", len(expected), records)
+	}
+	for i := range expected {
+		if records[i] != expected[i] {
+			t.Errorf("Expected record %d to be %+v, got %+v", i, expected[i], records[i])
+		}
+	}
+}
+
+func TestSeriesKey(t *testing.T) {
+	a := seriesKey(map[string]string{"pod": "web-1", "container": "app"})
+	b := seriesKey(map[string]string{"container": "app", "pod": "web-1"})
+	c := seriesKey(map[string]string{"container": "app", "pod": "web-2"})
+
+	if a != b {
+		t.Errorf("Expected label order not to matter, got '%s' and '%s'", a, b)
+	}
+	if a == c {
+		t.Errorf("Expected different label values to produce different keys")
+	}
+}
@@ -0,0 +1,31 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// ListClusters returns the values of clusterLabel found on the deployment
+// series of a namespace in a Prometheus/Thanos shared by several clusters
+func ListClusters(ctx context.Context, client *Client, profile *types.MetricsProfile, clusterLabel, namespace string) ([]string, error) {
+	promql := fmt.Sprintf(`count by (%s) (%s)`, clusterLabel,
+		Selector(profile.DeploymentCreated, Eq(profile.NamespaceLabel, namespace)))
+
+	results, err := client.Query(ctx, promql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+
+	var clusters []string
+	for _, result := range results.Data.Result {
+		if cluster, ok := result.Metric[clusterLabel]; ok && cluster != "" {
+			clusters = append(clusters, cluster)
+		}
+	}
+	sort.Strings(clusters)
+
+	return clusters, nil
+}
@@ -0,0 +1,68 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListClusters(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+
+		response := `{
+			"data": {
+				"result": [
+					{"metric": {"cluster": "prod-eu"}, "value": ["1234567890", "4"]},
+					{"metric": {"cluster": "prod-us"}, "value": ["1234567890", "2"]},
+					{"metric": {}, "value": ["1234567890", "1"]}
+				]
+			}
+		}`
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 30*time.Second)
+	clusters, err := ListClusters(context.Background(), client, DefaultProfile(), "cluster", "production")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(clusters) != 2 || clusters[0] != "prod-eu" || clusters[1] != "prod-us" {
+		t.Errorf("Expected clusters [prod-eu prod-us], got %v", clusters)
+	}
+	if !contains(query, "count by (cluster)") || !contains(query, `namespace="production"`) {
+		t.Errorf("Expected cluster discovery query grouped by cluster, got: %s", query)
+	}
+}
+
+func TestMetricsChecker_ClusterFiltering(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"result":[{"metric":{},"value":["1234567890","1"]}]}}`))
+	}))
+	defer server.Close()
+
+	checker := NewMetricsChecker(NewClient(server.URL, 30*time.Second), "production")
+	checker.SetCluster("cluster", "prod-eu")
+	checker.Diagnose(context.Background(), 1)
+
+	if len(queries) == 0 {
+		t.Fatal("Expected queries to be issued")
+	}
+	for _, query := range queries {
+		if !contains(query, `cluster="prod-eu"`) {
+			t.Errorf("Expected query to contain cluster filter, got: %s", query)
+		}
+	}
+}
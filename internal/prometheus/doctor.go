@@ -0,0 +1,328 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// FailureClass categorizes a problem found while diagnosing Prometheus
+type FailureClass int
+
+const (
+	FailureNone FailureClass = iota
+	FailureUnreachable
+	FailureMissingMetrics
+	FailureLabelSchema
+	FailureRetention
+)
+
+// String returns a human readable name for the failure class
+func (f FailureClass) String() string {
+	switch f {
+	case FailureNone:
+		return "none"
+	case FailureUnreachable:
+		return "prometheus unreachable"
+	case FailureMissingMetrics:
+		return "missing metrics"
+	case FailureLabelSchema:
+		return "unsupported label schema"
+	case FailureRetention:
+		return "insufficient retention"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	KubeStateMetricsNone = "none"
+	KubeStateMetricsV1   = "v1"
+	KubeStateMetricsV2   = "v2"
+	KubeStateMetricsBoth = "v1+v2"
+
+	CadvisorLabelsNone    = "none"
+	CadvisorLabelsCurrent = "container/pod"
+	CadvisorLabelsLegacy  = "container_name/pod_name"
+	CadvisorLabelsBoth    = "container/pod+container_name/pod_name"
+)
+
+// MetricStatus holds the diagnostic result for a single required metric
+type MetricStatus struct {
+	Name        string
+	Query       string
+	SeriesCount int
+	Error       string
+}
+
+// DiagnosticReport is a structured view of the Prometheus data the recommender depends on
+type DiagnosticReport struct {
+	Cluster   string
+	Namespace string
+	Profile   string
+	Reachable bool
+	Error     string
+
+	Metrics          []MetricStatus
+	KubeStateMetrics string
+	CadvisorLabels   string
+
+	// LabelSchemaMatches reports whether the cadvisor labels in use are the
+	// ones the configured profile queries. SuggestedProfile names the built-in
+	// profile matching the detected schema, if any.
+	LabelSchemaMatches bool
+	SuggestedProfile   string
+
+	// OldestSampleAge is the age of the oldest container memory sample found
+	// within the lookback window. LookbackExhausted reports that data exists
+	// at the window edge, so the real age is at least OldestSampleAge.
+	OldestSampleAge   time.Duration
+	LookbackExhausted bool
+	RequiredRetention time.Duration
+}
+
+// MissingMetrics returns the names of required metrics without any series
+func (r *DiagnosticReport) MissingMetrics() []string {
+	var missing []string
+	for _, metric := range r.Metrics {
+		if metric.SeriesCount == 0 {
+			missing = append(missing, metric.Name)
+		}
+	}
+	return missing
+}
+
+// RetentionOK reports whether the available history covers the analysis period
+func (r *DiagnosticReport) RetentionOK() bool {
+	return r.OldestSampleAge >= r.RequiredRetention
+}
+
+// Failures returns every failure class found, most severe first
+func (r *DiagnosticReport) Failures() []FailureClass {
+	if !r.Reachable {
+		return []FailureClass{FailureUnreachable}
+	}
+
+	var failures []FailureClass
+	if len(r.MissingMetrics()) > 0 {
+		failures = append(failures, FailureMissingMetrics)
+	}
+	if !r.LabelSchemaMatches {
+		failures = append(failures, FailureLabelSchema)
+	}
+	if !r.RetentionOK() {
+		failures = append(failures, FailureRetention)
+	}
+	return failures
+}
+
+// Diagnose inspects every required metric, the label schemas in use and
+// the retention available for an analysis spanning countDays
+func (mc *MetricsChecker) Diagnose(ctx context.Context, countDays int) *DiagnosticReport {
+	report := &DiagnosticReport{
+		Cluster:           mc.cluster,
+		Namespace:         mc.namespace,
+		Profile:           mc.profile.Name,
+		Reachable:         true,
+		RequiredRetention: time.Duration(countDays) * 24 * time.Hour,
+	}
+
+	for _, metric := range mc.requiredMetrics() {
+		status := MetricStatus{Name: metric.Name, Query: metric.Selector}
+		count, err := mc.countSeries(ctx, metric.Selector)
+		if err != nil {
+			report.Reachable = false
+			report.Error = err.Error()
+			status.Error = err.Error()
+			report.Metrics = append(report.Metrics, status)
+			return report
+		}
+		status.SeriesCount = count
+		report.Metrics = append(report.Metrics, status)
+	}
+
+	var err error
+	if report.KubeStateMetrics, err = mc.detectKubeStateMetrics(ctx); err != nil {
+		report.Reachable = false
+		report.Error = err.Error()
+		return report
+	}
+	if report.CadvisorLabels, err = mc.detectCadvisorLabels(ctx); err != nil {
+		report.Reachable = false
+		report.Error = err.Error()
+		return report
+	}
+	report.LabelSchemaMatches = mc.labelSchemaMatches(report.CadvisorLabels)
+	report.SuggestedProfile = suggestProfile(report.KubeStateMetrics, report.CadvisorLabels, mc.profile.NamespaceLabel)
+
+	// Search twice the analysis period so the report shows how much headroom exists
+	lookback := 2 * report.RequiredRetention
+	if lookback < 24*time.Hour {
+		lookback = 24 * time.Hour
+	}
+	if report.OldestSampleAge, report.LookbackExhausted, err = mc.oldestSampleAge(ctx, lookback); err != nil {
+		report.Reachable = false
+		report.Error = err.Error()
+		return report
+	}
+
+	return report
+}
+
+// detectKubeStateMetrics reports which kube-state-metrics resource metric naming is present
+func (mc *MetricsChecker) detectKubeStateMetrics(ctx context.Context) (string, error) {
+	v2, err := mc.countSeries(ctx, mc.selector("kube_pod_container_resource_requests", Eq("resource", "memory")))
+	if err != nil {
+		return "", err
+	}
+	v1, err := mc.countSeries(ctx, mc.selector("kube_pod_container_resource_requests_memory_bytes"))
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case v1 > 0 && v2 > 0:
+		return KubeStateMetricsBoth, nil
+	case v2 > 0:
+		return KubeStateMetricsV2, nil
+	case v1 > 0:
+		return KubeStateMetricsV1, nil
+	default:
+		return KubeStateMetricsNone, nil
+	}
+}
+
+// detectCadvisorLabels reports whether cadvisor uses container/pod or the pre-1.16 container_name/pod_name labels
+func (mc *MetricsChecker) detectCadvisorLabels(ctx context.Context) (string, error) {
+	current, err := mc.countSeries(ctx, mc.selector(mc.profile.ContainerMemory, Neq("container", "")))
+	if err != nil {
+		return "", err
+	}
+	legacy, err := mc.countSeries(ctx, mc.selector(mc.profile.ContainerMemory, Neq("container_name", "")))
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case current > 0 && legacy > 0:
+		return CadvisorLabelsBoth, nil
+	case current > 0:
+		return CadvisorLabelsCurrent, nil
+	case legacy > 0:
+		return CadvisorLabelsLegacy, nil
+	default:
+		return CadvisorLabelsNone, nil
+	}
+}
+
+// labelSchemaMatches reports whether the detected cadvisor schema carries the profile's container label
+func (mc *MetricsChecker) labelSchemaMatches(schema string) bool {
+	switch schema {
+	case CadvisorLabelsBoth:
+		return true
+	case CadvisorLabelsCurrent:
+		return mc.profile.CadvisorContainerLabel == "container"
+	case CadvisorLabelsLegacy:
+		return mc.profile.CadvisorContainerLabel == "container_name"
+	default:
+		// Nothing to compare against; the missing metrics check reports this case
+		return true
+	}
+}
+
+// suggestProfile names the built-in profile matching the detected schemas, or "" if none does
+func suggestProfile(kubeStateMetrics, cadvisorLabels, namespaceLabel string) string {
+	if namespaceLabel == presets[ProfileKubernetesNamespace].NamespaceLabel {
+		if kubeStateMetrics == KubeStateMetricsV2 && cadvisorLabels == CadvisorLabelsCurrent {
+			return ProfileKubernetesNamespace
+		}
+		return ""
+	}
+
+	switch {
+	case cadvisorLabels == CadvisorLabelsLegacy && kubeStateMetrics == KubeStateMetricsV1:
+		return ProfileLegacyCadvisor
+	case cadvisorLabels == CadvisorLabelsLegacy:
+		return ""
+	case kubeStateMetrics == KubeStateMetricsV1:
+		return ProfileKubeStateMetricsV1
+	case kubeStateMetrics == KubeStateMetricsV2, kubeStateMetrics == KubeStateMetricsBoth:
+		return ProfileKubeStateMetricsV2
+	default:
+		return ""
+	}
+}
+
+// oldestSampleAge binary searches, at hourly resolution, for the oldest point
+// within lookback where the container memory metric still has data
+func (mc *MetricsChecker) oldestSampleAge(ctx context.Context, lookback time.Duration) (time.Duration, bool, error) {
+	now := time.Now().Unix()
+	promql := fmt.Sprintf(`count(last_over_time(%s[1h]))`,
+		mc.selector(mc.profile.ContainerMemory))
+
+	hasData := func(hoursAgo int) (bool, error) {
+		data, err := mc.client.QueryAtTime(ctx, promql, now-int64(hoursAgo)*3600)
+		if err != nil {
+			return false, err
+		}
+		return firstValue(data) > 0, nil
+	}
+
+	maxHours := int(lookback / time.Hour)
+	ok, err := hasData(maxHours)
+	if err != nil {
+		return 0, false, err
+	}
+	if ok {
+		return lookback, true, nil
+	}
+
+	ok, err = hasData(0)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+
+	// Invariant: data exists at lo hours ago and not at hi hours ago
+	lo, hi := 0, maxHours
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		ok, err := hasData(mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	// last_over_time looks back one hour, so the oldest sample is up to an hour older than lo
+	return time.Duration(lo+1) * time.Hour, false, nil
+}
+
+// countSeries returns the number of series matching a selector, counting
+// series duplicated across HA replicas once
+func (mc *MetricsChecker) countSeries(ctx context.Context, selector string) (int, error) {
+	promql := "count(" + selector + ")"
+	if label := mc.client.ReplicaLabel(); label != "" {
+		promql = fmt.Sprintf("count(count without (%s) (%s))", label, selector)
+	}
+
+	data, err := mc.client.Query(ctx, promql)
+	if err != nil {
+		return 0, err
+	}
+	return int(firstValue(data)), nil
+}
+
+// firstValue parses the sample value of the first result, returning 0 when absent
+func firstValue(data types.Data) float64 {
+	if len(data.Data.Result) == 0 {
+		return 0
+	}
+	value, _ := ParseValue(data.Data.Result[0].Value)
+	return value
+}
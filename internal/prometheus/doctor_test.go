@@ -0,0 +1,198 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newDoctorServer returns a mock Prometheus that reports count for every query
+// accepted by match, and keeps container_memory_rss history for retentionHours
+func newDoctorServer(match func(query string) (int, bool), retentionHours int) *httptest.Server {
+	now := time.Now().Unix()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		value := 0
+
+		if contains(query, "last_over_time") {
+			queryTime, _ := strconv.ParseInt(r.URL.Query().Get("time"), 10, 64)
+			if now-queryTime <= int64(retentionHours)*3600 {
+				value = 10
+			}
+		} else if count, ok := match(query); ok {
+			value = count
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if value == 0 {
+			w.Write([]byte(`{"data":{"result":[]}}`))
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"result":[{"metric":{},"value":[1234567890,"%d"]}]}}`, value)
+	}))
+}
+
+func TestMetricsChecker_Diagnose_Healthy(t *testing.T) {
+	server := newDoctorServer(func(query string) (int, bool) {
+		if contains(query, "_memory_bytes") || contains(query, "container_name") {
+			return 0, false
+		}
+		return 3, true
+	}, 30*24)
+	defer server.Close()
+
+	checker := NewMetricsChecker(NewClient(server.URL, 30*time.Second), "test-namespace")
+	report := checker.Diagnose(context.Background(), 7)
+
+	if !report.Reachable {
+		t.Fatalf("Expected Prometheus to be reachable, got error: %s", report.Error)
+	}
+	if len(report.Metrics) != 7 {
+		t.Errorf("Expected 7 metric statuses, got %d", len(report.Metrics))
+	}
+	for _, metric := range report.Metrics {
+		if metric.SeriesCount != 3 {
+			t.Errorf("Expected 3 series for %s, got %d", metric.Name, metric.SeriesCount)
+		}
+	}
+	if report.KubeStateMetrics != KubeStateMetricsV2 {
+		t.Errorf("Expected kube-state-metrics v2, got %s", report.KubeStateMetrics)
+	}
+	if report.CadvisorLabels != CadvisorLabelsCurrent {
+		t.Errorf("Expected current cadvisor labels, got %s", report.CadvisorLabels)
+	}
+	if !report.LookbackExhausted {
+		t.Error("Expected lookback to be exhausted with 30 days of history")
+	}
+	if failures := report.Failures(); len(failures) != 0 {
+		t.Errorf("Expected no failures, got %v", failures)
+	}
+}
+
+func TestMetricsChecker_Diagnose_LegacyClusterWithShortRetention(t *testing.T) {
+	server := newDoctorServer(func(query string) (int, bool) {
+		switch {
+		case contains(query, "kube_pod_container_resource_requests_memory_bytes"):
+			return 4, true
+		case contains(query, "kube_pod_container_resource"):
+			return 0, true
+		case contains(query, `container!=""`):
+			return 0, true
+		default:
+			return 4, true
+		}
+	}, 50)
+	defer server.Close()
+
+	checker := NewMetricsChecker(NewClient(server.URL, 30*time.Second), "test-namespace")
+	report := checker.Diagnose(context.Background(), 7)
+
+	if report.KubeStateMetrics != KubeStateMetricsV1 {
+		t.Errorf("Expected kube-state-metrics v1, got %s", report.KubeStateMetrics)
+	}
+	if report.CadvisorLabels != CadvisorLabelsLegacy {
+		t.Errorf("Expected legacy cadvisor labels, got %s", report.CadvisorLabels)
+	}
+
+	if report.SuggestedProfile != ProfileLegacyCadvisor {
+		t.Errorf("Expected suggested profile %s, got %q", ProfileLegacyCadvisor, report.SuggestedProfile)
+	}
+
+	missing := report.MissingMetrics()
+	if len(missing) != 2 {
+		t.Errorf("Expected 2 missing metrics, got %v", missing)
+	}
+
+	if report.LookbackExhausted {
+		t.Error("Expected lookback not to be exhausted")
+	}
+	if report.OldestSampleAge < 50*time.Hour || report.OldestSampleAge > 51*time.Hour {
+		t.Errorf("Expected oldest sample age around 50h, got %v", report.OldestSampleAge)
+	}
+
+	expected := []FailureClass{FailureMissingMetrics, FailureLabelSchema, FailureRetention}
+	failures := report.Failures()
+	if len(failures) != len(expected) {
+		t.Fatalf("Expected failures %v, got %v", expected, failures)
+	}
+	for i := range expected {
+		if failures[i] != expected[i] {
+			t.Errorf("Expected failure %v at position %d, got %v", expected[i], i, failures[i])
+		}
+	}
+}
+
+func TestMetricsChecker_Diagnose_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	checker := NewMetricsChecker(NewClient(server.URL, 30*time.Second), "test-namespace")
+	report := checker.Diagnose(context.Background(), 7)
+
+	if report.Reachable {
+		t.Error("Expected Prometheus to be unreachable")
+	}
+	failures := report.Failures()
+	if len(failures) != 1 || failures[0] != FailureUnreachable {
+		t.Errorf("Expected only FailureUnreachable, got %v", failures)
+	}
+}
+
+func TestMetricsChecker_Diagnose_LegacyProfileMatchesLegacySchema(t *testing.T) {
+	server := newDoctorServer(func(query string) (int, bool) {
+		if contains(query, `container!=""`) {
+			return 0, true
+		}
+		return 2, true
+	}, 30*24)
+	defer server.Close()
+
+	profile, err := LoadProfile(ProfileLegacyCadvisor)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	checker := NewMetricsChecker(NewClient(server.URL, 30*time.Second), "test-namespace")
+	checker.SetProfile(profile)
+	report := checker.Diagnose(context.Background(), 7)
+
+	if !report.LabelSchemaMatches {
+		t.Error("Expected legacy cadvisor labels to match the legacy profile")
+	}
+	if failures := report.Failures(); len(failures) != 0 {
+		t.Errorf("Expected no failures, got %v", failures)
+	}
+}
+
+func TestMetricsChecker_CountSeriesWithReplicaLabel(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("query")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"result":[{"metric":{},"value":[1234567890,"5"]}]}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 30*time.Second)
+	client.SetReplicaLabel("prometheus_replica")
+	checker := NewMetricsChecker(client, "test-namespace")
+
+	count, err := checker.countSeries(context.Background(), `kube_pod_owner{namespace="test-namespace"}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if count != 5 {
+		t.Errorf("Expected 5 series, got %d", count)
+	}
+	if query != `count(count without (prometheus_replica) (kube_pod_owner{namespace="test-namespace"}))` {
+		t.Errorf("Expected replica-aware count query, got: %s", query)
+	}
+}
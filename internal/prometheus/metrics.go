@@ -2,14 +2,25 @@ package prometheus
 
 import (
 	"context"
-	"fmt"
 	"log"
+
+	"kubernetes-resources-recommend/internal/types"
 )
 
+// requiredMetric describes a metric series the recommender depends on
+type requiredMetric struct {
+	Name     string
+	Selector string
+}
+
 // MetricsChecker validates that required metrics are available in Prometheus
 type MetricsChecker struct {
 	client    *Client
 	namespace string
+	profile   *types.MetricsProfile
+
+	clusterLabel string
+	cluster      string
 }
 
 // NewMetricsChecker creates a new metrics checker
@@ -17,29 +28,56 @@ func NewMetricsChecker(client *Client, namespace string) *MetricsChecker {
 	return &MetricsChecker{
 		client:    client,
 		namespace: namespace,
+		profile:   DefaultProfile(),
 	}
 }
 
-// CheckRequiredMetrics verifies that all required metrics are available
-func (mc *MetricsChecker) CheckRequiredMetrics(ctx context.Context) bool {
-	requiredMetrics := []string{
-		fmt.Sprintf(`container_memory_rss{namespace="%s"}`, mc.namespace),
-		fmt.Sprintf(`kube_pod_owner{namespace="%s"}`, mc.namespace),
-		fmt.Sprintf(`kube_replicaset_owner{namespace="%s"}`, mc.namespace),
-		fmt.Sprintf(`kube_deployment_created{namespace="%s"}`, mc.namespace),
-		fmt.Sprintf(`kube_deployment_spec_replicas{namespace="%s"}`, mc.namespace),
-		fmt.Sprintf(`kube_pod_container_resource_requests{namespace="%s", resource="memory"}`, mc.namespace),
-		fmt.Sprintf(`kube_pod_container_resource_limits{namespace="%s", resource="memory"}`, mc.namespace),
+// SetProfile sets the metric and label names used by the checks
+func (mc *MetricsChecker) SetProfile(profile *types.MetricsProfile) {
+	mc.profile = profile
+}
+
+// SetCluster restricts the checks to one cluster of a shared Prometheus/Thanos
+func (mc *MetricsChecker) SetCluster(clusterLabel, cluster string) {
+	mc.clusterLabel = clusterLabel
+	mc.cluster = cluster
+}
+
+// selector renders a series selector restricted to the checked cluster and namespace
+func (mc *MetricsChecker) selector(metric string, matchers ...Matcher) string {
+	scope := []Matcher{
+		Eq(mc.clusterLabel, mc.cluster),
+		Eq(mc.profile.NamespaceLabel, mc.namespace),
+	}
+	return Selector(metric, append(scope, matchers...)...)
+}
+
+// requiredMetrics returns the metric selectors that must have data in the namespace
+func (mc *MetricsChecker) requiredMetrics() []requiredMetric {
+	p := mc.profile
+	memory := Eq(p.ResourceLabel, p.ResourceMemory)
+
+	return []requiredMetric{
+		{p.ContainerMemory, mc.selector(p.ContainerMemory)},
+		{p.PodOwner, mc.selector(p.PodOwner)},
+		{p.ReplicaSetOwner, mc.selector(p.ReplicaSetOwner)},
+		{p.DeploymentCreated, mc.selector(p.DeploymentCreated)},
+		{p.DeploymentReplicas, mc.selector(p.DeploymentReplicas)},
+		{p.MemoryRequests, mc.selector(p.MemoryRequests, memory)},
+		{p.MemoryLimits, mc.selector(p.MemoryLimits, memory)},
 	}
+}
 
-	for _, metric := range requiredMetrics {
-		results, err := mc.client.Query(ctx, metric)
+// CheckRequiredMetrics verifies that all required metrics are available
+func (mc *MetricsChecker) CheckRequiredMetrics(ctx context.Context) bool {
+	for _, metric := range mc.requiredMetrics() {
+		results, err := mc.client.Query(ctx, metric.Selector)
 		if err != nil {
-			log.Printf("Error querying metric %s: %v", metric, err)
+			log.Printf("Error querying metric %s: %v", metric.Selector, err)
 			return false
 		}
 		if len(results.Data.Result) == 0 {
-			log.Printf("No data found for metric: %s", metric)
+			log.Printf("No data found for metric: %s", metric.Selector)
 			return false
 		}
 	}
@@ -0,0 +1,29 @@
+package prometheus
+
+import (
+	"regexp"
+	"strings"
+)
+
+// podNameSuffix matches the pod-template-hash and random suffix a Deployment
+// appends to the names of its pods, as in web-5d8f7c9b6-x2k9z
+const podNameSuffix = "-[a-z0-9]{5,10}-[a-z0-9]{5}"
+
+// deploymentPodName captures the deployment of a pod name
+var deploymentPodName = regexp.MustCompile("^(.+)" + podNameSuffix + "$")
+
+// DeploymentPods matches the label of the pods of deployment. PromQL anchors
+// the expression, so the pods of web do not include those of web-api.
+func DeploymentPods(label, deployment string) Matcher {
+	return Re(label, strings.ReplaceAll(deployment, ".", `\\.`)+podNameSuffix)
+}
+
+// DeploymentOfPod returns the deployment of a pod named
+// <deployment>-<pod-template-hash>-<suffix>, empty for other names such as
+// the pods of a StatefulSet
+func DeploymentOfPod(pod string) string {
+	if match := deploymentPodName.FindStringSubmatch(pod); match != nil {
+		return match[1]
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package prometheus
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDeploymentPods(t *testing.T) {
+	matcher := DeploymentPods("pod", "web")
+	if selector := Selector("kube_pod_info", matcher); selector != `kube_pod_info{pod=~"web-[a-z0-9]{5,10}-[a-z0-9]{5}"}` {
+		t.Errorf("Unexpected selector %s", selector)
+	}
+
+	// Prometheus anchors label regular expressions at both ends
+	pattern := regexp.MustCompile("^(?:" + matcher.Value + ")$")
+	for pod, expected := range map[string]bool{
+		"web-5d8f7c9b6-x2k9z":     true,
+		"web-7b9f5-abcde":         true,
+		"web-api-5d8f7c9b6-x2k9z": false,
+		"web-0":                   false,
+		"webapp-5d8f7c9b6-x2k9z":  false,
+	} {
+		if got := pattern.MatchString(pod); got != expected {
+			t.Errorf("%s: expected %v, got %v", pod, expected, got)
+		}
+	}
+
+	// Dots of the deployment name are literal
+	if value := DeploymentPods("pod", "web.v2").Value; !strings.HasPrefix(value, `web\\.v2-`) {
+		t.Errorf("Expected the dot to be escaped, got %s", value)
+	}
+}
+
+func TestDeploymentOfPod(t *testing.T) {
+	for pod, deployment := range map[string]string{
+		"web-5d8f7c9b6-x2k9z":     "web",
+		"web-api-5d8f7c9b6-x2k9z": "web-api",
+		"db-0":                    "",
+		"web-x2k9z":               "",
+	} {
+		if got := DeploymentOfPod(pod); got != deployment {
+			t.Errorf("%s: expected %q, got %q", pod, deployment, got)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+const (
+	ProfileKubeStateMetricsV2  = "kube-state-metrics-v2"
+	ProfileKubeStateMetricsV1  = "kube-state-metrics-v1"
+	ProfileLegacyCadvisor      = "legacy-cadvisor"
+	ProfileKubernetesNamespace = "kubernetes-namespace"
+)
+
+// presets holds the built-in metrics profiles keyed by name
+var presets = map[string]types.MetricsProfile{
+	ProfileKubeStateMetricsV2: kubeStateMetricsV2(),
+	ProfileKubeStateMetricsV1: func() types.MetricsProfile {
+		p := kubeStateMetricsV2()
+		p.Name = ProfileKubeStateMetricsV1
+		p.MemoryRequests = "kube_pod_container_resource_requests_memory_bytes"
+		p.MemoryLimits = "kube_pod_container_resource_limits_memory_bytes"
+		p.NodeAllocatable = "kube_node_status_allocatable_memory_bytes"
+		p.ResourceLabel = ""
+		p.ResourceMemory = ""
+		return p
+	}(),
+	ProfileLegacyCadvisor: func() types.MetricsProfile {
+		p := kubeStateMetricsV2()
+		p.Name = ProfileLegacyCadvisor
+		p.MemoryRequests = "kube_pod_container_resource_requests_memory_bytes"
+		p.MemoryLimits = "kube_pod_container_resource_limits_memory_bytes"
+		p.NodeAllocatable = "kube_node_status_allocatable_memory_bytes"
+		p.ResourceLabel = ""
+		p.ResourceMemory = ""
+		p.CadvisorContainerLabel = "container_name"
+		p.CadvisorPodLabel = "pod_name"
+		return p
+	}(),
+	ProfileKubernetesNamespace: func() types.MetricsProfile {
+		p := kubeStateMetricsV2()
+		p.Name = ProfileKubernetesNamespace
+		p.NamespaceLabel = "kubernetes_namespace"
+		return p
+	}(),
+}
+
+// kubeStateMetricsV2 returns the names exposed by kube-state-metrics v2 and cadvisor on Kubernetes 1.16+
+func kubeStateMetricsV2() types.MetricsProfile {
+	return types.MetricsProfile{
+		Name:                   ProfileKubeStateMetricsV2,
+		ContainerMemory:        "container_memory_rss",
+		PodOwner:               "kube_pod_owner",
+		ReplicaSetOwner:        "kube_replicaset_owner",
+		DeploymentCreated:      "kube_deployment_created",
+		DeploymentReplicas:     "kube_deployment_spec_replicas",
+		MemoryRequests:         "kube_pod_container_resource_requests",
+		MemoryLimits:           "kube_pod_container_resource_limits",
+		ContainerOOMEvents:     "container_oom_events_total",
+		ContainerRestarts:      "kube_pod_container_status_restarts_total",
+		PodStatusReason:        "kube_pod_status_reason",
+		PodInfo:                "kube_pod_info",
+		NodeLabels:             "kube_node_labels",
+		NodeAllocatable:        "kube_node_status_allocatable",
+		PodStatusPhase:         "kube_pod_status_phase",
+		ResourceLabel:          "resource",
+		ResourceMemory:         "memory",
+		NamespaceLabel:         "namespace",
+		CadvisorContainerLabel: "container",
+		CadvisorPodLabel:       "pod",
+		ContainerLabel:         "container",
+		PodLabel:               "pod",
+		OwnerNameLabel:         "owner_name",
+		ReplicaSetLabel:        "replicaset",
+		DeploymentLabel:        "deployment",
+		ReasonLabel:            "reason",
+		NodeLabel:              "node",
+		OwnerKindLabel:         "owner_kind",
+		PhaseLabel:             "phase",
+	}
+}
+
+// DefaultProfile returns the profile used when none is configured
+func DefaultProfile() *types.MetricsProfile {
+	p := kubeStateMetricsV2()
+	return &p
+}
+
+// ProfileNames returns the names of the built-in profiles
+func ProfileNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadProfile resolves a built-in profile by name, or reads a JSON profile
+// file. A file may set "base" to a preset name and override only the names
+// that differ from it.
+func LoadProfile(nameOrPath string) (*types.MetricsProfile, error) {
+	if nameOrPath == "" {
+		return DefaultProfile(), nil
+	}
+	if preset, ok := presets[nameOrPath]; ok {
+		return &preset, nil
+	}
+
+	content, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("unknown metrics profile %q (built-in profiles: %s): %w",
+			nameOrPath, strings.Join(ProfileNames(), ", "), err)
+	}
+
+	var header struct {
+		Base string `json:"base"`
+	}
+	if err := json.Unmarshal(content, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics profile %s: %w", nameOrPath, err)
+	}
+	if header.Base == "" {
+		header.Base = ProfileKubeStateMetricsV2
+	}
+	profile, ok := presets[header.Base]
+	if !ok {
+		return nil, fmt.Errorf("metrics profile %s: unknown base profile %q", nameOrPath, header.Base)
+	}
+
+	profile.Name = nameOrPath
+	if err := json.Unmarshal(content, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics profile %s: %w", nameOrPath, err)
+	}
+	return &profile, nil
+}
+
+// Matcher is a single PromQL label matcher
+type Matcher struct {
+	Label string
+	Op    string
+	Value string
+}
+
+// Eq matches a label equal to value
+func Eq(label, value string) Matcher {
+	return Matcher{Label: label, Op: "=", Value: value}
+}
+
+// Neq matches a label not equal to value
+func Neq(label, value string) Matcher {
+	return Matcher{Label: label, Op: "!=", Value: value}
+}
+
+// Re matches a label against a regular expression
+func Re(label, value string) Matcher {
+	return Matcher{Label: label, Op: "=~", Value: value}
+}
+
+// Selector renders a PromQL series selector. Matchers with an empty label are skipped.
+func Selector(metric string, matchers ...Matcher) string {
+	parts := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		if m.Label == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf(`%s%s"%s"`, m.Label, m.Op, m.Value))
+	}
+	return metric + "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,129 @@
+package prometheus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProfile_Presets(t *testing.T) {
+	for _, name := range ProfileNames() {
+		t.Run(name, func(t *testing.T) {
+			profile, err := LoadProfile(name)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if profile.Name != name {
+				t.Errorf("Expected profile name '%s', got '%s'", name, profile.Name)
+			}
+			if profile.ContainerMemory == "" || profile.NamespaceLabel == "" || profile.CadvisorContainerLabel == "" {
+				t.Errorf("Expected preset %s to define every metric and label name, got %+v", name, profile)
+			}
+		})
+	}
+}
+
+func TestLoadProfile_Default(t *testing.T) {
+	profile, err := LoadProfile("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if profile.Name != ProfileKubeStateMetricsV2 {
+		t.Errorf("Expected default profile %s, got %s", ProfileKubeStateMetricsV2, profile.Name)
+	}
+	if profile.MemoryRequests != "kube_pod_container_resource_requests" {
+		t.Errorf("Expected v2 requests metric, got %s", profile.MemoryRequests)
+	}
+}
+
+func TestLoadProfile_LegacyPreset(t *testing.T) {
+	profile, err := LoadProfile(ProfileLegacyCadvisor)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if profile.MemoryRequests != "kube_pod_container_resource_requests_memory_bytes" {
+		t.Errorf("Expected v1 requests metric, got %s", profile.MemoryRequests)
+	}
+	if profile.ResourceLabel != "" {
+		t.Errorf("Expected no resource label, got %s", profile.ResourceLabel)
+	}
+	if profile.CadvisorContainerLabel != "container_name" || profile.CadvisorPodLabel != "pod_name" {
+		t.Errorf("Expected legacy cadvisor labels, got %s/%s", profile.CadvisorContainerLabel, profile.CadvisorPodLabel)
+	}
+	if profile.ContainerLabel != "container" {
+		t.Errorf("Expected kube-state-metrics container label to stay 'container', got %s", profile.ContainerLabel)
+	}
+}
+
+func TestLoadProfile_PresetsAreIndependentCopies(t *testing.T) {
+	first, _ := LoadProfile(ProfileKubeStateMetricsV2)
+	first.NamespaceLabel = "changed"
+
+	second, _ := LoadProfile(ProfileKubeStateMetricsV2)
+	if second.NamespaceLabel != "namespace" {
+		t.Errorf("Expected preset to be unaffected by changes to a loaded copy, got %s", second.NamespaceLabel)
+	}
+}
+
+func TestLoadProfile_FileWithBase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.json")
+	content := `{"base": "kube-state-metrics-v1", "namespace_label": "kubernetes_namespace"}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+
+	profile, err := LoadProfile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if profile.NamespaceLabel != "kubernetes_namespace" {
+		t.Errorf("Expected overridden namespace label, got %s", profile.NamespaceLabel)
+	}
+	if profile.MemoryLimits != "kube_pod_container_resource_limits_memory_bytes" {
+		t.Errorf("Expected limits metric from the v1 base, got %s", profile.MemoryLimits)
+	}
+	if profile.Name != path {
+		t.Errorf("Expected profile name '%s', got '%s'", path, profile.Name)
+	}
+}
+
+func TestLoadProfile_Errors(t *testing.T) {
+	if _, err := LoadProfile("no-such-profile"); err == nil {
+		t.Error("Expected error for unknown profile, got nil")
+	}
+
+	path := filepath.Join(t.TempDir(), "profile.json")
+	if err := os.WriteFile(path, []byte(`{"base": "unknown"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+	if _, err := LoadProfile(path); err == nil {
+		t.Error("Expected error for unknown base profile, got nil")
+	}
+}
+
+func TestSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		metric   string
+		matchers []Matcher
+		expected string
+	}{
+		{"No matchers", "up", nil, "up{}"},
+		{"Equality", "up", []Matcher{Eq("namespace", "prod")}, `up{namespace="prod"}`},
+		{
+			"Mixed operators",
+			"container_memory_rss",
+			[]Matcher{Eq("namespace", "prod"), Neq("container", ""), Re("pod", "a|b")},
+			`container_memory_rss{namespace="prod", container!="", pod=~"a|b"}`,
+		},
+		{"Empty label skipped", "m", []Matcher{Eq("", "memory"), Eq("a", "b")}, `m{a="b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Selector(tt.metric, tt.matchers...); got != tt.expected {
+				t.Errorf("Expected selector '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package prometheus
+
+import (
+	"math"
+	"strconv"
+)
+
+// ParseValue parses the [timestamp, "value"] sample of an instant query,
+// rejecting malformed and NaN values
+func ParseValue(sample []interface{}) (float64, bool) {
+	if len(sample) != 2 {
+		return 0, false
+	}
+	value, ok := sample[1].(string)
+	if !ok {
+		return 0, false
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) {
+		return 0, false
+	}
+	return parsed, true
+}
+
+// ParseSample parses a [timestamp, "value"] sample of a range query into its
+// Unix time and value
+func ParseSample(sample []interface{}) (int64, float64, bool) {
+	if len(sample) != 2 {
+		return 0, 0, false
+	}
+	at, ok := sample[0].(float64)
+	if !ok {
+		return 0, 0, false
+	}
+	value, ok := ParseValue(sample)
+	if !ok {
+		return 0, 0, false
+	}
+	return int64(at), value, true
+}
@@ -0,0 +1,30 @@
+package prometheus
+
+import "testing"
+
+func TestParseValue(t *testing.T) {
+	if value, ok := ParseValue([]interface{}{1.0, "1.5"}); !ok || value != 1.5 {
+		t.Errorf("Expected 1.5, got %v %v", value, ok)
+	}
+	for _, sample := range [][]interface{}{
+		nil,
+		{1.0},
+		{1.0, 1.5},
+		{1.0, "abc"},
+		{1.0, "NaN"},
+	} {
+		if _, ok := ParseValue(sample); ok {
+			t.Errorf("Expected %v to be rejected", sample)
+		}
+	}
+}
+
+func TestParseSample(t *testing.T) {
+	at, value, ok := ParseSample([]interface{}{1700000000.0, "42"})
+	if !ok || at != 1700000000 || value != 42 {
+		t.Errorf("Expected 1700000000 42, got %d %v %v", at, value, ok)
+	}
+	if _, _, ok := ParseSample([]interface{}{"1700000000", "42"}); ok {
+		t.Error("Expected a string timestamp to be rejected")
+	}
+}
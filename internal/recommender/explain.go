@@ -0,0 +1,141 @@
+package recommender
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// Trace is the full derivation of the recommendations of one deployment
+type Trace struct {
+	Cluster         string    `json:"cluster,omitempty"`
+	Namespace       string    `json:"namespace"`
+	Deployment      string    `json:"deployment"`
+	Now             time.Time `json:"now"`
+	CountDays       int       `json:"count_days"`
+	Percentile      float64   `json:"percentile"`
+	MinRequestBytes float64   `json:"min_request_bytes,omitempty"`
+	MaxRequestBytes float64   `json:"max_request_bytes,omitempty"`
+	LimitMultiplier float64   `json:"limit_multiplier"`
+
+	// Eligible reports whether the deployment passed the age and replica
+	// filters of a full run; an explained deployment is analysed regardless
+	Eligible bool `json:"eligible"`
+
+	Queries    []prometheus.QueryRecord `json:"queries"`
+	Hours      []HourTrace              `json:"hours"`
+	Containers []*ContainerTrace        `json:"containers"`
+}
+
+// HourTrace is an analysed hour with the ReplicaSets and pods it resolved
+type HourTrace struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	ReplicaSets []string  `json:"replicasets"`
+	Pods        []string  `json:"pods"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// DayTrace is the percentile usage of a container over one day and its weight
+type DayTrace struct {
+	Day             int       `json:"day"`
+	End             time.Time `json:"end"`
+	Samples         int       `json:"samples"`
+	PercentileIndex int       `json:"percentile_index"` // index of the percentile among the sorted samples
+	PercentileBytes float64   `json:"percentile_bytes"`
+	Weight          float64   `json:"weight"`
+	WeightedBytes   float64   `json:"weighted_bytes"`
+}
+
+// ContainerTrace is the derivation of the recommendation of a container: the
+// weighted sum of its days, then the clamping, rounding and limit multiplier
+type ContainerTrace struct {
+	Container        string     `json:"container"`
+	Days             []DayTrace `json:"days"`
+	WeightedSumBytes float64    `json:"weighted_sum_bytes"`
+	ClampedBytes     float64    `json:"clamped_bytes"`
+	Clamp            string     `json:"clamp,omitempty"` // min or max when a bound applied
+
+	Recommendation types.RecommendationResult `json:"recommendation"`
+}
+
+// container returns the trace of container, added on first use
+func (t *Trace) container(name string) *ContainerTrace {
+	for _, c := range t.Containers {
+		if c.Container == name {
+			return c
+		}
+	}
+	c := &ContainerTrace{Container: name}
+	t.Containers = append(t.Containers, c)
+	return c
+}
+
+// addDay records the percentile usage of a day, index being its position among the sorted samples
+func (c *ContainerTrace) addDay(usage types.DailyUsage, index int) {
+	c.Days = append(c.Days, DayTrace{
+		Day:             usage.Day,
+		End:             usage.End,
+		Samples:         usage.Samples,
+		PercentileIndex: index,
+		PercentileBytes: usage.PercentileBytes,
+		Weight:          usage.Weight,
+		WeightedBytes:   usage.PercentileBytes * usage.Weight,
+	})
+}
+
+// Explain derives the recommendations of a single deployment, recording every
+// query issued and each intermediate step. It records the queries of the
+// client of the recommender, which must not serve other callers meanwhile.
+// The deployment is analysed even when a full run would skip it.
+func (r *Recommender) Explain(ctx context.Context, deployment string) (*Trace, error) {
+	r.reset(time.Now())
+	trace := &Trace{
+		Cluster:         r.cluster,
+		Namespace:       r.namespace,
+		Deployment:      deployment,
+		Now:             time.Unix(r.now, 0).UTC(),
+		CountDays:       r.countDays,
+		Percentile:      r.percentile,
+		MinRequestBytes: r.minRequestBytes,
+		MaxRequestBytes: r.maxRequestBytes,
+		LimitMultiplier: r.limitMultiplier,
+		Queries:         []prometheus.QueryRecord{},
+		Hours:           []HourTrace{},
+		Containers:      []*ContainerTrace{},
+	}
+	r.client.SetRecorder(func(record prometheus.QueryRecord) {
+		trace.Queries = append(trace.Queries, record)
+	})
+	defer r.client.SetRecorder(nil)
+
+	deployments, err := r.getEligibleDeployments(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployments: %w", err)
+	}
+	for _, result := range deployments.Data.Result {
+		if result.Metric[r.profile.DeploymentLabel] == deployment {
+			trace.Eligible = true
+		}
+	}
+
+	containerMemory, containerDaily := r.analyzeDeployment(ctx, deployment, trace)
+	sort.Slice(trace.Containers, func(i, j int) bool { return trace.Containers[i].Container < trace.Containers[j].Container })
+	for _, c := range trace.Containers {
+		c.WeightedSumBytes = containerMemory[c.Container]
+		c.ClampedBytes = r.clampRequest(c.WeightedSumBytes)
+		switch {
+		case c.ClampedBytes > c.WeightedSumBytes:
+			c.Clamp = "min"
+		case c.ClampedBytes < c.WeightedSumBytes:
+			c.Clamp = "max"
+		}
+		c.Recommendation = r.recommendation(ctx, deployment, c.Container, c.WeightedSumBytes, containerDaily[c.Container])
+		c.Recommendation.Daily = nil // detailed by the days of the trace
+	}
+	return trace, nil
+}
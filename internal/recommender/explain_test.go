@@ -0,0 +1,60 @@
+package recommender
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func TestRecommender_Explain(t *testing.T) {
+	client := prometheus.NewClient("http://unused", 30*time.Second)
+	recommender := NewRecommender(client, &types.RecommendationConfig{
+		Namespace:             "test-namespace",
+		CountDays:             1,
+		WorkerCount:           1,
+		MinRequestMB:          16,
+		MemoryLimitMultiplier: 1.5,
+	})
+	server := newHourlyUsageServer(t, time.Now().Unix())
+	defer server.Close()
+	recommender.client = prometheus.NewClient(server.URL, 30*time.Second)
+
+	trace, err := recommender.Explain(context.Background(), "web")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The deployment query, three per hour, then the current request and limit
+	if len(trace.Queries) != 1+24*3+2 {
+		t.Errorf("Expected %d queries, got %d", 1+24*3+2, len(trace.Queries))
+	}
+	if trace.Queries[1].Kind != prometheus.QueryKindRange || !contains(trace.Queries[1].PromQL, `owner_name="web"`) {
+		t.Errorf("Expected the ReplicaSets of the first hour to be queried second, got %+v", trace.Queries[1])
+	}
+	if trace.Eligible {
+		t.Error("Expected the deployment to be reported as not eligible")
+	}
+	if len(trace.Hours) != 24 || trace.Hours[0].Pods[0] != "web-12345-abcde" || trace.Hours[0].ReplicaSets[0] != "web-12345" {
+		t.Fatalf("Unexpected hours: %+v", trace.Hours)
+	}
+
+	if len(trace.Containers) != 1 {
+		t.Fatalf("Expected 1 container, got %d", len(trace.Containers))
+	}
+	container := trace.Containers[0]
+	const mb = 1024 * 1024
+	// The P90 of 0-23 MiB is the 22nd sample, halved by the weight of the day
+	if len(container.Days) != 1 || container.Days[0].PercentileIndex != 21 || container.Days[0].WeightedBytes != 21*mb*0.5 {
+		t.Errorf("Unexpected days: %+v", container.Days)
+	}
+	if container.WeightedSumBytes != 10.5*mb || container.ClampedBytes != 16*mb || container.Clamp != "min" {
+		t.Errorf("Expected 10.5 MiB raised to the 16 MiB minimum, got %+v", container)
+	}
+	rec := container.Recommendation
+	if rec.RecommendedRequestMB != 16 || rec.RecommendedLimitMB != 24 || rec.Daily != nil {
+		t.Errorf("Unexpected recommendation: %+v", rec)
+	}
+}
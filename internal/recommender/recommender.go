@@ -30,36 +30,72 @@ type Recommender struct {
 	countDays       int
 	workerCount     int
 	limitMultiplier float64
+	profile         *types.MetricsProfile
+	clusterLabel    string
+	cluster         string
+	percentile      float64
+	minRequestBytes float64
+	maxRequestBytes float64
 
 	deploymentChan chan string
 	wg             sync.WaitGroup
 	mux            sync.RWMutex
 	results        map[string]map[string]float64
+	daily          map[string]map[string][]types.DailyUsage
 	now            int64
 	memoryPool     sync.Pool
 }
 
+// defaultPercentile is the percentile of the hourly usage taken per day when none is configured
+const defaultPercentile = 90
+
 // NewRecommender creates a new memory recommender
 func NewRecommender(client *prometheus.Client, config *types.RecommendationConfig) *Recommender {
-	return &Recommender{
+	profile := config.Profile
+	if profile == nil {
+		profile = prometheus.DefaultProfile()
+	}
+	percentile := config.Percentile
+	if percentile <= 0 {
+		percentile = defaultPercentile
+	}
+
+	r := &Recommender{
 		client:          client,
 		namespace:       config.Namespace,
 		countDays:       config.CountDays,
 		workerCount:     config.WorkerCount,
 		limitMultiplier: config.MemoryLimitMultiplier,
-		deploymentChan:  make(chan string, 100),
-		results:         make(map[string]map[string]float64),
-		now:             time.Now().Unix(),
+		profile:         profile,
+		clusterLabel:    config.ClusterLabel,
+		cluster:         config.Cluster,
+		percentile:      percentile,
+		minRequestBytes: float64(config.MinRequestMB) * 1024 * 1024,
+		maxRequestBytes: float64(config.MaxRequestMB) * 1024 * 1024,
 		memoryPool: sync.Pool{
 			New: func() interface{} {
 				return make(map[string][]float64)
 			},
 		},
 	}
+	r.reset(time.Now())
+	return r
+}
+
+// reset clears the state of the previous run and anchors the analysis window at now
+func (r *Recommender) reset(now time.Time) {
+	r.deploymentChan = make(chan string, 100)
+	r.results = make(map[string]map[string]float64)
+	r.daily = make(map[string]map[string][]types.DailyUsage)
+	r.now = now.Unix()
 }
 
-// GenerateRecommendations generates memory recommendations for all deployments
+// GenerateRecommendations generates memory recommendations for all deployments,
+// analysing the days up to the time of the call. A recommender may run again
+// once the previous run has returned.
 func (r *Recommender) GenerateRecommendations(ctx context.Context) ([]types.RecommendationResult, error) {
+	r.reset(time.Now())
+
 	// Get all eligible deployments
 	deployments, err := r.getEligibleDeployments(ctx)
 	if err != nil {
@@ -74,7 +110,7 @@ func (r *Recommender) GenerateRecommendations(ctx context.Context) ([]types.Reco
 
 	// Send deployments to workers
 	for _, result := range deployments.Data.Result {
-		deployment := result.Metric["deployment"]
+		deployment := result.Metric[r.profile.DeploymentLabel]
 		r.deploymentChan <- deployment
 	}
 
@@ -85,68 +121,108 @@ func (r *Recommender) GenerateRecommendations(ctx context.Context) ([]types.Reco
 	var recommendations []types.RecommendationResult
 	r.mux.RLock()
 	for deployment, containers := range r.results {
-		for container, recommendedMemoryBytes := range containers {
-			// Get current resource configuration
-			currentConfig, err := r.getCurrentResourceConfig(ctx, deployment, container)
-			if err != nil {
-				log.Printf("Warning: failed to get current config for %s/%s: %v", deployment, container, err)
-				currentConfig = &ResourceConfig{} // Use zero values if can't get current config
-			}
+		for container, weightedBytes := range containers {
+			recommendations = append(recommendations, r.recommendation(ctx, deployment, container, weightedBytes, r.daily[deployment][container]))
+		}
+	}
+	r.mux.RUnlock()
 
-			// Calculate recommended values
-			recommendedRequestMB := int64(recommendedMemoryBytes) / 1024 / 1024
-			recommendedLimitMB := int64(recommendedMemoryBytes*r.limitMultiplier) / 1024 / 1024
-			recommendedLimitBytes := recommendedMemoryBytes * r.limitMultiplier
+	return recommendations, nil
+}
 
-			// Calculate optimization metrics
-			requestOptimizationMB := currentConfig.RequestMB - recommendedRequestMB
-			limitOptimizationMB := currentConfig.LimitMB - recommendedLimitMB
+// recommendation turns the weighted daily usage of a container into its
+// recommended request and limit, compared to its current configuration
+func (r *Recommender) recommendation(ctx context.Context, deployment, container string, weightedBytes float64, daily []types.DailyUsage) types.RecommendationResult {
+	// Get current resource configuration
+	currentConfig, err := r.getCurrentResourceConfig(ctx, deployment, container)
+	if err != nil {
+		log.Printf("Warning: failed to get current config for %s/%s: %v", deployment, container, err)
+		currentConfig = &ResourceConfig{} // Use zero values if can't get current config
+	}
 
-			var requestOptimizationPct, limitOptimizationPct float64
-			if currentConfig.RequestMB > 0 {
-				requestOptimizationPct = float64(requestOptimizationMB) / float64(currentConfig.RequestMB) * 100
-			}
-			if currentConfig.LimitMB > 0 {
-				limitOptimizationPct = float64(limitOptimizationMB) / float64(currentConfig.LimitMB) * 100
-			}
+	// Calculate recommended values
+	recommendedMemoryBytes := r.clampRequest(weightedBytes)
+	recommendedRequestMB := int64(recommendedMemoryBytes) / 1024 / 1024
+	recommendedLimitMB := int64(recommendedMemoryBytes*r.limitMultiplier) / 1024 / 1024
+	recommendedLimitBytes := recommendedMemoryBytes * r.limitMultiplier
 
-			recommendations = append(recommendations, types.RecommendationResult{
-				Namespace:  r.namespace,
-				Deployment: deployment,
-				Container:  container,
-
-				// Current configuration
-				CurrentRequestMB:    currentConfig.RequestMB,
-				CurrentLimitMB:      currentConfig.LimitMB,
-				CurrentRequestBytes: currentConfig.RequestBytes,
-				CurrentLimitBytes:   currentConfig.LimitBytes,
-
-				// Recommended configuration
-				RecommendedRequestMB:    recommendedRequestMB,
-				RecommendedLimitMB:      recommendedLimitMB,
-				RecommendedRequestBytes: recommendedMemoryBytes,
-				RecommendedLimitBytes:   recommendedLimitBytes,
-
-				// Optimization metrics
-				RequestOptimizationMB:  requestOptimizationMB,
-				LimitOptimizationMB:    limitOptimizationMB,
-				RequestOptimizationPct: requestOptimizationPct,
-				LimitOptimizationPct:   limitOptimizationPct,
-
-				MemoryLimitMultiplier: r.limitMultiplier,
-			})
-		}
+	// Calculate optimization metrics
+	requestOptimizationMB := currentConfig.RequestMB - recommendedRequestMB
+	limitOptimizationMB := currentConfig.LimitMB - recommendedLimitMB
+
+	var requestOptimizationPct, limitOptimizationPct float64
+	if currentConfig.RequestMB > 0 {
+		requestOptimizationPct = float64(requestOptimizationMB) / float64(currentConfig.RequestMB) * 100
+	}
+	if currentConfig.LimitMB > 0 {
+		limitOptimizationPct = float64(limitOptimizationMB) / float64(currentConfig.LimitMB) * 100
 	}
-	r.mux.RUnlock()
 
-	return recommendations, nil
+	return types.RecommendationResult{
+		Cluster:    r.cluster,
+		Namespace:  r.namespace,
+		Deployment: deployment,
+		Container:  container,
+
+		// Current configuration
+		CurrentRequestMB:    currentConfig.RequestMB,
+		CurrentLimitMB:      currentConfig.LimitMB,
+		CurrentRequestBytes: currentConfig.RequestBytes,
+		CurrentLimitBytes:   currentConfig.LimitBytes,
+
+		// Recommended configuration
+		RecommendedRequestMB:    recommendedRequestMB,
+		RecommendedLimitMB:      recommendedLimitMB,
+		RecommendedRequestBytes: recommendedMemoryBytes,
+		RecommendedLimitBytes:   recommendedLimitBytes,
+
+		// Optimization metrics
+		RequestOptimizationMB:  requestOptimizationMB,
+		LimitOptimizationMB:    limitOptimizationMB,
+		RequestOptimizationPct: requestOptimizationPct,
+		LimitOptimizationPct:   limitOptimizationPct,
+
+		MemoryLimitMultiplier: r.limitMultiplier,
+
+		Confidence: r.confidence(daily),
+		Daily:      daily,
+	}
+}
+
+// confidence returns the weight of the days with samples over the weight of
+// every analysed day
+func (r *Recommender) confidence(days []types.DailyUsage) float64 {
+	var covered, total float64
+	for _, day := range days {
+		covered += day.Weight
+	}
+	for day := 0; day < r.countDays; day++ {
+		total += math.Pow(0.5, float64(day+1))
+	}
+	if total == 0 {
+		return 0
+	}
+	return covered / total
+}
+
+// clampRequest limits the recommended request to the configured bounds
+func (r *Recommender) clampRequest(bytes float64) float64 {
+	if r.minRequestBytes > 0 && bytes < r.minRequestBytes {
+		return r.minRequestBytes
+	}
+	if r.maxRequestBytes > 0 && bytes > r.maxRequestBytes {
+		return r.maxRequestBytes
+	}
+	return bytes
 }
 
 // getEligibleDeployments retrieves deployments that are eligible for analysis
 func (r *Recommender) getEligibleDeployments(ctx context.Context) (types.Data, error) {
 	// Get deployments created before the analysis period and with replicas > 0
-	promql := fmt.Sprintf(`kube_deployment_created{namespace="%s"} <= %d and kube_deployment_spec_replicas > 0`,
-		r.namespace, time.Now().Unix()-int64(r.countDays*86400))
+	promql := fmt.Sprintf(`%s <= %d and %s > 0`,
+		r.selector(r.profile.DeploymentCreated),
+		r.now-int64(r.countDays*86400),
+		r.profile.DeploymentReplicas)
 
 	return r.client.Query(ctx, promql)
 }
@@ -156,64 +232,135 @@ func (r *Recommender) worker(ctx context.Context) {
 	defer r.wg.Done()
 
 	for deployment := range r.deploymentChan {
-		containerMemory := make(map[string]float64)
+		containerMemory, containerDaily := r.analyzeDeployment(ctx, deployment, nil)
 
-		// Analyze past N days
-		for day := 0; day < r.countDays; day++ {
-			dayMemory := r.memoryPool.Get().(map[string][]float64)
-			defer r.memoryPool.Put(dayMemory)
+		// Store results
+		r.mux.Lock()
+		r.results[deployment] = containerMemory
+		r.daily[deployment] = containerDaily
+		r.mux.Unlock()
 
-			// Clear the map
-			for k := range dayMemory {
-				delete(dayMemory, k)
-			}
+		// Log progress
+		for container := range containerMemory {
+			log.Printf("Processed namespace: %s, deployment: %s, container: %s",
+				r.namespace, deployment, container)
+		}
+	}
+}
+
+// analyzeDeployment returns the decay-weighted sum of the daily percentile
+// usage of every container of deployment, with the days behind it. Each step
+// is recorded in trace when not nil.
+func (r *Recommender) analyzeDeployment(ctx context.Context, deployment string, trace *Trace) (map[string]float64, map[string][]types.DailyUsage) {
+	containerMemory := make(map[string]float64)
+	containerDaily := make(map[string][]types.DailyUsage)
 
-			// Analyze 24 hours for this day
-			for hour := 0; hour < 24; hour++ {
-				queryEnd := r.now - int64(day*24*3600+hour*3600)
-				queryStart := queryEnd - 3600
+	// Analyze past N days
+	for day := 0; day < r.countDays; day++ {
+		dayMemory := r.memoryPool.Get().(map[string][]float64)
+
+		// Clear the map
+		for k := range dayMemory {
+			delete(dayMemory, k)
+		}
 
-				if err := r.analyzeHour(ctx, deployment, queryStart, queryEnd, dayMemory); err != nil {
-					continue // Skip this hour on error
+		// Analyze 24 hours for this day, keeping the samples of each hour
+		dayHourly := make(map[string][]types.HourlyUsage)
+		for hour := 0; hour < 24; hour++ {
+			queryEnd := r.now - int64(day*24*3600+hour*3600)
+			queryStart := queryEnd - 3600
+
+			var hourTrace *HourTrace
+			if trace != nil {
+				hourTrace = &HourTrace{Start: time.Unix(queryStart, 0).UTC(), End: time.Unix(queryEnd, 0).UTC()}
+			}
+			hourMemory := make(map[string][]float64)
+			err := r.analyzeHour(ctx, deployment, queryStart, queryEnd, hourMemory, hourTrace)
+			if hourTrace != nil {
+				if err != nil {
+					hourTrace.Error = err.Error()
 				}
+				trace.Hours = append(trace.Hours, *hourTrace)
+			}
+			if err != nil {
+				continue // Skip this hour on error
+			}
+			// Each hour contributes the mean usage of the pods to the day
+			for container, memories := range hourMemory {
+				usage := hourlyUsage(queryEnd, memories)
+				dayMemory[container] = append(dayMemory[container], usage.MeanBytes)
+				dayHourly[container] = append(dayHourly[container], usage)
 			}
+		}
 
-			// Calculate P90 for this day and apply weight
-			for container, memories := range dayMemory {
-				if len(memories) > 0 {
-					sort.Float64s(memories)
-					p90Index := len(memories) * 90 / 100
-					if p90Index >= len(memories) {
-						p90Index = len(memories) - 1
-					}
-
-					// Apply exponential decay weight: 0.5^(day+1)
-					weight := math.Pow(0.5, float64(day+1))
-					containerMemory[container] += memories[p90Index] * weight
+		// Calculate the percentile for this day and apply weight
+		for container, memories := range dayMemory {
+			if len(memories) > 0 {
+				sort.Float64s(memories)
+				index := r.percentileIndex(len(memories))
+
+				// Apply exponential decay weight: 0.5^(day+1)
+				weight := math.Pow(0.5, float64(day+1))
+				containerMemory[container] += memories[index] * weight
+				usage := types.DailyUsage{
+					Day:             day,
+					End:             time.Unix(r.now-int64(day*24*3600), 0).UTC(),
+					PercentileBytes: memories[index],
+					Weight:          weight,
+					Samples:         len(memories),
+					Hourly:          dayHourly[container],
+				}
+				containerDaily[container] = append(containerDaily[container], usage)
+				if trace != nil {
+					trace.container(container).addDay(usage, index)
 				}
 			}
 		}
+		r.memoryPool.Put(dayMemory)
+	}
+	return containerMemory, containerDaily
+}
 
-		// Store results
-		r.mux.Lock()
-		r.results[deployment] = containerMemory
-		r.mux.Unlock()
+// percentileIndex returns the index of the configured percentile among n sorted samples
+func (r *Recommender) percentileIndex(n int) int {
+	index := int(float64(n) * r.percentile / 100)
+	if index >= n {
+		index = n - 1
+	}
+	return index
+}
 
-		// Log progress
-		for container := range containerMemory {
-			log.Printf("Processed namespace: %s, deployment: %s, container: %s",
-				r.namespace, deployment, container)
-		}
+// hourlyUsage summarises the hourly average usage of each pod of a container
+// over the hour ending at end
+func hourlyUsage(end int64, memories []float64) types.HourlyUsage {
+	usage := types.HourlyUsage{
+		End:      time.Unix(end, 0).UTC(),
+		Replicas: len(memories),
+		MinBytes: memories[0],
+		MaxBytes: memories[0],
+	}
+	var total float64
+	for _, memory := range memories {
+		usage.MinBytes = math.Min(usage.MinBytes, memory)
+		usage.MaxBytes = math.Max(usage.MaxBytes, memory)
+		total += memory
 	}
+	usage.MeanBytes = total / float64(len(memories))
+	return usage
 }
 
-// analyzeHour analyzes memory usage for a specific hour
-func (r *Recommender) analyzeHour(ctx context.Context, deployment string, start, end int64, dayMemory map[string][]float64) error {
+// analyzeHour collects the average memory usage of each pod over a specific
+// hour, per container, recording the ReplicaSets and pods it resolved in
+// trace when not nil
+func (r *Recommender) analyzeHour(ctx context.Context, deployment string, start, end int64, hourMemory map[string][]float64, trace *HourTrace) error {
 	// Get ReplicaSets for this deployment
 	replicaSets, err := r.getReplicaSets(ctx, deployment, start, end)
 	if err != nil {
 		return err
 	}
+	if trace != nil {
+		trace.ReplicaSets = replicaSets
+	}
 	if len(replicaSets) == 0 {
 		return fmt.Errorf("no replicasets found for deployment %s", deployment)
 	}
@@ -223,6 +370,9 @@ func (r *Recommender) analyzeHour(ctx context.Context, deployment string, start,
 	if err != nil {
 		return err
 	}
+	if trace != nil {
+		trace.Pods = pods
+	}
 	if len(pods) == 0 {
 		return fmt.Errorf("no pods found for deployment %s", deployment)
 	}
@@ -233,12 +383,12 @@ func (r *Recommender) analyzeHour(ctx context.Context, deployment string, start,
 		return err
 	}
 
-	// Aggregate memory data
+	// Aggregate memory data, one series per pod
 	for _, result := range memoryData.Data.Result {
-		container := result.Metric["container"]
+		container := result.Metric[r.profile.CadvisorContainerLabel]
 		if memoryStr, ok := result.Value[1].(string); ok {
 			if memory, err := strconv.ParseFloat(memoryStr, 64); err == nil {
-				dayMemory[container] = append(dayMemory[container], memory)
+				hourMemory[container] = append(hourMemory[container], memory)
 			}
 		}
 	}
@@ -248,7 +398,8 @@ func (r *Recommender) analyzeHour(ctx context.Context, deployment string, start,
 
 // getReplicaSets retrieves ReplicaSets owned by a deployment
 func (r *Recommender) getReplicaSets(ctx context.Context, deployment string, start, end int64) ([]string, error) {
-	promql := fmt.Sprintf(`kube_replicaset_owner{namespace="%s", owner_name="%s"}`, r.namespace, deployment)
+	promql := r.selector(r.profile.ReplicaSetOwner,
+		prometheus.Eq(r.profile.OwnerNameLabel, deployment))
 
 	results, err := r.client.QueryRange(ctx, promql, start, end, 60)
 	if err != nil {
@@ -257,7 +408,7 @@ func (r *Recommender) getReplicaSets(ctx context.Context, deployment string, sta
 
 	var replicaSets []string
 	for _, result := range results.Data.Result {
-		if rs, ok := result.Metric["replicaset"]; ok {
+		if rs, ok := result.Metric[r.profile.ReplicaSetLabel]; ok {
 			replicaSets = append(replicaSets, rs)
 		}
 	}
@@ -267,7 +418,8 @@ func (r *Recommender) getReplicaSets(ctx context.Context, deployment string, sta
 
 // getPods retrieves pods owned by ReplicaSets
 func (r *Recommender) getPods(ctx context.Context, replicaSets string, start, end int64) ([]string, error) {
-	promql := fmt.Sprintf(`kube_pod_owner{namespace="%s", owner_name=~"%s"}`, r.namespace, replicaSets)
+	promql := r.selector(r.profile.PodOwner,
+		prometheus.Re(r.profile.OwnerNameLabel, replicaSets))
 
 	results, err := r.client.QueryRange(ctx, promql, start, end, 60)
 	if err != nil {
@@ -276,7 +428,7 @@ func (r *Recommender) getPods(ctx context.Context, replicaSets string, start, en
 
 	var pods []string
 	for _, result := range results.Data.Result {
-		if pod, ok := result.Metric["pod"]; ok {
+		if pod, ok := result.Metric[r.profile.PodLabel]; ok {
 			pods = append(pods, pod)
 		}
 	}
@@ -284,10 +436,16 @@ func (r *Recommender) getPods(ctx context.Context, replicaSets string, start, en
 	return pods, nil
 }
 
-// getPodMemoryUsage retrieves memory usage for pods
+// getPodMemoryUsage retrieves the average memory usage of each container of
+// each pod over the hour ending at queryTime
 func (r *Recommender) getPodMemoryUsage(ctx context.Context, pods string, queryTime int64) (types.Data, error) {
-	promql := fmt.Sprintf(`avg(avg_over_time(container_memory_rss{namespace="%s",container !="",container!="POD", pod=~"%s"}[1h])) by (container)`,
-		r.namespace, pods)
+	containerLabel := r.profile.CadvisorContainerLabel
+	podLabel := r.profile.CadvisorPodLabel
+	selector := r.selector(r.profile.ContainerMemory,
+		prometheus.Neq(containerLabel, ""),
+		prometheus.Neq(containerLabel, "POD"),
+		prometheus.Re(podLabel, pods))
+	promql := fmt.Sprintf(`avg(avg_over_time(%s[1h])) by (%s, %s)`, selector, containerLabel, podLabel)
 
 	return r.client.QueryAtTime(ctx, promql, queryTime)
 }
@@ -297,8 +455,7 @@ func (r *Recommender) getCurrentResourceConfig(ctx context.Context, deployment,
 	config := &ResourceConfig{}
 
 	// Query current memory requests
-	requestPromql := fmt.Sprintf(`kube_pod_container_resource_requests{namespace="%s", container="%s", resource="memory", pod=~"%s-.*"}`,
-		r.namespace, container, deployment)
+	requestPromql := r.resourceSelector(r.profile.MemoryRequests, deployment, container)
 
 	requestData, err := r.client.Query(ctx, requestPromql)
 	if err != nil {
@@ -319,8 +476,7 @@ func (r *Recommender) getCurrentResourceConfig(ctx context.Context, deployment,
 	}
 
 	// Query current memory limits
-	limitPromql := fmt.Sprintf(`kube_pod_container_resource_limits{namespace="%s", container="%s", resource="memory", pod=~"%s-.*"}`,
-		r.namespace, container, deployment)
+	limitPromql := r.resourceSelector(r.profile.MemoryLimits, deployment, container)
 
 	limitData, err := r.client.Query(ctx, limitPromql)
 	if err != nil {
@@ -342,3 +498,20 @@ func (r *Recommender) getCurrentResourceConfig(ctx context.Context, deployment,
 
 	return config, nil
 }
+
+// selector renders a series selector restricted to the analyzed cluster and namespace
+func (r *Recommender) selector(metric string, matchers ...prometheus.Matcher) string {
+	scope := []prometheus.Matcher{
+		prometheus.Eq(r.clusterLabel, r.cluster),
+		prometheus.Eq(r.profile.NamespaceLabel, r.namespace),
+	}
+	return prometheus.Selector(metric, append(scope, matchers...)...)
+}
+
+// resourceSelector selects the memory series of a requests/limits metric for a deployment container
+func (r *Recommender) resourceSelector(metric, deployment, container string) string {
+	return r.selector(metric,
+		prometheus.Eq(r.profile.ContainerLabel, container),
+		prometheus.Eq(r.profile.ResourceLabel, r.profile.ResourceMemory),
+		prometheus.DeploymentPods(r.profile.PodLabel, deployment))
+}
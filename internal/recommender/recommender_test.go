@@ -2,8 +2,14 @@ package recommender
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -440,3 +446,407 @@ func indexOf(s, substr string) int {
 	}
 	return -1
 }
+
+func TestRecommender_LegacyProfileQueries(t *testing.T) {
+	var queries []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"result":[{"metric":{"container_name":"app"},"value":["1234567890","1048576"]}]}}`))
+	}))
+	defer server.Close()
+
+	profile, err := prometheus.LoadProfile(prometheus.ProfileLegacyCadvisor)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	profile.NamespaceLabel = "kubernetes_namespace"
+
+	client := prometheus.NewClient(server.URL, 30*time.Second)
+	config := &types.RecommendationConfig{
+		Namespace:             "test-namespace",
+		MemoryLimitMultiplier: 1.5,
+		CountDays:             7,
+		WorkerCount:           1,
+		Profile:               profile,
+	}
+	recommender := NewRecommender(client, config)
+	ctx := context.Background()
+
+	memoryData, err := recommender.getPodMemoryUsage(ctx, "test-pod", time.Now().Unix())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if memoryData.Data.Result[0].Metric["container_name"] != "app" {
+		t.Errorf("Expected container_name 'app', got '%s'", memoryData.Data.Result[0].Metric["container_name"])
+	}
+
+	if _, err := recommender.getCurrentResourceConfig(ctx, "test-deployment", "app"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(queries) != 3 {
+		t.Fatalf("Expected 3 queries, got %d", len(queries))
+	}
+	if !contains(queries[0], `pod_name=~"test-pod"`) || !contains(queries[0], "by (container_name, pod_name)") {
+		t.Errorf("Expected memory query to use legacy cadvisor labels, got: %s", queries[0])
+	}
+	if !contains(queries[1], "kube_pod_container_resource_requests_memory_bytes") || contains(queries[1], "resource=") {
+		t.Errorf("Expected kube-state-metrics v1 requests query, got: %s", queries[1])
+	}
+	for _, query := range queries {
+		if !contains(query, `kubernetes_namespace="test-namespace"`) {
+			t.Errorf("Expected relabeled namespace filter, got: %s", query)
+		}
+	}
+}
+
+func TestRecommender_ClusterScope(t *testing.T) {
+	var queries []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"result":[]}}`))
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL, 30*time.Second)
+	config := &types.RecommendationConfig{
+		Namespace:             "test-namespace",
+		MemoryLimitMultiplier: 1.5,
+		CountDays:             7,
+		WorkerCount:           1,
+		ClusterLabel:          "cluster",
+		Cluster:               "prod-eu",
+	}
+	recommender := NewRecommender(client, config)
+	ctx := context.Background()
+
+	start := time.Now().Unix() - 3600
+	end := time.Now().Unix()
+
+	recommender.getEligibleDeployments(ctx)
+	recommender.getReplicaSets(ctx, "test-deployment", start, end)
+	recommender.getPods(ctx, "test-deployment-12345", start, end)
+	recommender.getPodMemoryUsage(ctx, "test-pod", end)
+	recommender.getCurrentResourceConfig(ctx, "test-deployment", "app")
+
+	if len(queries) != 6 {
+		t.Fatalf("Expected 6 queries, got %d", len(queries))
+	}
+	for _, query := range queries {
+		if !contains(query, `cluster="prod-eu"`) {
+			t.Errorf("Expected query to contain cluster filter, got: %s", query)
+		}
+	}
+}
+
+func TestRecommender_getPods_ReplicaDeduplication(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := `{
+			"data": {
+				"result": [
+					{"metric": {"pod": "web-12345-abcde", "prometheus_replica": "a"}, "values": [["1234567890", "1"]]},
+					{"metric": {"pod": "web-12345-abcde", "prometheus_replica": "b"}, "values": [["1234567890", "1"]]}
+				]
+			}
+		}`
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	client := prometheus.NewClient(server.URL, 30*time.Second)
+	client.SetReplicaLabel("prometheus_replica")
+	config := &types.RecommendationConfig{
+		Namespace:             "test-namespace",
+		MemoryLimitMultiplier: 1.5,
+		CountDays:             7,
+		WorkerCount:           1,
+	}
+	recommender := NewRecommender(client, config)
+
+	pods, err := recommender.getPods(context.Background(), "web-12345", time.Now().Unix()-3600, time.Now().Unix())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(pods) != 1 || pods[0] != "web-12345-abcde" {
+		t.Errorf("Expected a single deduplicated pod, got %v", pods)
+	}
+}
+
+// newHourlyUsageServer serves one replicaset per deployment and a pod per
+// factor, one pod without factors, the usage of a pod being factor × N MiB
+// for the hour ending N hours before now
+func newHourlyUsageServer(t *testing.T, now int64, factors ...float64) *httptest.Server {
+	if len(factors) == 0 {
+		factors = []float64{1}
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		var response string
+		switch {
+		case contains(query, "kube_replicaset_owner"):
+			response = `{"data": {"result": [{"metric": {"replicaset": "web-12345"}, "values": [["1234567890", "1"]]}]}}`
+		case contains(query, "kube_pod_owner"):
+			response = `{"data": {"result": [{"metric": {"pod": "web-12345-abcde"}, "values": [["1234567890", "1"]]}]}}`
+		case contains(query, "container_memory_rss"):
+			queryTime, err := strconv.ParseInt(r.URL.Query().Get("time"), 10, 64)
+			if err != nil {
+				t.Errorf("Unexpected time parameter: %v", err)
+			}
+			hoursAgo := (now - queryTime) / 3600
+			var series []string
+			for i, factor := range factors {
+				series = append(series, fmt.Sprintf(`{"metric": {"container": "app", "pod": "web-12345-pod%d"}, "value": [1234567890, "%g"]}`,
+					i, factor*float64(hoursAgo*1024*1024)))
+			}
+			response = `{"data": {"result": [` + strings.Join(series, ", ") + `]}}`
+		default:
+			response = `{"data": {"result": []}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(response))
+	}))
+}
+
+func TestRecommender_Percentile(t *testing.T) {
+	tests := []struct {
+		name       string
+		percentile float64
+		expectedMB float64
+	}{
+		{"Default P90", 0, 21},
+		{"P50", 50, 12},
+		{"P100", 100, 23},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := prometheus.NewClient("http://unused", 30*time.Second)
+			recommender := NewRecommender(client, &types.RecommendationConfig{
+				Namespace:   "test-namespace",
+				CountDays:   1,
+				WorkerCount: 1,
+				Percentile:  tt.percentile,
+			})
+			server := newHourlyUsageServer(t, recommender.now)
+			defer server.Close()
+			recommender.client = prometheus.NewClient(server.URL, 30*time.Second)
+
+			recommender.wg.Add(1)
+			recommender.deploymentChan <- "web"
+			close(recommender.deploymentChan)
+			recommender.worker(context.Background())
+
+			// The single analysed day has a weight of 0.5
+			expected := tt.expectedMB * 1024 * 1024 * 0.5
+			if got := recommender.results["web"]["app"]; got != expected {
+				t.Errorf("Expected %.0f bytes, got %.0f", expected, got)
+			}
+		})
+	}
+}
+
+func TestRecommender_DailySeries(t *testing.T) {
+	client := prometheus.NewClient("http://unused", 30*time.Second)
+	recommender := NewRecommender(client, &types.RecommendationConfig{
+		Namespace:   "test-namespace",
+		CountDays:   2,
+		WorkerCount: 1,
+	})
+	// Two pods using half and one and a half times N MiB average to N MiB
+	server := newHourlyUsageServer(t, recommender.now, 0.5, 1.5)
+	defer server.Close()
+	recommender.client = prometheus.NewClient(server.URL, 30*time.Second)
+
+	recommender.wg.Add(1)
+	recommender.deploymentChan <- "web"
+	close(recommender.deploymentChan)
+	recommender.worker(context.Background())
+
+	daily := recommender.daily["web"]["app"]
+	if len(daily) != 2 {
+		t.Fatalf("Expected 2 days, got %d", len(daily))
+	}
+
+	// Day 0 covers 0-23 MiB and day 1 24-47 MiB, the P90 being the 22nd sample
+	const mb = 1024 * 1024
+	expected := []types.DailyUsage{
+		{Day: 0, End: time.Unix(recommender.now, 0).UTC(), PercentileBytes: 21 * mb, Weight: 0.5, Samples: 24},
+		{Day: 1, End: time.Unix(recommender.now-86400, 0).UTC(), PercentileBytes: 45 * mb, Weight: 0.25, Samples: 24},
+	}
+	for i := range expected {
+		day := daily[i]
+		day.Hourly = nil
+		if !reflect.DeepEqual(day, expected[i]) {
+			t.Errorf("Expected day %d to be %+v, got %+v", i, expected[i], day)
+		}
+		if len(daily[i].Hourly) != 24 {
+			t.Errorf("Expected 24 hours on day %d, got %d", i, len(daily[i].Hourly))
+		}
+	}
+
+	// The second hour of day 1 ended 25 hours ago, with pods using 12.5 and 37.5 MiB
+	hour := daily[1].Hourly[1]
+	expectedHour := types.HourlyUsage{
+		End:       time.Unix(recommender.now-25*3600, 0).UTC(),
+		Replicas:  2,
+		MinBytes:  12.5 * mb,
+		MeanBytes: 25 * mb,
+		MaxBytes:  37.5 * mb,
+	}
+	if hour != expectedHour {
+		t.Errorf("Expected hour %+v, got %+v", expectedHour, hour)
+	}
+
+	weighted := 21*mb*0.5 + 45*mb*0.25
+	if got := recommender.results["web"]["app"]; got != weighted {
+		t.Errorf("Expected the weighted sum of the series %.0f, got %.0f", weighted, got)
+	}
+}
+
+func TestRecommender_clampRequest(t *testing.T) {
+	client := prometheus.NewClient("https://prometheus.example.com", 30*time.Second)
+	const mb = 1024 * 1024
+
+	tests := []struct {
+		name     string
+		min, max int64
+		bytes    float64
+		expected float64
+	}{
+		{"Open bounds", 0, 0, 10 * mb, 10 * mb},
+		{"Raised to minimum", 64, 0, 10 * mb, 64 * mb},
+		{"Lowered to maximum", 0, 256, 512 * mb, 256 * mb},
+		{"Within bounds", 64, 256, 128 * mb, 128 * mb},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recommender := NewRecommender(client, &types.RecommendationConfig{
+				MinRequestMB: tt.min,
+				MaxRequestMB: tt.max,
+			})
+			if got := recommender.clampRequest(tt.bytes); got != tt.expected {
+				t.Errorf("Expected %.0f, got %.0f", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRecommender_confidence(t *testing.T) {
+	recommender := NewRecommender(prometheus.NewClient("https://prometheus.example.com", 30*time.Second), &types.RecommendationConfig{
+		Namespace: "test-namespace",
+		CountDays: 3,
+	})
+
+	tests := []struct {
+		name     string
+		days     []types.DailyUsage
+		expected float64
+	}{
+		{"Every day sampled", []types.DailyUsage{{Day: 0, Weight: 0.5}, {Day: 1, Weight: 0.25}, {Day: 2, Weight: 0.125}}, 1},
+		{"Last day missing", []types.DailyUsage{{Day: 1, Weight: 0.25}, {Day: 2, Weight: 0.125}}, 0.375 / 0.875},
+		{"No samples", nil, 0},
+	}
+
+	for _, tt := range tests {
+		if got := recommender.confidence(tt.days); math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("%s: expected confidence %f, got %f", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestRecommender_GenerateRecommendations_Repeated(t *testing.T) {
+	var usageMB int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		var response string
+		switch {
+		case contains(query, "kube_deployment_created"):
+			response = `{"data": {"result": [{"metric": {"deployment": "web"}, "value": [1234567890, "1"]}]}}`
+		case contains(query, "kube_replicaset_owner"):
+			response = `{"data": {"result": [{"metric": {"replicaset": "web-12345"}, "values": [["1234567890", "1"]]}]}}`
+		case contains(query, "kube_pod_owner"):
+			response = `{"data": {"result": [{"metric": {"pod": "web-12345-abcde"}, "values": [["1234567890", "1"]]}]}}`
+		case contains(query, "container_memory_rss"):
+			response = fmt.Sprintf(`{"data": {"result": [{"metric": {"container": "app"}, "value": [1234567890, "%d"]}]}}`, usageMB*1024*1024)
+		default:
+			response = `{"data": {"result": []}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	recommender := NewRecommender(prometheus.NewClient(server.URL, 30*time.Second), &types.RecommendationConfig{
+		Namespace:             "test-namespace",
+		CountDays:             1,
+		WorkerCount:           2,
+		MemoryLimitMultiplier: 1.5,
+	})
+
+	// Each run starts afresh, the day weight of 0.5 halving the usage
+	for _, tt := range []struct{ usageMB, expectedMB int64 }{{100, 50}, {200, 100}} {
+		usageMB = tt.usageMB
+		recommendations, err := recommender.GenerateRecommendations(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(recommendations) != 1 {
+			t.Fatalf("Expected 1 recommendation, got %d", len(recommendations))
+		}
+		if got := recommendations[0].RecommendedRequestMB; got != tt.expectedMB {
+			t.Errorf("Expected a request of %d MB for a usage of %d MB, got %d", tt.expectedMB, tt.usageMB, got)
+		}
+		if len(recommendations[0].Daily) != 1 {
+			t.Errorf("Expected the daily series of the run only, got %d days", len(recommendations[0].Daily))
+		}
+	}
+}
+
+func TestRecommender_getCurrentResourceConfig_PrefixedDeployment(t *testing.T) {
+	// The pods of web-api come first and share the prefix of those of web
+	pods := []struct {
+		name  string
+		bytes string
+	}{
+		{"web-api-6b7c8d9f4-aaaaa", "2147483648"},
+		{"web-5d8f7c9b6-bbbbb", "536870912"},
+	}
+	podPattern := regexp.MustCompile(`pod=~"([^"]*)"`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		match := podPattern.FindStringSubmatch(r.URL.Query().Get("query"))
+		if match == nil {
+			t.Errorf("Expected a pod matcher in %s", r.URL.Query().Get("query"))
+			return
+		}
+		selected := regexp.MustCompile("^(?:" + strings.ReplaceAll(match[1], `\\`, `\`) + ")$")
+		results := []string{}
+		for _, pod := range pods {
+			if selected.MatchString(pod.name) {
+				results = append(results, `{"metric": {"pod": "`+pod.name+`"}, "value": [1, "`+pod.bytes+`"]}`)
+			}
+		}
+		w.Write([]byte(`{"data": {"result": [` + strings.Join(results, ",") + `]}}`))
+	}))
+	defer server.Close()
+
+	recommender := NewRecommender(prometheus.NewClient(server.URL, 30*time.Second), &types.RecommendationConfig{Namespace: "shop"})
+	resourceConfig, err := recommender.getCurrentResourceConfig(context.Background(), "web", "app")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resourceConfig.RequestBytes != 536870912 || resourceConfig.LimitBytes != 536870912 {
+		t.Errorf("Expected the request and limit of web, got %+v", resourceConfig)
+	}
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"kubernetes-resources-recommend/internal/exporter"
+	"kubernetes-resources-recommend/internal/types"
+	"kubernetes-resources-recommend/pkg/config"
+)
+
+// GenerateFunc generates the recommendations of a namespace
+type GenerateFunc func(ctx context.Context, namespace string) ([]types.RecommendationResult, error)
+
+// Result is the cached outcome of a recommendation run
+type Result struct {
+	Namespace       string                       `json:"namespace"`
+	GeneratedAt     time.Time                    `json:"generated_at"`
+	Recommendations []types.RecommendationResult `json:"recommendations"`
+}
+
+// namespaceStatus is the entry of a cached namespace in the namespace list
+type namespaceStatus struct {
+	Namespace       string    `json:"namespace"`
+	GeneratedAt     time.Time `json:"generated_at"`
+	Recommendations int       `json:"recommendations"`
+}
+
+// flight is a recommendation run in progress, shared by concurrent requests
+type flight struct {
+	done   chan struct{}
+	result *Result
+	err    error
+}
+
+// Server serves the recommendations of namespaces over HTTP, generating them
+// on the first request of a namespace and caching them until refreshed
+type Server struct {
+	generate GenerateFunc
+	now      func() time.Time
+
+	// ctx is the context of the recommendation runs, namespaces the
+	// namespaces served, nil to serve any
+	ctx        context.Context
+	namespaces map[string]bool
+
+	mu       sync.Mutex
+	cache    map[string]*Result
+	inFlight map[string]*flight
+}
+
+// New creates a new server generating recommendations with generate
+func New(generate GenerateFunc) *Server {
+	return &Server{
+		generate: generate,
+		now:      time.Now,
+		ctx:      context.Background(),
+		cache:    make(map[string]*Result),
+		inFlight: make(map[string]*flight),
+	}
+}
+
+// SetContext sets the context recommendation runs are generated on, cancelling
+// the runs in progress when it is done. Runs do not depend on the request that
+// started them, which other requests of the namespace may be waiting for.
+func (s *Server) SetContext(ctx context.Context) {
+	s.ctx = ctx
+}
+
+// SetNamespaces restricts the namespaces served to namespaces, the others
+// being answered with 404 instead of starting a recommendation run
+func (s *Server) SetNamespaces(namespaces []string) {
+	s.namespaces = make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		s.namespaces[namespace] = true
+	}
+}
+
+// Handler returns the HTTP handler of the REST API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /namespaces", s.handleNamespaces)
+	mux.HandleFunc("GET /namespaces/{namespace}/recommendations", s.handleRecommendations)
+	mux.HandleFunc("GET /namespaces/{namespace}/workloads/{name}", s.handleWorkload)
+	mux.HandleFunc("POST /namespaces/{namespace}/refresh", s.handleRefresh)
+	return mux
+}
+
+// Refresh generates the recommendations of namespace and replaces its cached
+// result. Concurrent refreshes of a namespace share a single run, which goes
+// on when ctx is done: ctx only bounds the wait for its outcome.
+func (s *Server) Refresh(ctx context.Context, namespace string) (*Result, error) {
+	s.mu.Lock()
+	f, ok := s.inFlight[namespace]
+	if !ok {
+		f = &flight{done: make(chan struct{})}
+		s.inFlight[namespace] = f
+		go s.run(namespace, f)
+	}
+	s.mu.Unlock()
+	return f.wait(ctx)
+}
+
+// run generates the recommendations of namespace on the server context,
+// caching them on success, and completes f
+func (s *Server) run(namespace string, f *flight) {
+	recommendations, err := s.generate(s.ctx, namespace)
+	if recommendations == nil {
+		recommendations = []types.RecommendationResult{}
+	}
+	if err == nil {
+		f.result = &Result{Namespace: namespace, GeneratedAt: s.now().UTC(), Recommendations: recommendations}
+	}
+	f.err = err
+
+	s.mu.Lock()
+	if err == nil {
+		s.cache[namespace] = f.result
+	}
+	delete(s.inFlight, namespace)
+	s.mu.Unlock()
+	close(f.done)
+}
+
+// Schedule refreshes namespaces every interval until ctx is done, starting
+// immediately. A failed refresh keeps the previous result cached.
+func (s *Server) Schedule(ctx context.Context, namespaces []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, namespace := range namespaces {
+			if _, err := s.Refresh(ctx, namespace); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to refresh recommendations of %s: %v", namespace, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// result returns the cached result of namespace, generating it when missing
+func (s *Server) result(ctx context.Context, namespace string) (*Result, error) {
+	s.mu.Lock()
+	result, ok := s.cache[namespace]
+	s.mu.Unlock()
+	if ok {
+		return result, nil
+	}
+	return s.Refresh(ctx, namespace)
+}
+
+// wait returns the outcome of the run, or the error of ctx if it ends first
+func (f *flight) wait(ctx context.Context) (*Result, error) {
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleMetrics publishes the cached recommendations of every namespace as
+// Prometheus gauges, with the time each namespace was last generated
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	results := make([]*Result, 0, len(s.cache))
+	for _, result := range s.cache {
+		results = append(results, result)
+	}
+	s.mu.Unlock()
+	sort.Slice(results, func(i, j int) bool { return results[i].Namespace < results[j].Namespace })
+
+	var metrics bytes.Buffer
+	var recommendations []types.RecommendationResult
+	metrics.WriteString("# HELP resources_recommend_generated_timestamp_seconds Time the recommendations of the namespace were generated.\n")
+	metrics.WriteString("# TYPE resources_recommend_generated_timestamp_seconds gauge\n")
+	for _, result := range results {
+		fmt.Fprintf(&metrics, "resources_recommend_generated_timestamp_seconds{namespace=%q} %d\n", result.Namespace, result.GeneratedAt.Unix())
+		recommendations = append(recommendations, result.Recommendations...)
+	}
+	if err := exporter.WriteMetrics(&metrics, recommendations); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", exporter.MetricsMediaType)
+	w.Write(metrics.Bytes())
+}
+
+func (s *Server) handleNamespaces(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	statuses := make([]namespaceStatus, 0, len(s.cache))
+	for _, result := range s.cache {
+		statuses = append(statuses, namespaceStatus{
+			Namespace:       result.Namespace,
+			GeneratedAt:     result.GeneratedAt,
+			Recommendations: len(result.Recommendations),
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Namespace < statuses[j].Namespace })
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// handleRecommendations serves the recommendations of a namespace as JSON,
+// or as a report download when the format query parameter is set
+func (s *Server) handleRecommendations(w http.ResponseWriter, r *http.Request) {
+	namespace, ok := s.pathNamespace(w, r)
+	if !ok {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" {
+		var err error
+		if format, err = exporter.NormalizeFormat(format); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	result, err := s.result(r.Context(), namespace)
+	if err != nil {
+		writeGenerateError(w, namespace, err)
+		return
+	}
+
+	if format == "" {
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+	writeReport(w, result, format)
+}
+
+func (s *Server) handleWorkload(w http.ResponseWriter, r *http.Request) {
+	namespace, ok := s.pathNamespace(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("name")
+
+	result, err := s.result(r.Context(), namespace)
+	if err != nil {
+		writeGenerateError(w, namespace, err)
+		return
+	}
+
+	workload := &Result{Namespace: namespace, GeneratedAt: result.GeneratedAt}
+	for _, rec := range result.Recommendations {
+		if rec.Deployment == name {
+			workload.Recommendations = append(workload.Recommendations, rec)
+		}
+	}
+	if len(workload.Recommendations) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no recommendations for workload %s in namespace %s", name, namespace))
+		return
+	}
+	writeJSON(w, http.StatusOK, workload)
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	namespace, ok := s.pathNamespace(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := s.Refresh(r.Context(), namespace)
+	if err != nil {
+		writeGenerateError(w, namespace, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// pathNamespace returns the namespace of the request path, answering 400 when
+// it is not a valid namespace name and 404 when it is not served
+func (s *Server) pathNamespace(w http.ResponseWriter, r *http.Request) (string, bool) {
+	namespace := r.PathValue("namespace")
+	if !config.ValidNamespace(namespace) {
+		writeError(w, http.StatusBadRequest, &config.InvalidNamespaceError{Namespace: namespace})
+		return "", false
+	}
+	if s.namespaces != nil && !s.namespaces[namespace] {
+		writeError(w, http.StatusNotFound, fmt.Errorf("namespace %s is not served", namespace))
+		return "", false
+	}
+	return namespace, true
+}
+
+// writeReport writes the recommendations of result as a report download in format
+func writeReport(w http.ResponseWriter, result *Result, format string) {
+	var report bytes.Buffer
+	reportExporter, err := exporter.New(format, exporter.Stdout, &report)
+	if err == nil {
+		err = reportExporter.Export(result.Recommendations)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to export recommendations: %w", err))
+		return
+	}
+
+	filename := fmt.Sprintf("%s-resource-recommend.%s", result.Namespace, exporter.Extension(format))
+	w.Header().Set("Content-Type", exporter.MediaType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Last-Modified", result.GeneratedAt.Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+	w.Write(report.Bytes())
+}
+
+// writeGenerateError reports a failed recommendation run
+func writeGenerateError(w http.ResponseWriter, namespace string, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	log.Printf("Failed to generate recommendations of %s: %v", namespace, err)
+	writeError(w, http.StatusBadGateway, fmt.Errorf("failed to generate recommendations of %s: %w", namespace, err))
+}
+
+// writeError writes err as a JSON error body with status
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeJSON writes value as an indented JSON body with status
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
@@ -0,0 +1,347 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/types"
+)
+
+func sampleRecommendations(namespace string) []types.RecommendationResult {
+	return []types.RecommendationResult{
+		{Namespace: namespace, Deployment: "web-app", Container: "nginx", CurrentRequestMB: 512, RecommendedRequestMB: 256, RecommendedLimitMB: 384, MemoryLimitMultiplier: 1.5},
+		{Namespace: namespace, Deployment: "web-app", Container: "sidecar", CurrentRequestMB: 128, RecommendedRequestMB: 64, RecommendedLimitMB: 96, MemoryLimitMultiplier: 1.5},
+		{Namespace: namespace, Deployment: "worker", Container: "app", CurrentRequestMB: 1024, RecommendedRequestMB: 768, RecommendedLimitMB: 1152, MemoryLimitMultiplier: 1.5},
+	}
+}
+
+// newTestServer returns a server counting its runs, generating the sample
+// recommendations or failing for the namespace "broken"
+func newTestServer() (*Server, *int32) {
+	var runs int32
+	s := New(func(_ context.Context, namespace string) ([]types.RecommendationResult, error) {
+		atomic.AddInt32(&runs, 1)
+		if namespace == "broken" {
+			return nil, errors.New("prometheus unreachable")
+		}
+		return sampleRecommendations(namespace), nil
+	})
+	s.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	return s, &runs
+}
+
+func serve(t *testing.T, s *Server, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	s.Handler().ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+	return recorder
+}
+
+func TestServer_Recommendations(t *testing.T) {
+	s, runs := newTestServer()
+
+	response := serve(t, s, http.MethodGet, "/namespaces/shop/recommendations")
+	if response.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", response.Code, response.Body)
+	}
+	var result Result
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if result.Namespace != "shop" || len(result.Recommendations) != 3 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if !result.GeneratedAt.Equal(s.now()) {
+		t.Errorf("Expected generation time %v, got %v", s.now(), result.GeneratedAt)
+	}
+
+	// The second request is served from the cache
+	serve(t, s, http.MethodGet, "/namespaces/shop/recommendations")
+	if *runs != 1 {
+		t.Errorf("Expected 1 run, got %d", *runs)
+	}
+
+	// A refresh runs again
+	if response := serve(t, s, http.MethodPost, "/namespaces/shop/refresh"); response.Code != http.StatusOK {
+		t.Errorf("Expected status 200 on refresh, got %d", response.Code)
+	}
+	if *runs != 2 {
+		t.Errorf("Expected 2 runs after refresh, got %d", *runs)
+	}
+}
+
+func TestServer_Workload(t *testing.T) {
+	s, _ := newTestServer()
+
+	response := serve(t, s, http.MethodGet, "/namespaces/shop/workloads/web-app")
+	if response.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", response.Code, response.Body)
+	}
+	var result Result
+	if err := json.Unmarshal(response.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(result.Recommendations) != 2 {
+		t.Errorf("Expected the 2 containers of web-app, got %d", len(result.Recommendations))
+	}
+
+	response = serve(t, s, http.MethodGet, "/namespaces/shop/workloads/missing")
+	if response.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", response.Code)
+	}
+}
+
+func TestServer_Download(t *testing.T) {
+	s, _ := newTestServer()
+
+	tests := []struct {
+		format      string
+		contentType string
+		filename    string
+		contains    string
+	}{
+		{"csv", "text/csv; charset=utf-8", "shop-resource-recommend.csv", "web-app"},
+		{"patches", "application/yaml", "shop-resource-recommend.patch.yaml", "kind: Deployment"},
+		{"xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "shop-resource-recommend.xlsx", "PK"},
+	}
+
+	for _, tt := range tests {
+		response := serve(t, s, http.MethodGet, "/namespaces/shop/recommendations?format="+tt.format)
+		if response.Code != http.StatusOK {
+			t.Errorf("Expected status 200 for %s, got %d: %s", tt.format, response.Code, response.Body)
+			continue
+		}
+		if contentType := response.Header().Get("Content-Type"); contentType != tt.contentType {
+			t.Errorf("Expected content type %s for %s, got %s", tt.contentType, tt.format, contentType)
+		}
+		if disposition := response.Header().Get("Content-Disposition"); !strings.Contains(disposition, tt.filename) {
+			t.Errorf("Expected attachment %s for %s, got %s", tt.filename, tt.format, disposition)
+		}
+		if !strings.Contains(response.Body.String(), tt.contains) {
+			t.Errorf("Expected %s body to contain %q", tt.format, tt.contains)
+		}
+	}
+
+	if response := serve(t, s, http.MethodGet, "/namespaces/shop/recommendations?format=pdf"); response.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown format, got %d", response.Code)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	s, runs := newTestServer()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		error  string
+	}{
+		{"Invalid namespace", http.MethodGet, "/namespaces/Shop_1/recommendations", http.StatusBadRequest, `invalid namespace "Shop_1"`},
+		{"Generation failure", http.MethodGet, "/namespaces/broken/recommendations", http.StatusBadGateway, "prometheus unreachable"},
+		{"Refresh failure", http.MethodPost, "/namespaces/broken/refresh", http.StatusBadGateway, "prometheus unreachable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := serve(t, s, tt.method, tt.target)
+			if response.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, response.Code)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Invalid JSON: %v", err)
+			}
+			if !strings.Contains(body["error"], tt.error) {
+				t.Errorf("Expected error containing %q, got %q", tt.error, body["error"])
+			}
+		})
+	}
+
+	// Failures are not cached
+	if *runs != 2 {
+		t.Errorf("Expected 2 runs, got %d", *runs)
+	}
+	if response := serve(t, s, http.MethodGet, "/namespaces"); strings.TrimSpace(response.Body.String()) != "[]" {
+		t.Errorf("Expected no cached namespace, got %s", response.Body)
+	}
+}
+
+func TestServer_HealthAndNamespaces(t *testing.T) {
+	s, _ := newTestServer()
+
+	if response := serve(t, s, http.MethodGet, "/healthz"); response.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", response.Code)
+	}
+
+	serve(t, s, http.MethodGet, "/namespaces/shop/recommendations")
+	serve(t, s, http.MethodGet, "/namespaces/billing/recommendations")
+
+	var statuses []namespaceStatus
+	response := serve(t, s, http.MethodGet, "/namespaces")
+	if err := json.Unmarshal(response.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].Namespace != "billing" || statuses[1].Namespace != "shop" {
+		t.Errorf("Unexpected namespaces: %+v", statuses)
+	}
+	if statuses[1].Recommendations != 3 {
+		t.Errorf("Expected 3 recommendations for shop, got %d", statuses[1].Recommendations)
+	}
+}
+
+func TestServer_Refresh_SharesConcurrentRuns(t *testing.T) {
+	release := make(chan struct{})
+	var runs int32
+	s := New(func(_ context.Context, namespace string) ([]types.RecommendationResult, error) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return sampleRecommendations(namespace), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Refresh(context.Background(), "shop"); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	// Let every caller join the run before it completes
+	for {
+		s.mu.Lock()
+		_, running := s.inFlight["shop"]
+		s.mu.Unlock()
+		if running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if runs != 1 {
+		t.Errorf("Expected concurrent refreshes to share 1 run, got %d", runs)
+	}
+}
+
+func TestServer_Refresh_OutlivesCancelledRequest(t *testing.T) {
+	release := make(chan struct{})
+	var runs int32
+	s := New(func(ctx context.Context, namespace string) ([]types.RecommendationResult, error) {
+		atomic.AddInt32(&runs, 1)
+		select {
+		case <-release:
+			return sampleRecommendations(namespace), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	// The request starting the run goes away before it completes
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := s.Refresh(ctx, "shop")
+		cancelled <- err
+	}()
+	for {
+		s.mu.Lock()
+		_, running := s.inFlight["shop"]
+		s.mu.Unlock()
+		if running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled request to stop waiting, got %v", err)
+	}
+
+	// Another request of the namespace still gets the shared run
+	close(release)
+	result, err := s.Refresh(context.Background(), "shop")
+	if err != nil || len(result.Recommendations) != 3 {
+		t.Fatalf("Expected the run to complete, got %+v, %v", result, err)
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("Expected a single run, got %d", n)
+	}
+}
+
+func TestServer_SetNamespaces(t *testing.T) {
+	s, runs := newTestServer()
+	s.SetNamespaces([]string{"shop"})
+
+	if response := serve(t, s, http.MethodGet, "/namespaces/shop/recommendations"); response.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for a served namespace, got %d", response.Code)
+	}
+	for _, target := range []string{"/namespaces/billing/recommendations", "/namespaces/billing/workloads/web-app"} {
+		if response := serve(t, s, http.MethodGet, target); response.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", target, response.Code)
+		}
+	}
+	if response := serve(t, s, http.MethodPost, "/namespaces/billing/refresh"); response.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a refresh, got %d", response.Code)
+	}
+	if n := atomic.LoadInt32(runs); n != 1 {
+		t.Errorf("Expected only the served namespace to run, got %d runs", n)
+	}
+}
+
+func TestServer_Schedule(t *testing.T) {
+	s, runs := newTestServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Schedule(ctx, []string{"shop", "billing"}, time.Hour)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(runs) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if response := serve(t, s, http.MethodGet, "/namespaces/billing/recommendations"); response.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", response.Code)
+	}
+	if n := atomic.LoadInt32(runs); n != 2 {
+		t.Errorf("Expected the scheduled runs to fill the cache, got %d runs", n)
+	}
+}
+
+func TestServer_Metrics(t *testing.T) {
+	s, _ := newTestServer()
+	serve(t, s, http.MethodGet, "/namespaces/shop/recommendations")
+
+	response := serve(t, s, http.MethodGet, "/metrics")
+	if response.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", response.Code)
+	}
+	if contentType := response.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %s", contentType)
+	}
+	for _, line := range []string{
+		`resources_recommend_generated_timestamp_seconds{namespace="shop"} 1709294400`,
+		`resources_recommend_confidence_ratio{namespace="shop",workload="worker",container="app"} 0`,
+	} {
+		if !strings.Contains(response.Body.String(), line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, response.Body)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package types
+
+// MetricsProfile maps the metric and label names the recommender queries
+// to the names exposed by a particular Prometheus setup
+type MetricsProfile struct {
+	Name string `json:"name"`
+
+	// Metric names
+	ContainerMemory    string `json:"container_memory"`
+	PodOwner           string `json:"pod_owner"`
+	ReplicaSetOwner    string `json:"replicaset_owner"`
+	DeploymentCreated  string `json:"deployment_created"`
+	DeploymentReplicas string `json:"deployment_replicas"`
+	MemoryRequests     string `json:"memory_requests"`
+	MemoryLimits       string `json:"memory_limits"`
+
+	// Metrics compared by the verify command before and after a change
+	ContainerOOMEvents string `json:"container_oom_events"`
+	ContainerRestarts  string `json:"container_restarts"`
+	PodStatusReason    string `json:"pod_status_reason"`
+
+	// Metrics placing pods on node pools, for the node pool prices
+	PodInfo    string `json:"pod_info"`
+	NodeLabels string `json:"node_labels"`
+
+	// Metrics of the node capacities and running pods re-packed by the bin-packing simulation
+	NodeAllocatable string `json:"node_allocatable"`
+	PodStatusPhase  string `json:"pod_status_phase"`
+
+	// ResourceLabel and ResourceMemory select the memory series of the
+	// requests/limits metrics. An empty ResourceLabel means the metrics are
+	// already memory specific, as in kube-state-metrics v1.
+	ResourceLabel  string `json:"resource_label"`
+	ResourceMemory string `json:"resource_memory"`
+
+	// NamespaceLabel is shared by cadvisor and kube-state-metrics series
+	NamespaceLabel string `json:"namespace_label"`
+
+	// cadvisor label names
+	CadvisorContainerLabel string `json:"cadvisor_container_label"`
+	CadvisorPodLabel       string `json:"cadvisor_pod_label"`
+
+	// kube-state-metrics label names
+	ContainerLabel  string `json:"container_label"`
+	PodLabel        string `json:"pod_label"`
+	OwnerNameLabel  string `json:"owner_name_label"`
+	ReplicaSetLabel string `json:"replicaset_label"`
+	DeploymentLabel string `json:"deployment_label"`
+	ReasonLabel     string `json:"reason_label"`
+	NodeLabel       string `json:"node_label"`
+	OwnerKindLabel  string `json:"owner_kind_label"`
+	PhaseLabel      string `json:"phase_label"`
+}
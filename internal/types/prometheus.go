@@ -4,6 +4,7 @@ package types
 type Result struct {
 	Metric map[string]string `json:"metric"`
 	Value  []interface{}     `json:"value"`
+	Values [][]interface{}   `json:"values,omitempty"` // samples of a range query
 }
 
 // Results represents a collection of metric results
@@ -1,7 +1,10 @@
 package types
 
+import "time"
+
 // RecommendationResult represents the memory recommendation for a container
 type RecommendationResult struct {
+	Cluster    string `json:"cluster,omitempty"`
 	Namespace  string `json:"namespace"`
 	Deployment string `json:"deployment"`
 	Container  string `json:"container"`
@@ -26,6 +29,119 @@ type RecommendationResult struct {
 
 	// Configuration
 	MemoryLimitMultiplier float64 `json:"memory_limit_multiplier"`
+
+	// Confidence is the share of the decay-weighted analysis window backed by
+	// usage samples, from 0 to 1; days without samples lower the recommendation
+	Confidence float64 `json:"confidence"`
+
+	// Daily is the usage of each analysed day behind the recommendation, most
+	// recent first; days without samples are left out
+	Daily []DailyUsage `json:"daily,omitempty"`
+
+	// Backtest replays a past window of usage against the recommendation, nil when not run
+	Backtest *Backtest `json:"backtest,omitempty"`
+
+	// Verification compares the windows before and after the recommendation
+	// was applied, nil when not verified
+	Verification *Verification `json:"verification,omitempty"`
+
+	// VerificationOnly marks a container verified without a recommendation,
+	// whose current and recommended requests are unknown rather than 0
+	VerificationOnly bool `json:"verification_only,omitempty"`
+
+	// Cost prices the current and recommended requests, nil when no price is configured
+	Cost *Cost `json:"cost,omitempty"`
+}
+
+// Cost is the price of the memory requests of a container across the replicas of its deployment
+type Cost struct {
+	Currency        string  `json:"currency"`
+	PricePerGiBHour float64 `json:"price_per_gib_hour"`
+	PriceSource     string  `json:"price_source"` // namespace, node pool, cluster, api or default
+	NodePool        string  `json:"node_pool,omitempty"`
+	Replicas        int     `json:"replicas"`
+
+	CurrentMonthly     float64 `json:"current_monthly"`
+	RecommendedMonthly float64 `json:"recommended_monthly"`
+	AnnualSavings      float64 `json:"annual_savings"` // negative when the recommendation costs more
+}
+
+// Backtest counts the pods, minutes and peaks of a past window of usage that
+// would have exceeded the recommended request or limit
+type Backtest struct {
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Resolution int       `json:"resolution_seconds"` // width of each replayed sample
+
+	Pods               int     `json:"pods"`
+	PodsOverRequest    int     `json:"pods_over_request"`
+	MinutesOverRequest float64 `json:"minutes_over_request"`
+	PodsOverLimit      int     `json:"pods_over_limit"`
+	MinutesOverLimit   float64 `json:"minutes_over_limit"`
+	PeakBytes          float64 `json:"peak_bytes"`
+
+	// Peaks are the highest usage of each pod above the request, highest first
+	Peaks []BacktestPeak `json:"peaks,omitempty"`
+}
+
+// BacktestPeak is the highest usage of a pod that exceeded the recommended request
+type BacktestPeak struct {
+	Pod       string    `json:"pod"`
+	Time      time.Time `json:"time"`
+	Bytes     float64   `json:"bytes"`
+	OverLimit bool      `json:"over_limit"`
+}
+
+// Verification compares the usage and failures of a container over equal
+// windows before and after a change
+type Verification struct {
+	ChangedAt time.Time          `json:"changed_at"`
+	Window    int                `json:"window_seconds"` // length of each window
+	Before    VerificationWindow `json:"before"`
+	After     VerificationWindow `json:"after"`
+
+	// Evictions counts the evicted pods of the deployment, set on its first
+	// container only so that each eviction is reported once
+	Evictions *VerificationEvictions `json:"evictions,omitempty"`
+
+	// Regressions describes what got worse after the change, empty when it was safe
+	Regressions []string `json:"regressions"`
+}
+
+// VerificationWindow is the usage percentile and failure counts of a container over one window
+type VerificationWindow struct {
+	UsageBytes float64 `json:"usage_bytes"`
+	OOMKills   int     `json:"oom_kills"`
+	Restarts   int     `json:"restarts"`
+}
+
+// VerificationEvictions is the number of evicted pods of a deployment in the
+// windows before and after a change
+type VerificationEvictions struct {
+	Before int `json:"before"`
+	After  int `json:"after"`
+}
+
+// DailyUsage is the memory usage of a container over one analysed day
+type DailyUsage struct {
+	Day             int       `json:"day"` // days before the analysis, 0 for the last 24 hours
+	End             time.Time `json:"end"` // end of the 24-hour window
+	PercentileBytes float64   `json:"percentile_bytes"`
+	Weight          float64   `json:"weight"`
+	Samples         int       `json:"samples"`
+
+	// Hourly summarises the samples of each hour, most recent first
+	Hourly []HourlyUsage `json:"hourly,omitempty"`
+}
+
+// HourlyUsage is the memory usage of a container over one hour, across the
+// hourly average usage of each of its pods
+type HourlyUsage struct {
+	End       time.Time `json:"end"`
+	Replicas  int       `json:"replicas"` // pods with usage during the hour
+	MinBytes  float64   `json:"min_bytes"`
+	MeanBytes float64   `json:"mean_bytes"`
+	MaxBytes  float64   `json:"max_bytes"`
 }
 
 // RecommendationConfig holds configuration for the recommendation algorithm
@@ -35,4 +151,18 @@ type RecommendationConfig struct {
 	MemoryLimitMultiplier float64 `json:"memory_limit_multiplier"`
 	CountDays             int     `json:"count_days"`
 	WorkerCount           int     `json:"worker_count"`
+
+	// ClusterLabel and Cluster restrict every query to one cluster of a
+	// shared Prometheus/Thanos; an empty ClusterLabel disables the filter
+	ClusterLabel string `json:"cluster_label,omitempty"`
+	Cluster      string `json:"cluster,omitempty"`
+
+	// Percentile of the hourly usage taken per day; 0 selects the P90.
+	// The recommended request is clamped to [MinRequestMB, MaxRequestMB], 0 leaving a bound open.
+	Percentile   float64 `json:"percentile,omitempty"`
+	MinRequestMB int64   `json:"min_request_mb,omitempty"`
+	MaxRequestMB int64   `json:"max_request_mb,omitempty"`
+
+	// Profile maps metric and label names; nil selects the kube-state-metrics v2 defaults
+	Profile *MetricsProfile `json:"profile,omitempty"`
 }
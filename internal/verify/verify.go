@@ -0,0 +1,256 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+// DefaultWindow is the length of the windows compared before and after a change
+const DefaultWindow = 7 * 24 * time.Hour
+
+// defaultPercentile is the usage percentile compared when none is configured
+const defaultPercentile = 90
+
+// evictedReason is the pod status reason of an evicted pod
+const evictedReason = "Evicted"
+
+// Verifier compares the memory usage and failures of the containers of
+// deployments over equal windows before and after a change
+type Verifier struct {
+	client       *prometheus.Client
+	profile      *types.MetricsProfile
+	namespace    string
+	clusterLabel string
+	cluster      string
+	window       time.Duration
+	percentile   float64
+
+	now func() time.Time
+}
+
+// New creates a verifier of the deployments of namespace comparing windows of
+// DefaultWindow at the P90
+func New(client *prometheus.Client, profile *types.MetricsProfile, namespace string) *Verifier {
+	return &Verifier{
+		client:     client,
+		profile:    profile,
+		namespace:  namespace,
+		window:     DefaultWindow,
+		percentile: defaultPercentile,
+		now:        time.Now,
+	}
+}
+
+// SetCluster restricts the queries to one cluster of a shared Prometheus/Thanos
+func (v *Verifier) SetCluster(clusterLabel, cluster string) {
+	v.clusterLabel = clusterLabel
+	v.cluster = cluster
+}
+
+// SetWindow sets the length of the windows compared before and after a change
+func (v *Verifier) SetWindow(window time.Duration) {
+	v.window = window
+}
+
+// SetPercentile sets the usage percentile compared, 0 selecting the P90
+func (v *Verifier) SetPercentile(percentile float64) {
+	if percentile <= 0 {
+		percentile = defaultPercentile
+	}
+	v.percentile = percentile
+}
+
+// Verify compares the containers of deployment before and after changedAt,
+// keyed by container. The window after the change is cut at the time of the
+// call, and the window before shortened to the same length.
+func (v *Verifier) Verify(ctx context.Context, changedAt time.Time, deployment string) (map[string]*types.Verification, error) {
+	window := v.window
+	if elapsed := v.now().Sub(changedAt); elapsed < window {
+		window = elapsed.Truncate(time.Second)
+	}
+	if window < time.Minute {
+		return nil, fmt.Errorf("the change at %s leaves less than a minute to compare", changedAt.Format(time.RFC3339))
+	}
+
+	before, evictedBefore, err := v.measure(ctx, deployment, changedAt, window)
+	if err != nil {
+		return nil, err
+	}
+	after, evictedAfter, err := v.measure(ctx, deployment, changedAt.Add(window), window)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []string
+	verifications := make(map[string]*types.Verification)
+	for _, windows := range []map[string]types.VerificationWindow{before, after} {
+		for container := range windows {
+			if _, ok := verifications[container]; !ok {
+				containers = append(containers, container)
+			}
+			verifications[container] = &types.Verification{
+				ChangedAt: changedAt.UTC(),
+				Window:    int(window / time.Second),
+				Before:    before[container],
+				After:     after[container],
+			}
+		}
+	}
+
+	// Evictions remove whole pods, so the deployment reports them once
+	if len(containers) > 0 {
+		sort.Strings(containers)
+		verifications[containers[0]].Evictions = &types.VerificationEvictions{Before: evictedBefore, After: evictedAfter}
+	}
+	for _, verification := range verifications {
+		verification.Regressions = regressions(verification)
+	}
+	return verifications, nil
+}
+
+// measure returns the usage and failures of each container of deployment, and
+// the number of its evicted pods, over the window ending at end
+func (v *Verifier) measure(ctx context.Context, deployment string, end time.Time, window time.Duration) (map[string]types.VerificationWindow, int, error) {
+	p := v.profile
+	rangeSelector := func(selector string) string {
+		return fmt.Sprintf("%s[%ds]", selector, int64(window/time.Second))
+	}
+	cadvisor := func(metric string) string {
+		return v.selector(metric,
+			prometheus.Neq(p.CadvisorContainerLabel, ""),
+			prometheus.Neq(p.CadvisorContainerLabel, "POD"),
+			prometheus.DeploymentPods(p.CadvisorPodLabel, deployment))
+	}
+	pods := prometheus.DeploymentPods(p.PodLabel, deployment)
+
+	usage, err := v.byLabel(ctx, fmt.Sprintf(`max by (%s) (quantile_over_time(%g, %s))`,
+		p.CadvisorContainerLabel, v.percentile/100, rangeSelector(cadvisor(p.ContainerMemory))), p.CadvisorContainerLabel, end)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query the usage of %s: %w", deployment, err)
+	}
+	oomKills, err := v.byLabel(ctx, fmt.Sprintf(`sum by (%s) (increase(%s))`,
+		p.CadvisorContainerLabel, rangeSelector(cadvisor(p.ContainerOOMEvents))), p.CadvisorContainerLabel, end)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query the OOM kills of %s: %w", deployment, err)
+	}
+	restarts, err := v.byLabel(ctx, fmt.Sprintf(`sum by (%s) (increase(%s))`,
+		p.ContainerLabel, rangeSelector(v.selector(p.ContainerRestarts, pods))), p.ContainerLabel, end)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query the restarts of %s: %w", deployment, err)
+	}
+	evicted, err := v.byLabel(ctx, fmt.Sprintf(`count(max_over_time(%s) > 0)`,
+		rangeSelector(v.selector(p.PodStatusReason, pods, prometheus.Eq(p.ReasonLabel, evictedReason)))), "", end)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query the evictions of %s: %w", deployment, err)
+	}
+
+	windows := make(map[string]types.VerificationWindow)
+	for _, counts := range []map[string]float64{usage, oomKills, restarts} {
+		for container := range counts {
+			if container != "" {
+				windows[container] = types.VerificationWindow{
+					UsageBytes: usage[container],
+					OOMKills:   count(oomKills[container]),
+					Restarts:   count(restarts[container]),
+				}
+			}
+		}
+	}
+	return windows, count(evicted[""]), nil
+}
+
+// byLabel evaluates promql at t and returns its value keyed by the
+// value of label, empty for a query without labels
+func (v *Verifier) byLabel(ctx context.Context, promql, label string, t time.Time) (map[string]float64, error) {
+	data, err := v.client.QueryAtTime(ctx, promql, t.Unix())
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64, len(data.Data.Result))
+	for _, result := range data.Data.Result {
+		if value, ok := prometheus.ParseValue(result.Value); ok {
+			values[result.Metric[label]] = value
+		}
+	}
+	return values, nil
+}
+
+// selector renders a series selector restricted to the verified cluster and namespace
+func (v *Verifier) selector(metric string, matchers ...prometheus.Matcher) string {
+	scope := []prometheus.Matcher{
+		prometheus.Eq(v.clusterLabel, v.cluster),
+		prometheus.Eq(v.profile.NamespaceLabel, v.namespace),
+	}
+	return prometheus.Selector(metric, append(scope, matchers...)...)
+}
+
+// regressions describes the failures that rose after the change
+func regressions(v *types.Verification) []string {
+	found := []string{}
+	for _, failure := range []struct {
+		name          string
+		before, after int
+	}{
+		{"OOM kills", v.Before.OOMKills, v.After.OOMKills},
+		{"restarts", v.Before.Restarts, v.After.Restarts},
+	} {
+		if failure.after > failure.before {
+			found = append(found, fmt.Sprintf("%s rose from %d to %d", failure.name, failure.before, failure.after))
+		}
+	}
+	if v.Evictions != nil && v.Evictions.After > v.Evictions.Before {
+		found = append(found, fmt.Sprintf("evictions of the deployment rose from %d to %d", v.Evictions.Before, v.Evictions.After))
+	}
+	return found
+}
+
+// Apply sets the verifications of the containers of deployment on the
+// matching recommendations, appending a verification-only result for the
+// containers without one. A usage after the change above the recommended request is
+// flagged as a regression.
+func Apply(recommendations []types.RecommendationResult, cluster, namespace, deployment string, verifications map[string]*types.Verification) []types.RecommendationResult {
+	containers := make([]string, 0, len(verifications))
+	for container := range verifications {
+		containers = append(containers, container)
+	}
+	sort.Strings(containers)
+
+	for _, container := range containers {
+		verification := verifications[container]
+		found := false
+		for i := range recommendations {
+			rec := &recommendations[i]
+			if rec.Cluster != cluster || rec.Namespace != namespace || rec.Deployment != deployment || rec.Container != container {
+				continue
+			}
+			found = true
+			if rec.RecommendedRequestBytes > 0 && verification.After.UsageBytes > rec.RecommendedRequestBytes {
+				verification.Regressions = append(verification.Regressions, fmt.Sprintf("usage of %d MB above the recommended request of %d MB",
+					int64(verification.After.UsageBytes)/1024/1024, rec.RecommendedRequestMB))
+			}
+			rec.Verification = verification
+		}
+		if !found {
+			recommendations = append(recommendations, types.RecommendationResult{
+				Cluster:      cluster,
+				Namespace:    namespace,
+				Deployment:   deployment,
+				Container:        container,
+				Verification:     verification,
+				VerificationOnly: true,
+			})
+		}
+	}
+	return recommendations
+}
+
+// count rounds the increase of a counter to whole events
+func count(value float64) int {
+	return int(math.Round(value))
+}
@@ -0,0 +1,174 @@
+package verify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kubernetes-resources-recommend/internal/prometheus"
+	"kubernetes-resources-recommend/internal/types"
+)
+
+const mb = 1024 * 1024
+
+// changedAt is the change verified by the tests
+var changedAt = time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+
+// newVerifyServer answers the usage, OOM kill, restart and eviction queries of
+// the app container, with one OOM kill and one eviction after the change only.
+// It records every query with its evaluation time.
+func newVerifyServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		at, _ := strconv.ParseInt(r.URL.Query().Get("time"), 10, 64)
+		after := at > changedAt.Unix()
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+
+		value := func(labels string, v int) string {
+			return `{"data": {"result": [{"metric": {` + labels + `}, "value": [1, "` + strconv.Itoa(v) + `"]}]}}`
+		}
+		response := `{"data": {"result": []}}`
+		switch {
+		case strings.Contains(query, "quantile_over_time"):
+			usage := 200 * mb
+			if after {
+				usage = 300 * mb
+			}
+			response = value(`"container": "app"`, usage)
+		case strings.Contains(query, "container_oom_events_total") && after:
+			response = value(`"container": "app"`, 1)
+		case strings.Contains(query, "restarts_total"):
+			response = value(`"container": "app"`, 2)
+		case strings.Contains(query, "kube_pod_status_reason") && after:
+			response = value("", 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	server, queries := newVerifyServer(t)
+	v := New(prometheus.NewClient(server.URL, 30*time.Second), prometheus.DefaultProfile(), "shop")
+	v.SetWindow(24 * time.Hour)
+	v.now = func() time.Time { return changedAt.Add(48 * time.Hour) }
+
+	verifications, err := v.Verify(context.Background(), changedAt, "web")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(*queries) != 8 {
+		t.Fatalf("Expected 4 queries per window, got %d", len(*queries))
+	}
+	for _, expected := range []string{
+		`max by (container) (quantile_over_time(0.9, container_memory_rss{namespace="shop", container!="", container!="POD", pod=~"web-[a-z0-9]{5,10}-[a-z0-9]{5}"}[86400s]))`,
+		`sum by (container) (increase(kube_pod_container_status_restarts_total{namespace="shop", pod=~"web-[a-z0-9]{5,10}-[a-z0-9]{5}"}[86400s]))`,
+		`count(max_over_time(kube_pod_status_reason{namespace="shop", pod=~"web-[a-z0-9]{5,10}-[a-z0-9]{5}", reason="Evicted"}[86400s]) > 0)`,
+	} {
+		found := false
+		for _, query := range *queries {
+			found = found || query == expected
+		}
+		if !found {
+			t.Errorf("Expected the query %s, got %v", expected, *queries)
+		}
+	}
+
+	expected := &types.Verification{
+		ChangedAt:   changedAt,
+		Window:      86400,
+		Before:      types.VerificationWindow{UsageBytes: 200 * mb, Restarts: 2},
+		After:       types.VerificationWindow{UsageBytes: 300 * mb, OOMKills: 1, Restarts: 2},
+		Evictions:   &types.VerificationEvictions{After: 1},
+		Regressions: []string{"OOM kills rose from 0 to 1", "evictions of the deployment rose from 0 to 1"},
+	}
+	if len(verifications) != 1 || !reflect.DeepEqual(verifications["app"], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, verifications["app"])
+	}
+}
+
+func TestVerifier_Verify_EvictionsOncePerDeployment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		at, _ := strconv.ParseInt(r.URL.Query().Get("time"), 10, 64)
+		response := `{"data": {"result": []}}`
+		switch {
+		case strings.Contains(query, "quantile_over_time"):
+			response = `{"data": {"result": [{"metric": {"container": "sidecar"}, "value": [1, "1"]}, {"metric": {"container": "app"}, "value": [1, "1"]}]}}`
+		case strings.Contains(query, "kube_pod_status_reason") && at > changedAt.Unix():
+			response = `{"data": {"result": [{"metric": {}, "value": [1, "2"]}]}}`
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	v := New(prometheus.NewClient(server.URL, 30*time.Second), prometheus.DefaultProfile(), "shop")
+	v.now = func() time.Time { return changedAt.Add(time.Hour) }
+
+	verifications, err := v.Verify(context.Background(), changedAt, "web")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if app := verifications["app"]; app.Evictions == nil || app.Evictions.After != 2 || len(app.Regressions) != 1 {
+		t.Errorf("Expected the evictions of the deployment on app, got %+v", app)
+	}
+	if sidecar := verifications["sidecar"]; sidecar.Evictions != nil || len(sidecar.Regressions) != 0 {
+		t.Errorf("Expected no evictions on sidecar, got %+v", sidecar)
+	}
+}
+
+func TestVerifier_Verify_ShortensWindows(t *testing.T) {
+	server, _ := newVerifyServer(t)
+	v := New(prometheus.NewClient(server.URL, 30*time.Second), prometheus.DefaultProfile(), "shop")
+	v.now = func() time.Time { return changedAt.Add(2 * time.Hour) }
+
+	verifications, err := v.Verify(context.Background(), changedAt, "web")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := verifications["app"].Window; got != 7200 {
+		t.Errorf("Expected windows of the 2 hours since the change, got %ds", got)
+	}
+
+	v.now = func() time.Time { return changedAt }
+	if _, err := v.Verify(context.Background(), changedAt, "web"); err == nil {
+		t.Error("Expected an error for a change without time to compare")
+	}
+}
+
+func TestApply(t *testing.T) {
+	recommendations := []types.RecommendationResult{
+		{Namespace: "shop", Deployment: "web", Container: "app", RecommendedRequestMB: 256, RecommendedRequestBytes: 256 * mb},
+		{Namespace: "shop", Deployment: "api", Container: "app"},
+	}
+	verifications := map[string]*types.Verification{
+		"app":     {After: types.VerificationWindow{UsageBytes: 300 * mb}, Regressions: []string{}},
+		"sidecar": {Regressions: []string{}},
+	}
+
+	recommendations = Apply(recommendations, "", "shop", "web", verifications)
+	if len(recommendations) != 3 {
+		t.Fatalf("Expected the sidecar to be appended, got %+v", recommendations)
+	}
+	if got := recommendations[0].Verification.Regressions; len(got) != 1 || got[0] != "usage of 300 MB above the recommended request of 256 MB" {
+		t.Errorf("Unexpected regressions: %v", got)
+	}
+	if recommendations[0].VerificationOnly || recommendations[1].Verification != nil {
+		t.Error("Expected the other deployment to be left unverified")
+	}
+	if sidecar := recommendations[2]; sidecar.Deployment != "web" || sidecar.Container != "sidecar" || sidecar.Verification == nil || !sidecar.VerificationOnly {
+		t.Errorf("Unexpected sidecar result: %+v", sidecar)
+	}
+}
@@ -2,6 +2,13 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,32 +20,493 @@ type Config struct {
 	CountDays             int
 	WorkerCount           int
 	HTTPTimeout           time.Duration
+	MetricsProfile        string
+	ClusterLabel          string
+	Clusters              string
+	ReplicaLabel          string
+
+	// Percentile of the hourly usage taken per day (0 for the default of 90),
+	// and the bounds the recommended request is clamped to (0 leaves a bound open)
+	Percentile   float64
+	MinRequestMB int64
+	MaxRequestMB int64
+
+	// ConfigFile and Profile select a named profile from a YAML/JSON configuration file
+	ConfigFile string
+	Profile    string
+
+	// MemoryPrice is the price of a GiB of memory request per hour, in
+	// Currency. PricesURL reads the price from an OpenCost-style API instead.
+	MemoryPrice float64
+	Currency    string
+	PricesURL   string
+
+	// NodePoolLabel is the kube_node_labels label naming the node pool of a node
+	NodePoolLabel string
+
+	// ClusterPrices and NodePoolPrices hold the memory prices of the clusters
+	// and node pools priced apart, from the configuration file
+	ClusterPrices  map[string]float64
+	NodePoolPrices map[string]float64
+
+	// NamespaceOverrides holds per-namespace settings from the configuration file
+	NamespaceOverrides map[string]*NamespaceOverride
+
+	// sources records where each setting came from, keyed by flag name
+	sources map[string]string
 }
 
+// NamespaceOverride holds settings that differ for a single namespace.
+// Nil fields keep the global value.
+type NamespaceOverride struct {
+	MemoryLimitMultiplier *float64
+	CountDays             *int
+	WorkerCount           *int
+	MemoryPrice           *float64
+
+	// location is where the namespace section starts, sources where each setting was set
+	location string
+	sources  map[string]string
+}
+
+// EnvPrefix starts the environment variable of every setting, such as
+// RESOURCES_RECOMMEND_PROMETHEUS_URL, so common names like PROFILE or
+// CLUSTERS set for other tools are never read
+const EnvPrefix = "RESOURCES_RECOMMEND_"
+
+// setting describes a configuration field settable by flag, environment variable and file
+type setting struct {
+	name string
+	env  string // name of the environment variable after EnvPrefix
+}
+
+// settings lists every field loaded with flags > env vars > file > defaults precedence
+var settings = []setting{
+	{"prometheusUrl", "PROMETHEUS_URL"},
+	{"checkNamespace", "CHECK_NAMESPACE"},
+	{"limits", "LIMITS"},
+	{"countDays", "COUNT_DAYS"},
+	{"workerCount", "WORKER_COUNT"},
+	{"httpTimeout", "HTTP_TIMEOUT"},
+	{"metricsProfile", "METRICS_PROFILE"},
+	{"clusterLabel", "CLUSTER_LABEL"},
+	{"clusters", "CLUSTERS"},
+	{"replicaLabel", "REPLICA_LABEL"},
+	{"percentile", "PERCENTILE"},
+	{"minRequestMB", "MIN_REQUEST_MB"},
+	{"maxRequestMB", "MAX_REQUEST_MB"},
+	{"memoryPrice", "MEMORY_PRICE"},
+	{"currency", "CURRENCY"},
+	{"pricesUrl", "PRICES_URL"},
+	{"nodePoolLabel", "NODE_POOL_LABEL"},
+}
+
+// namespaceSettings lists the fields a namespace override may set
+var namespaceSettings = []string{"limits", "countDays", "workerCount", "memoryPrice"}
+
+const (
+	sourceDefault = "default"
+	sourceFlag    = "flag"
+)
+
 // LoadFromFlags loads configuration from command line flags
 func LoadFromFlags() *Config {
-	var config Config
+	config, err := LoadFromArgs(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return config
+}
+
+// LoadFromArgs registers the configuration flags on fs and parses args.
+// Values are resolved with flags > environment variables > configuration file > defaults precedence.
+func LoadFromArgs(fs *flag.FlagSet, args []string) (*Config, error) {
+	config := RegisterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := config.Load(fs); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// RegisterFlags registers the configuration flags on fs. Call Load once fs
+// has been parsed to apply environment variables and the configuration file.
+func RegisterFlags(fs *flag.FlagSet) *Config {
+	config := &Config{sources: make(map[string]string)}
+
+	fs.StringVar(&config.PrometheusURL, "prometheusUrl", "https://prometheus.example.com", "prometheus url")
+	fs.StringVar(&config.CheckNamespace, "checkNamespace", "default", "check namespace")
+	fs.Float64Var(&config.MemoryLimitMultiplier, "limits", 1.5, "request multiple")
+	fs.IntVar(&config.CountDays, "countDays", 7, "number of past days to analyse")
+	fs.IntVar(&config.WorkerCount, "workerCount", 20, "number of deployments analysed concurrently")
+	fs.DurationVar(&config.HTTPTimeout, "httpTimeout", 60*time.Second, "timeout for Prometheus requests")
+	fs.StringVar(&config.MetricsProfile, "metricsProfile", "kube-state-metrics-v2", "built-in metrics profile name or path to a JSON profile file")
+	fs.StringVar(&config.ClusterLabel, "clusterLabel", "", "label distinguishing clusters in a shared Prometheus/Thanos, empty for a single cluster")
+	fs.StringVar(&config.Clusters, "clusters", "", "comma-separated clusters to analyse, empty for every cluster found under -clusterLabel")
+	fs.StringVar(&config.ReplicaLabel, "replicaLabel", "", "label distinguishing HA Prometheus replicas, stripped and deduplicated from every result")
+	fs.Float64Var(&config.Percentile, "percentile", 90, "percentile of the hourly memory usage taken for each day")
+	fs.Int64Var(&config.MinRequestMB, "minRequestMB", 0, "lower bound of the recommended memory request in MB, 0 for none")
+	fs.Int64Var(&config.MaxRequestMB, "maxRequestMB", 0, "upper bound of the recommended memory request in MB, 0 for none")
+	fs.Float64Var(&config.MemoryPrice, "memoryPrice", 0, "price of a GiB of memory request per hour, 0 to leave costs out")
+	fs.StringVar(&config.Currency, "currency", "USD", "currency of the memory prices")
+	fs.StringVar(&config.PricesURL, "pricesUrl", "", "OpenCost-style pricing API whose RAM price replaces -memoryPrice, empty for none")
+	fs.StringVar(&config.NodePoolLabel, "nodePoolLabel", "", "kube_node_labels label naming the node pool of a node, used to apply the node pool prices of the configuration file")
+	fs.StringVar(&config.ConfigFile, "config", "", "YAML or JSON configuration file (env "+EnvPrefix+"CONFIG_FILE)")
+	fs.StringVar(&config.Profile, "profile", "", "named profile of the configuration file (env "+EnvPrefix+"PROFILE)")
+
+	return config
+}
+
+// Load resolves the settings not given on the parsed fs from environment
+// variables and the configuration file
+func (c *Config) Load(fs *flag.FlagSet) error {
+	setByFlag := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { setByFlag[f.Name] = true })
+
+	if !setByFlag["config"] {
+		c.ConfigFile = os.Getenv(EnvPrefix + "CONFIG_FILE")
+	}
+	if !setByFlag["profile"] {
+		c.Profile = os.Getenv(EnvPrefix + "PROFILE")
+	}
+
+	var file *fileSettings
+	if c.ConfigFile != "" {
+		var err error
+		if file, err = loadFile(c.ConfigFile, c.Profile); err != nil {
+			return err
+		}
+		c.Profile = file.profile
+	}
+
+	for _, s := range settings {
+		value, source := "", sourceDefault
+		if setByFlag[s.name] {
+			source = sourceFlag
+		} else if env, ok := os.LookupEnv(EnvPrefix + s.env); ok {
+			value, source = env, "env "+EnvPrefix+s.env
+		} else if file != nil {
+			if v, ok := file.values[s.name]; ok {
+				value, source = v.value, v.location
+			}
+		}
+
+		c.sources[s.name] = source
+		if source == sourceDefault || source == sourceFlag {
+			continue
+		}
+		if err := fs.Lookup(s.name).Value.Set(value); err != nil {
+			return &FieldError{Field: s.name, Source: source, Err: fmt.Errorf("invalid value %q: %w", value, err)}
+		}
+	}
+
+	if file != nil {
+		overrides, err := file.namespaceOverrides()
+		if err != nil {
+			return err
+		}
+		c.NamespaceOverrides = overrides
+
+		if c.ClusterPrices, err = parsePrices("clusterPrices", file.clusterPrices, c.sources); err != nil {
+			return err
+		}
+		if c.NodePoolPrices, err = parsePrices("nodePoolPrices", file.nodePoolPrices, c.sources); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FlagGroup is a titled set of related configuration flags, used to lay out help output
+type FlagGroup struct {
+	Title string
+	Flags []string
+}
+
+// FlagGroups returns the configuration flags grouped by topic, in help order
+func FlagGroups() []FlagGroup {
+	return []FlagGroup{
+		{"Prometheus", []string{"prometheusUrl", "httpTimeout", "metricsProfile", "replicaLabel", "clusterLabel", "clusters"}},
+		{"Analysis", []string{"checkNamespace", "countDays", "workerCount", "percentile", "limits", "minRequestMB", "maxRequestMB"}},
+		{"Cost", []string{"memoryPrice", "currency", "pricesUrl", "nodePoolLabel"}},
+		{"Configuration file", []string{"config", "profile"}},
+	}
+}
+
+// Source returns where a setting, named by its flag, was loaded from:
+// "default", "flag", "env NAME" or "file:line"
+func (c *Config) Source(name string) string {
+	if source, ok := c.sources[name]; ok {
+		return source
+	}
+	return sourceDefault
+}
+
+// ForNamespace returns a copy of the configuration with the overrides of a
+// namespace applied. Settings given by flag or environment variable win over
+// the configuration file, including its namespace overrides.
+func (c *Config) ForNamespace(namespace string) *Config {
+	copied := *c
+	copied.CheckNamespace = namespace
+
+	override, ok := c.NamespaceOverrides[namespace]
+	if !ok {
+		return &copied
+	}
 
-	flag.StringVar(&config.PrometheusURL, "prometheusUrl", "https://prometheus.example.com", "prometheus url")
-	flag.StringVar(&config.CheckNamespace, "checkNamespace", "default", "check namespace")
-	flag.Float64Var(&config.MemoryLimitMultiplier, "limits", 1.5, "request multiple")
-	flag.Parse()
+	copied.sources = make(map[string]string, len(c.sources))
+	for name, source := range c.sources {
+		copied.sources[name] = source
+	}
+	if override.MemoryLimitMultiplier != nil && c.fromFile("limits") {
+		copied.MemoryLimitMultiplier = *override.MemoryLimitMultiplier
+		copied.sources["limits"] = override.sources["limits"]
+	}
+	if override.CountDays != nil && c.fromFile("countDays") {
+		copied.CountDays = *override.CountDays
+		copied.sources["countDays"] = override.sources["countDays"]
+	}
+	if override.WorkerCount != nil && c.fromFile("workerCount") {
+		copied.WorkerCount = *override.WorkerCount
+		copied.sources["workerCount"] = override.sources["workerCount"]
+	}
+	if override.MemoryPrice != nil && c.fromFile("memoryPrice") {
+		copied.MemoryPrice = *override.MemoryPrice
+		copied.sources["memoryPrice"] = override.sources["memoryPrice"]
+	}
+	return &copied
+}
+
+// NamespacePrices returns the memory prices of the namespaces priced apart in
+// the configuration file, none when a flag or environment variable sets the
+// price of every namespace
+func (c *Config) NamespacePrices() map[string]float64 {
+	prices := make(map[string]float64)
+	if !c.fromFile("memoryPrice") {
+		return prices
+	}
+	for namespace, override := range c.NamespaceOverrides {
+		if override.MemoryPrice != nil {
+			prices[namespace] = *override.MemoryPrice
+		}
+	}
+	return prices
+}
 
-	// Set default values
-	config.CountDays = 7
-	config.WorkerCount = 20
-	config.HTTPTimeout = 60 * time.Second
+// fromFile reports whether a setting was left to the configuration file,
+// neither a flag nor an environment variable setting it
+func (c *Config) fromFile(name string) bool {
+	source := c.Source(name)
+	return source != sourceFlag && !strings.HasPrefix(source, "env ")
+}
 
-	return &config
+// ClusterList returns the clusters selected with -clusters
+func (c *Config) ClusterList() []string {
+	var clusters []string
+	for _, cluster := range strings.Split(c.Clusters, ",") {
+		if cluster = strings.TrimSpace(cluster); cluster != "" {
+			clusters = append(clusters, cluster)
+		}
+	}
+	return clusters
 }
 
-// Validate checks if the configuration is valid
+// Validation bounds
+const (
+	maxNamespaceLength       = 63
+	minMemoryLimitMultiplier = 1.0
+	minCountDays             = 1
+	maxCountDays             = 90
+	minWorkerCount           = 1
+	maxWorkerCount           = 500
+	minHTTPTimeout           = time.Second
+	maxHTTPTimeout           = 30 * time.Minute
+)
+
+// namespacePattern matches a DNS-1123 label
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Validate checks if the configuration is valid. Every problem is reported
+// at once as ValidationErrors; use errors.Is and errors.As to inspect them.
 func (c *Config) Validate() error {
-	if c.PrometheusURL == "" {
+	var errs ValidationErrors
+
+	add := func(name string, err error) {
+		errs = append(errs, c.fieldError(name, err))
+	}
+
+	if err := validatePrometheusURL(c.PrometheusURL); err != nil {
+		add("prometheusUrl", err)
+	}
+	if strings.TrimSpace(c.CheckNamespace) == "" {
+		add("checkNamespace", ErrMissingNamespace)
+	} else if !ValidNamespace(c.CheckNamespace) {
+		add("checkNamespace", &InvalidNamespaceError{Namespace: c.CheckNamespace})
+	}
+	if c.MemoryLimitMultiplier < minMemoryLimitMultiplier {
+		add("limits", &InvalidMultiplierError{Multiplier: c.MemoryLimitMultiplier})
+	}
+	if c.CountDays < minCountDays || c.CountDays > maxCountDays {
+		add("countDays", &InvalidCountDaysError{CountDays: c.CountDays})
+	}
+	if c.WorkerCount < minWorkerCount || c.WorkerCount > maxWorkerCount {
+		add("workerCount", &InvalidWorkerCountError{WorkerCount: c.WorkerCount})
+	}
+	if c.HTTPTimeout < minHTTPTimeout || c.HTTPTimeout > maxHTTPTimeout {
+		add("httpTimeout", &InvalidTimeoutError{Timeout: c.HTTPTimeout})
+	}
+	if c.Percentile < 0 || c.Percentile > 100 {
+		add("percentile", &InvalidPercentileError{Percentile: c.Percentile})
+	}
+	if c.MinRequestMB < 0 || c.MaxRequestMB < 0 || (c.MaxRequestMB > 0 && c.MinRequestMB > c.MaxRequestMB) {
+		name := "minRequestMB"
+		if c.MaxRequestMB < 0 {
+			name = "maxRequestMB"
+		}
+		add(name, &InvalidBoundsError{MinRequestMB: c.MinRequestMB, MaxRequestMB: c.MaxRequestMB})
+	}
+	if c.MemoryPrice < 0 {
+		add("memoryPrice", &InvalidPriceError{Price: c.MemoryPrice})
+	}
+	for _, prices := range []struct {
+		name   string
+		prices map[string]float64
+	}{{"clusterPrices", c.ClusterPrices}, {"nodePoolPrices", c.NodePoolPrices}} {
+		names := make([]string, 0, len(prices.prices))
+		for name := range prices.prices {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if price := prices.prices[name]; price < 0 {
+				field := prices.name + "." + name
+				errs = append(errs, &FieldError{Field: field, Source: c.Source(field), Err: &InvalidPriceError{Price: price}})
+			}
+		}
+	}
+
+	// Overrides are reported against the file location they were set at
+	namespaces := make([]string, 0, len(c.NamespaceOverrides))
+	for namespace := range c.NamespaceOverrides {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		override := c.NamespaceOverrides[namespace]
+		overrideError := func(name string, err error) {
+			errs = append(errs, &FieldError{Field: "namespaces." + namespace + "." + name, Source: override.sources[name], Err: err})
+		}
+
+		if !ValidNamespace(namespace) {
+			errs = append(errs, &FieldError{Field: "namespaces." + namespace, Source: override.location, Err: &InvalidNamespaceError{Namespace: namespace}})
+		}
+		if m := override.MemoryLimitMultiplier; m != nil && *m < minMemoryLimitMultiplier {
+			overrideError("limits", &InvalidMultiplierError{Multiplier: *m})
+		}
+		if days := override.CountDays; days != nil && (*days < minCountDays || *days > maxCountDays) {
+			overrideError("countDays", &InvalidCountDaysError{CountDays: *days})
+		}
+		if workers := override.WorkerCount; workers != nil && (*workers < minWorkerCount || *workers > maxWorkerCount) {
+			overrideError("workerCount", &InvalidWorkerCountError{WorkerCount: *workers})
+		}
+		if price := override.MemoryPrice; price != nil && *price < 0 {
+			overrideError("memoryPrice", &InvalidPriceError{Price: *price})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validatePrometheusURL checks that the URL is an absolute http(s) URL
+func validatePrometheusURL(rawURL string) error {
+	if strings.TrimSpace(rawURL) == "" {
 		return ErrMissingPrometheusURL
 	}
-	if c.CheckNamespace == "" {
-		return ErrMissingNamespace
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return &InvalidURLError{URL: rawURL, Reason: err.Error()}
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return &InvalidURLError{URL: rawURL, Reason: "scheme must be http or https"}
+	}
+	if parsed.Host == "" {
+		return &InvalidURLError{URL: rawURL, Reason: "host is missing"}
 	}
 	return nil
 }
+
+// ValidNamespace reports whether namespace is a valid DNS-1123 label
+func ValidNamespace(namespace string) bool {
+	return len(namespace) <= maxNamespaceLength && namespacePattern.MatchString(namespace)
+}
+
+// fieldError attributes err to the file location of a setting. Errors of
+// settings that did not come from a file are returned unchanged.
+func (c *Config) fieldError(name string, err error) error {
+	source := c.Source(name)
+	if source == sourceDefault || source == sourceFlag || strings.HasPrefix(source, "env ") {
+		return err
+	}
+	return &FieldError{Field: name, Source: source, Err: err}
+}
+
+// parseNamespaceOverride converts the raw values of a namespace section
+func parseNamespaceOverride(location string, values map[string]fileValue) (*NamespaceOverride, error) {
+	override := &NamespaceOverride{location: location, sources: make(map[string]string)}
+
+	for name, v := range values {
+		override.sources[name] = v.location
+		switch name {
+		case "limits", "memoryPrice":
+			value, err := strconv.ParseFloat(v.value, 64)
+			if err != nil {
+				return nil, &FieldError{Field: name, Source: v.location, Err: fmt.Errorf("invalid value %q: %w", v.value, err)}
+			}
+			if name == "limits" {
+				override.MemoryLimitMultiplier = &value
+			} else {
+				override.MemoryPrice = &value
+			}
+		case "countDays", "workerCount":
+			n, err := strconv.Atoi(v.value)
+			if err != nil {
+				return nil, &FieldError{Field: name, Source: v.location, Err: fmt.Errorf("invalid value %q: %w", v.value, err)}
+			}
+			if name == "countDays" {
+				override.CountDays = &n
+			} else {
+				override.WorkerCount = &n
+			}
+		}
+	}
+
+	return override, nil
+}
+
+// parsePrices converts the raw prices of a file section named section,
+// recording the location of each as the source of section.name
+func parsePrices(section string, values map[string]fileValue, sources map[string]string) (map[string]float64, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	prices := make(map[string]float64, len(values))
+	for name, v := range values {
+		field := section + "." + name
+		price, err := strconv.ParseFloat(v.value, 64)
+		if err != nil {
+			return nil, &FieldError{Field: field, Source: v.location, Err: fmt.Errorf("invalid value %q: %w", v.value, err)}
+		}
+		prices[name] = price
+		sources[field] = v.location
+	}
+	return prices, nil
+}
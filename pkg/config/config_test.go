@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -97,7 +99,7 @@ func TestConfig_Validate_MissingPrometheusURL(t *testing.T) {
 	}
 
 	err := config.Validate()
-	if err != ErrMissingPrometheusURL {
+	if !errors.Is(err, ErrMissingPrometheusURL) {
 		t.Errorf("Expected ErrMissingPrometheusURL, got: %v", err)
 	}
 }
@@ -113,7 +115,7 @@ func TestConfig_Validate_MissingNamespace(t *testing.T) {
 	}
 
 	err := config.Validate()
-	if err != ErrMissingNamespace {
+	if !errors.Is(err, ErrMissingNamespace) {
 		t.Errorf("Expected ErrMissingNamespace, got: %v", err)
 	}
 }
@@ -129,10 +131,13 @@ func TestConfig_Validate_BothMissing(t *testing.T) {
 	}
 
 	err := config.Validate()
-	// Should return the first error (PrometheusURL)
-	if err != ErrMissingPrometheusURL {
+	// Should report both errors at once
+	if !errors.Is(err, ErrMissingPrometheusURL) {
 		t.Errorf("Expected ErrMissingPrometheusURL, got: %v", err)
 	}
+	if !errors.Is(err, ErrMissingNamespace) {
+		t.Errorf("Expected ErrMissingNamespace, got: %v", err)
+	}
 }
 
 func TestConfig_StructFields(t *testing.T) {
@@ -184,13 +189,13 @@ func TestConfig_EdgeCases(t *testing.T) {
 			name:           "Whitespace only PrometheusURL",
 			prometheusURL:  "   ",
 			checkNamespace: "valid",
-			expectedError:  nil, // Current validation doesn't trim whitespace
+			expectedError:  ErrMissingPrometheusURL,
 		},
 		{
 			name:           "Whitespace only namespace",
 			prometheusURL:  "https://prometheus.example.com",
 			checkNamespace: "   ",
-			expectedError:  nil, // Current validation doesn't trim whitespace
+			expectedError:  ErrMissingNamespace,
 		},
 		{
 			name:           "Valid non-standard URL",
@@ -218,7 +223,10 @@ func TestConfig_EdgeCases(t *testing.T) {
 			}
 
 			err := config.Validate()
-			if err != tt.expectedError {
+			if tt.expectedError == nil && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.expectedError != nil && !errors.Is(err, tt.expectedError) {
 				t.Errorf("Expected error %v, got %v", tt.expectedError, err)
 			}
 		})
@@ -233,8 +241,10 @@ func TestConfig_MemoryLimitMultiplierValidation(t *testing.T) {
 		shouldPass bool
 	}{
 		{"Positive multiplier", 1.5, true},
-		{"Zero multiplier", 0.0, true}, // Current validation doesn't check this
-		{"Negative multiplier", -1.0, true}, // Current validation doesn't check this
+		{"Exactly one", 1.0, true},
+		{"Below one", 0.9, false},
+		{"Zero multiplier", 0.0, false},
+		{"Negative multiplier", -1.0, false},
 		{"Large multiplier", 10.0, true},
 	}
 
@@ -293,3 +303,191 @@ func BenchmarkConfigValidate(b *testing.B) {
 		config.Validate()
 	}
 }
+
+func TestConfig_ClusterList(t *testing.T) {
+	tests := []struct {
+		name     string
+		clusters string
+		expected []string
+	}{
+		{"Empty", "", nil},
+		{"Single", "prod-eu", []string{"prod-eu"}},
+		{"Multiple with spaces", "prod-eu, prod-us ,,staging", []string{"prod-eu", "prod-us", "staging"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Clusters: tt.clusters}
+			clusters := config.ClusterList()
+			if len(clusters) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, clusters)
+			}
+			for i := range clusters {
+				if clusters[i] != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, clusters)
+				}
+			}
+		})
+	}
+}
+
+// validTestConfig returns a configuration that passes validation
+func validTestConfig() *Config {
+	return &Config{
+		PrometheusURL:         "https://prometheus.example.com",
+		CheckNamespace:        "production",
+		MemoryLimitMultiplier: 1.5,
+		CountDays:             7,
+		WorkerCount:           20,
+		HTTPTimeout:           60 * time.Second,
+		Percentile:            90,
+	}
+}
+
+func TestConfig_Validate_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		target interface{}
+	}{
+		{"URL without scheme", func(c *Config) { c.PrometheusURL = "prometheus:9090" }, new(*InvalidURLError)},
+		{"URL with unsupported scheme", func(c *Config) { c.PrometheusURL = "ftp://prometheus.example.com" }, new(*InvalidURLError)},
+		{"URL without host", func(c *Config) { c.PrometheusURL = "https://" }, new(*InvalidURLError)},
+		{"Unparsable URL", func(c *Config) { c.PrometheusURL = "https://prom etheus:bad" }, new(*InvalidURLError)},
+		{"Uppercase namespace", func(c *Config) { c.CheckNamespace = "Production" }, new(*InvalidNamespaceError)},
+		{"Namespace with trailing dash", func(c *Config) { c.CheckNamespace = "shop-" }, new(*InvalidNamespaceError)},
+		{"Namespace too long", func(c *Config) { c.CheckNamespace = strings.Repeat("a", 64) }, new(*InvalidNamespaceError)},
+		{"Multiplier below one", func(c *Config) { c.MemoryLimitMultiplier = 0.5 }, new(*InvalidMultiplierError)},
+		{"Zero days", func(c *Config) { c.CountDays = 0 }, new(*InvalidCountDaysError)},
+		{"Too many days", func(c *Config) { c.CountDays = 91 }, new(*InvalidCountDaysError)},
+		{"Zero workers", func(c *Config) { c.WorkerCount = 0 }, new(*InvalidWorkerCountError)},
+		{"Too many workers", func(c *Config) { c.WorkerCount = 501 }, new(*InvalidWorkerCountError)},
+		{"Timeout too short", func(c *Config) { c.HTTPTimeout = 500 * time.Millisecond }, new(*InvalidTimeoutError)},
+		{"Timeout too long", func(c *Config) { c.HTTPTimeout = time.Hour }, new(*InvalidTimeoutError)},
+		{"Negative percentile", func(c *Config) { c.Percentile = -1 }, new(*InvalidPercentileError)},
+		{"Percentile above 100", func(c *Config) { c.Percentile = 101 }, new(*InvalidPercentileError)},
+		{"Negative minimum request", func(c *Config) { c.MinRequestMB = -1 }, new(*InvalidBoundsError)},
+		{"Minimum above maximum", func(c *Config) { c.MinRequestMB, c.MaxRequestMB = 512, 256 }, new(*InvalidBoundsError)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validTestConfig()
+			tt.modify(config)
+
+			err := config.Validate()
+			if err == nil {
+				t.Fatal("Expected validation to fail")
+			}
+			if !errors.As(err, tt.target) {
+				t.Errorf("Unexpected error type: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_AcceptsBoundaries(t *testing.T) {
+	config := validTestConfig()
+	config.PrometheusURL = "http://localhost:9090/prometheus"
+	config.CheckNamespace = strings.Repeat("a", 63)
+	config.MemoryLimitMultiplier = 1
+	config.CountDays = 90
+	config.WorkerCount = 500
+	config.HTTPTimeout = 30 * time.Minute
+	config.Percentile = 100
+	config.MinRequestMB, config.MaxRequestMB = 256, 256
+
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected boundary values to pass validation, got: %v", err)
+	}
+
+	// A zero percentile selects the default and zero bounds are open
+	config.Percentile = 0
+	config.MinRequestMB, config.MaxRequestMB = 128, 0
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected open bounds to pass validation, got: %v", err)
+	}
+}
+
+func TestConfig_Validate_CollectsAllErrors(t *testing.T) {
+	config := &Config{
+		PrometheusURL:         "ftp://prometheus",
+		CheckNamespace:        "Shop",
+		MemoryLimitMultiplier: 0,
+		CountDays:             0,
+		WorkerCount:           0,
+		HTTPTimeout:           0,
+		Percentile:            200,
+		MinRequestMB:          -1,
+	}
+
+	err := config.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ValidationErrors, got %T", err)
+	}
+	if len(errs) != 8 {
+		t.Errorf("Expected 8 errors, got %d: %v", len(errs), err)
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != len(errs) {
+		t.Errorf("Expected one line per error, got %q", err.Error())
+	}
+}
+
+func TestConfig_Validate_NamespaceOverrides(t *testing.T) {
+	multiplier, days, workers := 0.5, 120, 20
+	config := validTestConfig()
+	config.NamespaceOverrides = map[string]*NamespaceOverride{
+		"batch": {
+			MemoryLimitMultiplier: &multiplier,
+			CountDays:             &days,
+			WorkerCount:           &workers,
+			sources:               map[string]string{"limits": "config.yaml:5", "countDays": "config.yaml:6"},
+		},
+		"Invalid_NS": {location: "config.yaml:9"},
+	}
+
+	err := config.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ValidationErrors, got %T", err)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 errors, got %d: %v", len(errs), err)
+	}
+
+	var namespaceErr *InvalidNamespaceError
+	if !errors.As(err, &namespaceErr) || namespaceErr.Namespace != "Invalid_NS" {
+		t.Errorf("Expected InvalidNamespaceError for Invalid_NS, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "config.yaml:5: namespaces.batch.limits") {
+		t.Errorf("Expected the multiplier error at its file location, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "config.yaml:6: namespaces.batch.countDays") {
+		t.Errorf("Expected the countDays error at its file location, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "config.yaml:9: namespaces.Invalid_NS") {
+		t.Errorf("Expected the namespace error at its file location, got %v", err)
+	}
+}
+
+func TestFlagGroups_CoverEveryFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+
+	grouped := make(map[string]int)
+	for _, group := range FlagGroups() {
+		for _, name := range group.Flags {
+			if fs.Lookup(name) == nil {
+				t.Errorf("Group %s lists unknown flag %s", group.Title, name)
+			}
+			grouped[name]++
+		}
+	}
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if grouped[f.Name] != 1 {
+			t.Errorf("Expected flag %s in exactly one group, found in %d", f.Name, grouped[f.Name])
+		}
+	})
+}
@@ -1,8 +1,144 @@
 package config
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
 	ErrMissingPrometheusURL = errors.New("PrometheusURL must be provided")
 	ErrMissingNamespace     = errors.New("CheckNamespace must be provided")
 )
+
+// FieldError attributes a configuration error to a setting and the location it was loaded from
+type FieldError struct {
+	Field  string
+	Source string
+	Err    error
+}
+
+// Error implements the error interface
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Source, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors collects every problem found by Config.Validate
+type ValidationErrors []error
+
+// Error implements the error interface, listing one problem per line
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the collected errors so errors.Is and errors.As inspect each of them
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// InvalidURLError reports a Prometheus URL that cannot be used
+type InvalidURLError struct {
+	URL    string
+	Reason string
+}
+
+// Error implements the error interface
+func (e *InvalidURLError) Error() string {
+	return fmt.Sprintf("invalid PrometheusURL %q: %s", e.URL, e.Reason)
+}
+
+// InvalidNamespaceError reports a namespace that is not a valid DNS-1123 label
+type InvalidNamespaceError struct {
+	Namespace string
+}
+
+// Error implements the error interface
+func (e *InvalidNamespaceError) Error() string {
+	return fmt.Sprintf("invalid namespace %q: must be a DNS-1123 label of at most %d lowercase alphanumeric characters or '-', starting and ending with an alphanumeric character",
+		e.Namespace, maxNamespaceLength)
+}
+
+// InvalidMultiplierError reports a memory limit multiplier below 1
+type InvalidMultiplierError struct {
+	Multiplier float64
+}
+
+// Error implements the error interface
+func (e *InvalidMultiplierError) Error() string {
+	return fmt.Sprintf("invalid memory limit multiplier %g: must be at least %g so limits are not below requests",
+		e.Multiplier, minMemoryLimitMultiplier)
+}
+
+// InvalidCountDaysError reports an analysis period outside the supported range
+type InvalidCountDaysError struct {
+	CountDays int
+}
+
+// Error implements the error interface
+func (e *InvalidCountDaysError) Error() string {
+	return fmt.Sprintf("invalid CountDays %d: must be between %d and %d", e.CountDays, minCountDays, maxCountDays)
+}
+
+// InvalidWorkerCountError reports a worker count outside the supported range
+type InvalidWorkerCountError struct {
+	WorkerCount int
+}
+
+// Error implements the error interface
+func (e *InvalidWorkerCountError) Error() string {
+	return fmt.Sprintf("invalid WorkerCount %d: must be between %d and %d", e.WorkerCount, minWorkerCount, maxWorkerCount)
+}
+
+// InvalidTimeoutError reports an HTTP timeout outside the supported range
+type InvalidTimeoutError struct {
+	Timeout time.Duration
+}
+
+// Error implements the error interface
+func (e *InvalidTimeoutError) Error() string {
+	return fmt.Sprintf("invalid HTTPTimeout %v: must be between %v and %v", e.Timeout, minHTTPTimeout, maxHTTPTimeout)
+}
+
+// InvalidPercentileError reports a usage percentile outside [0, 100]
+type InvalidPercentileError struct {
+	Percentile float64
+}
+
+// Error implements the error interface
+func (e *InvalidPercentileError) Error() string {
+	return fmt.Sprintf("invalid Percentile %g: must be between 0 and 100", e.Percentile)
+}
+
+// InvalidBoundsError reports request bounds that are negative or inverted
+type InvalidBoundsError struct {
+	MinRequestMB int64
+	MaxRequestMB int64
+}
+
+// Error implements the error interface
+func (e *InvalidBoundsError) Error() string {
+	if e.MinRequestMB < 0 || e.MaxRequestMB < 0 {
+		return fmt.Sprintf("invalid request bounds [%d, %d] MB: bounds must not be negative", e.MinRequestMB, e.MaxRequestMB)
+	}
+	return fmt.Sprintf("invalid request bounds [%d, %d] MB: MinRequestMB must not exceed MaxRequestMB", e.MinRequestMB, e.MaxRequestMB)
+}
+
+// InvalidPriceError reports a negative memory price
+type InvalidPriceError struct {
+	Price float64
+}
+
+// Error implements the error interface
+func (e *InvalidPriceError) Error() string {
+	return fmt.Sprintf("invalid memory price %g: must not be negative", e.Price)
+}
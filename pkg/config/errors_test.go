@@ -2,7 +2,9 @@ package config
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestErrorMessages(t *testing.T) {
@@ -104,7 +106,7 @@ func TestErrorInConfigValidation(t *testing.T) {
 			expectedError:  ErrMissingNamespace,
 		},
 		{
-			name:           "Both missing - should return PrometheusURL error first",
+			name:           "Both missing - should report the PrometheusURL error too",
 			prometheusURL:  "",
 			checkNamespace: "",
 			expectedError:  ErrMissingPrometheusURL,
@@ -120,12 +122,19 @@ func TestErrorInConfigValidation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			config := &Config{
-				PrometheusURL:  tt.prometheusURL,
-				CheckNamespace: tt.checkNamespace,
+				PrometheusURL:         tt.prometheusURL,
+				CheckNamespace:        tt.checkNamespace,
+				MemoryLimitMultiplier: 1.5,
+				CountDays:             7,
+				WorkerCount:           20,
+				HTTPTimeout:           60 * time.Second,
 			}
 
 			err := config.Validate()
-			if err != tt.expectedError {
+			if tt.expectedError == nil && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.expectedError != nil && !errors.Is(err, tt.expectedError) {
 				t.Errorf("Expected error %v, got %v", tt.expectedError, err)
 			}
 		})
@@ -164,3 +173,50 @@ func BenchmarkErrorIs(b *testing.B) {
 		errors.Is(ErrMissingPrometheusURL, ErrMissingPrometheusURL)
 	}
 }
+
+func TestTypedErrorMessages(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&InvalidURLError{URL: "ftp://x", Reason: "scheme must be http or https"}, `invalid PrometheusURL "ftp://x": scheme must be http or https`},
+		{&InvalidNamespaceError{Namespace: "Shop"}, `invalid namespace "Shop"`},
+		{&InvalidMultiplierError{Multiplier: 0.5}, "invalid memory limit multiplier 0.5"},
+		{&InvalidCountDaysError{CountDays: 0}, "invalid CountDays 0: must be between 1 and 90"},
+		{&InvalidWorkerCountError{WorkerCount: 0}, "invalid WorkerCount 0: must be between 1 and 500"},
+		{&InvalidTimeoutError{Timeout: 0}, "invalid HTTPTimeout 0s: must be between 1s and 30m0s"},
+		{&InvalidPercentileError{Percentile: 101}, "invalid Percentile 101: must be between 0 and 100"},
+		{&InvalidBoundsError{MinRequestMB: -1}, "bounds must not be negative"},
+		{&InvalidBoundsError{MinRequestMB: 512, MaxRequestMB: 256}, "MinRequestMB must not exceed MaxRequestMB"},
+		{&InvalidPriceError{Price: -0.5}, "invalid memory price -0.5: must not be negative"},
+	}
+
+	for _, tt := range tests {
+		if !strings.Contains(tt.err.Error(), tt.expected) {
+			t.Errorf("Expected error containing '%s', got '%s'", tt.expected, tt.err.Error())
+		}
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	errs := ValidationErrors{
+		ErrMissingPrometheusURL,
+		&FieldError{Field: "limits", Source: "config.yaml:3", Err: &InvalidMultiplierError{Multiplier: 0}},
+	}
+
+	if !errors.Is(errs, ErrMissingPrometheusURL) {
+		t.Error("ValidationErrors should match a collected sentinel error")
+	}
+	var multiplierErr *InvalidMultiplierError
+	if !errors.As(errs, &multiplierErr) {
+		t.Error("ValidationErrors should expose typed errors wrapped in a FieldError")
+	}
+	if errors.Is(errs, ErrMissingNamespace) {
+		t.Error("ValidationErrors should not match an error it does not contain")
+	}
+
+	expected := "PrometheusURL must be provided\nconfig.yaml:3: limits: invalid memory limit multiplier 0: must be at least 1 so limits are not below requests"
+	if errs.Error() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, errs.Error())
+	}
+}
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileValue is a raw setting read from the configuration file
+type fileValue struct {
+	value    string
+	location string
+}
+
+// fileSettings holds the settings of the selected profile of a configuration file
+type fileSettings struct {
+	profile    string
+	values     map[string]fileValue
+	namespaces map[string]map[string]fileValue
+
+	// namespaceLocations records where each namespace section was last declared
+	namespaceLocations map[string]string
+
+	// clusterPrices and nodePoolPrices are the memory prices keyed by cluster and node pool
+	clusterPrices  map[string]fileValue
+	nodePoolPrices map[string]fileValue
+}
+
+// loadFile reads a YAML or JSON configuration file of the form
+//
+//	profile: prod            # profile used when -profile is not given
+//	defaults:                # settings shared by every profile
+//	  prometheusUrl: https://prometheus.example.com
+//	profiles:
+//	  prod:
+//	    checkNamespace: shop
+//	    limits: 1.5
+//	    namespaces:          # per-namespace overrides
+//	      batch:
+//	        countDays: 14
+//	    clusterPrices:       # memory price per GiB-hour of clusters
+//	      prod-us: 0.005
+//	    nodePoolPrices:      # and of node pools, see nodePoolLabel
+//	      highmem: 0.003
+//
+// Setting keys are the flag names. The settings of the selected profile are
+// layered over the defaults.
+func loadFile(path, profile string) (*fileSettings, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	file := &fileSettings{
+		values:             make(map[string]fileValue),
+		namespaces:         make(map[string]map[string]fileValue),
+		namespaceLocations: make(map[string]string),
+		clusterPrices:      make(map[string]fileValue),
+		nodePoolPrices:     make(map[string]fileValue),
+	}
+	if len(doc.Content) == 0 {
+		file.profile = profile
+		return file, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: expected a mapping at the top level", path, root.Line)
+	}
+
+	var defaults, profiles *yaml.Node
+	defaultProfile := ""
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "profile":
+			defaultProfile = value.Value
+		case "defaults":
+			defaults = value
+		case "profiles":
+			profiles = value
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown key %q, expected profile, defaults or profiles", path, key.Line, key.Value)
+		}
+	}
+
+	if defaults != nil {
+		if err := file.merge(path, defaults); err != nil {
+			return nil, err
+		}
+	}
+
+	if profile == "" {
+		profile = defaultProfile
+	}
+	file.profile = profile
+	if profile == "" {
+		return file, nil
+	}
+
+	available := make(map[string]*yaml.Node)
+	if profiles != nil {
+		if profiles.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s:%d: profiles must be a mapping", path, profiles.Line)
+		}
+		for i := 0; i < len(profiles.Content); i += 2 {
+			available[profiles.Content[i].Value] = profiles.Content[i+1]
+		}
+	}
+
+	selected, ok := available[profile]
+	if !ok {
+		names := make([]string, 0, len(available))
+		for name := range available {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%s: profile %q not found (available: %s)", path, profile, strings.Join(names, ", "))
+	}
+	if err := file.merge(path, selected); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// merge layers the settings of a mapping node over the current values
+func (f *fileSettings) merge(path string, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s:%d: expected a mapping of settings", path, node.Line)
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		switch key.Value {
+		case "namespaces":
+			if err := f.mergeNamespaces(path, value); err != nil {
+				return err
+			}
+			continue
+		case "clusterPrices":
+			if err := mergePrices(path, key.Value, value, f.clusterPrices); err != nil {
+				return err
+			}
+			continue
+		case "nodePoolPrices":
+			if err := mergePrices(path, key.Value, value, f.nodePoolPrices); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isSetting(key.Value) {
+			return fmt.Errorf("%s:%d: unknown setting %q", path, key.Line, key.Value)
+		}
+
+		v, err := scalarValue(path, value)
+		if err != nil {
+			return err
+		}
+		f.values[key.Value] = v
+	}
+	return nil
+}
+
+// mergeNamespaces layers per-namespace overrides over the current ones
+func (f *fileSettings) mergeNamespaces(path string, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s:%d: namespaces must be a mapping", path, node.Line)
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		namespace, section := node.Content[i].Value, node.Content[i+1]
+		if section.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s:%d: namespace %s must be a mapping of settings", path, section.Line, namespace)
+		}
+
+		f.namespaceLocations[namespace] = fmt.Sprintf("%s:%d", path, node.Content[i].Line)
+		values, ok := f.namespaces[namespace]
+		if !ok {
+			values = make(map[string]fileValue)
+			f.namespaces[namespace] = values
+		}
+		for j := 0; j < len(section.Content); j += 2 {
+			key, value := section.Content[j], section.Content[j+1]
+			if !isNamespaceSetting(key.Value) {
+				return fmt.Errorf("%s:%d: setting %q cannot be overridden per namespace (allowed: %s)",
+					path, key.Line, key.Value, strings.Join(namespaceSettings, ", "))
+			}
+			v, err := scalarValue(path, value)
+			if err != nil {
+				return err
+			}
+			values[key.Value] = v
+		}
+	}
+	return nil
+}
+
+// mergePrices layers the prices of a mapping node, named section, over prices
+func mergePrices(path, section string, node *yaml.Node, prices map[string]fileValue) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s:%d: %s must be a mapping of prices", path, node.Line, section)
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		v, err := scalarValue(path, node.Content[i+1])
+		if err != nil {
+			return err
+		}
+		prices[node.Content[i].Value] = v
+	}
+	return nil
+}
+
+// namespaceOverrides converts the raw per-namespace settings
+func (f *fileSettings) namespaceOverrides() (map[string]*NamespaceOverride, error) {
+	if len(f.namespaces) == 0 {
+		return nil, nil
+	}
+
+	overrides := make(map[string]*NamespaceOverride, len(f.namespaces))
+	for namespace, values := range f.namespaces {
+		override, err := parseNamespaceOverride(f.namespaceLocations[namespace], values)
+		if err != nil {
+			return nil, err
+		}
+		overrides[namespace] = override
+	}
+	return overrides, nil
+}
+
+// scalarValue converts a setting node to its string form. Sequences, as used
+// for clusters, are joined with commas.
+func scalarValue(path string, node *yaml.Node) (fileValue, error) {
+	location := fmt.Sprintf("%s:%d", path, node.Line)
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return fileValue{value: node.Value, location: location}, nil
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return fileValue{}, fmt.Errorf("%s:%d: expected a list of scalar values", path, item.Line)
+			}
+			items = append(items, item.Value)
+		}
+		return fileValue{value: strings.Join(items, ","), location: location}, nil
+	default:
+		return fileValue{}, fmt.Errorf("%s: expected a scalar value", location)
+	}
+}
+
+// isSetting reports whether name is a setting loadable from the file
+func isSetting(name string) bool {
+	for _, s := range settings {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isNamespaceSetting reports whether name may be overridden per namespace
+func isNamespaceSetting(name string) bool {
+	for _, s := range namespaceSettings {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,362 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfigFile = `profile: staging
+defaults:
+  prometheusUrl: https://prometheus.example.com
+  countDays: 7
+profiles:
+  prod:
+    prometheusUrl: https://thanos.prod.example.com
+    checkNamespace: shop
+    limits: 1.3
+    workerCount: 40
+    httpTimeout: 2m
+    clusterLabel: cluster
+    clusters: [prod-eu, prod-us]
+    namespaces:
+      batch:
+        limits: 2
+        countDays: 14
+  staging:
+    checkNamespace: shop-staging
+    limits: 2.0
+  broken:
+    checkNamespace: ""
+`
+
+// writeConfigFile writes content to a temporary configuration file
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+// loadTestConfig loads a configuration from args with a fresh flag set
+func loadTestConfig(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	return LoadFromArgs(flag.NewFlagSet("test", flag.ContinueOnError), args)
+}
+
+func TestLoadFromArgs_DefaultProfileFromFile(t *testing.T) {
+	path := writeConfigFile(t, testConfigFile)
+
+	config, err := loadTestConfig(t, "-config", path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.Profile != "staging" {
+		t.Errorf("Expected profile 'staging', got '%s'", config.Profile)
+	}
+	if config.CheckNamespace != "shop-staging" {
+		t.Errorf("Expected CheckNamespace 'shop-staging', got '%s'", config.CheckNamespace)
+	}
+	if config.MemoryLimitMultiplier != 2.0 {
+		t.Errorf("Expected MemoryLimitMultiplier 2.0, got %.1f", config.MemoryLimitMultiplier)
+	}
+	if config.PrometheusURL != "https://prometheus.example.com" {
+		t.Errorf("Expected PrometheusURL from defaults, got '%s'", config.PrometheusURL)
+	}
+	if config.WorkerCount != 20 {
+		t.Errorf("Expected built-in WorkerCount 20, got %d", config.WorkerCount)
+	}
+	if source := config.Source("limits"); source != path+":20" {
+		t.Errorf("Expected limits source '%s:20', got '%s'", path, source)
+	}
+	if source := config.Source("workerCount"); source != "default" {
+		t.Errorf("Expected workerCount source 'default', got '%s'", source)
+	}
+}
+
+func TestLoadFromArgs_NamedProfile(t *testing.T) {
+	path := writeConfigFile(t, testConfigFile)
+
+	config, err := loadTestConfig(t, "-config", path, "-profile", "prod")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if config.PrometheusURL != "https://thanos.prod.example.com" {
+		t.Errorf("Expected profile to override defaults, got '%s'", config.PrometheusURL)
+	}
+	if config.WorkerCount != 40 {
+		t.Errorf("Expected WorkerCount 40, got %d", config.WorkerCount)
+	}
+	if config.HTTPTimeout != 2*time.Minute {
+		t.Errorf("Expected HTTPTimeout 2m, got %v", config.HTTPTimeout)
+	}
+	if config.CountDays != 7 {
+		t.Errorf("Expected CountDays 7 from defaults, got %d", config.CountDays)
+	}
+	if config.Clusters != "prod-eu,prod-us" {
+		t.Errorf("Expected clusters list to be joined, got '%s'", config.Clusters)
+	}
+}
+
+func TestLoadFromArgs_Precedence(t *testing.T) {
+	path := writeConfigFile(t, testConfigFile)
+	t.Setenv("RESOURCES_RECOMMEND_WORKER_COUNT", "10")
+	t.Setenv("RESOURCES_RECOMMEND_LIMITS", "1.8")
+
+	config, err := loadTestConfig(t, "-config", path, "-profile", "prod", "-limits", "1.1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// flag > env > file
+	if config.MemoryLimitMultiplier != 1.1 {
+		t.Errorf("Expected flag to win with 1.1, got %.1f", config.MemoryLimitMultiplier)
+	}
+	// env > file
+	if config.WorkerCount != 10 {
+		t.Errorf("Expected env var to win with 10, got %d", config.WorkerCount)
+	}
+	// file > default
+	if config.CheckNamespace != "shop" {
+		t.Errorf("Expected file value 'shop', got '%s'", config.CheckNamespace)
+	}
+
+	if source := config.Source("limits"); source != "flag" {
+		t.Errorf("Expected limits source 'flag', got '%s'", source)
+	}
+	if source := config.Source("workerCount"); source != "env RESOURCES_RECOMMEND_WORKER_COUNT" {
+		t.Errorf("Expected workerCount source 'env RESOURCES_RECOMMEND_WORKER_COUNT', got '%s'", source)
+	}
+}
+
+func TestLoadFromArgs_ConfigFileFromEnv(t *testing.T) {
+	path := writeConfigFile(t, testConfigFile)
+	t.Setenv("RESOURCES_RECOMMEND_CONFIG_FILE", path)
+	t.Setenv("RESOURCES_RECOMMEND_PROFILE", "prod")
+
+	config, err := loadTestConfig(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.CheckNamespace != "shop" {
+		t.Errorf("Expected CheckNamespace 'shop', got '%s'", config.CheckNamespace)
+	}
+}
+
+func TestLoadFromArgs_IgnoresUnprefixedEnv(t *testing.T) {
+	t.Setenv("PROFILE", "prod")
+	t.Setenv("CLUSTERS", "ci")
+	t.Setenv("PERCENTILE", "50")
+
+	config, err := loadTestConfig(t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Profile != "" || config.Clusters != "" || config.Percentile != 90 {
+		t.Errorf("Expected unprefixed variables to be ignored, got profile %q, clusters %q, percentile %g",
+			config.Profile, config.Clusters, config.Percentile)
+	}
+	if source := config.Source("percentile"); source != "default" {
+		t.Errorf("Expected percentile source 'default', got '%s'", source)
+	}
+}
+
+func TestConfig_ForNamespace(t *testing.T) {
+	path := writeConfigFile(t, testConfigFile)
+
+	config, err := loadTestConfig(t, "-config", path, "-profile", "prod")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	batch := config.ForNamespace("batch")
+	if batch.CheckNamespace != "batch" {
+		t.Errorf("Expected CheckNamespace 'batch', got '%s'", batch.CheckNamespace)
+	}
+	if batch.MemoryLimitMultiplier != 2 {
+		t.Errorf("Expected namespace override 2, got %.1f", batch.MemoryLimitMultiplier)
+	}
+	if batch.CountDays != 14 {
+		t.Errorf("Expected namespace override 14, got %d", batch.CountDays)
+	}
+	if batch.WorkerCount != 40 {
+		t.Errorf("Expected profile WorkerCount 40, got %d", batch.WorkerCount)
+	}
+	if config.MemoryLimitMultiplier != 1.3 {
+		t.Errorf("Expected original config to be unchanged, got %.1f", config.MemoryLimitMultiplier)
+	}
+
+	shop := config.ForNamespace("shop")
+	if shop.MemoryLimitMultiplier != 1.3 {
+		t.Errorf("Expected namespace without overrides to keep 1.3, got %.1f", shop.MemoryLimitMultiplier)
+	}
+
+	// An explicit flag wins over the namespace override
+	config, err = loadTestConfig(t, "-config", path, "-profile", "prod", "-countDays", "3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if days := config.ForNamespace("batch").CountDays; days != 3 {
+		t.Errorf("Expected flag CountDays 3 to win over namespace override, got %d", days)
+	}
+}
+
+func TestLoadFromArgs_Prices(t *testing.T) {
+	path := writeConfigFile(t, `defaults:
+  memoryPrice: 0.004
+  clusterPrices:
+    prod-eu: 0.005
+profiles:
+  prod:
+    currency: EUR
+    nodePoolLabel: label_pool
+    clusterPrices:
+      prod-us: -1
+    nodePoolPrices:
+      highmem: 0.003
+    namespaces:
+      batch:
+        memoryPrice: 0.002
+`)
+
+	config, err := loadTestConfig(t, "-config", path, "-profile", "prod")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.MemoryPrice != 0.004 || config.Currency != "EUR" || config.NodePoolLabel != "label_pool" {
+		t.Errorf("Unexpected pricing settings: %g %s %s", config.MemoryPrice, config.Currency, config.NodePoolLabel)
+	}
+	if len(config.ClusterPrices) != 2 || config.ClusterPrices["prod-eu"] != 0.005 || config.NodePoolPrices["highmem"] != 0.003 {
+		t.Errorf("Unexpected prices: %v %v", config.ClusterPrices, config.NodePoolPrices)
+	}
+	if price := config.ForNamespace("batch").MemoryPrice; price != 0.002 {
+		t.Errorf("Expected namespace price 0.002, got %g", price)
+	}
+	if prices := config.NamespacePrices(); len(prices) != 1 || prices["batch"] != 0.002 {
+		t.Errorf("Expected the namespace price of batch, got %v", prices)
+	}
+
+	var fieldErr *FieldError
+	var priceErr *InvalidPriceError
+	err = config.Validate()
+	if !errors.As(err, &fieldErr) || !errors.As(err, &priceErr) {
+		t.Fatalf("Expected an InvalidPriceError, got %v", err)
+	}
+	if fieldErr.Field != "clusterPrices.prod-us" || fieldErr.Source != path+":10" || priceErr.Price != -1 {
+		t.Errorf("Expected clusterPrices.prod-us at %s:10, got %v", path, err)
+	}
+}
+
+func TestConfig_NamespacePrices_Flag(t *testing.T) {
+	path := writeConfigFile(t, `defaults:
+  namespaces:
+    batch:
+      memoryPrice: 0.002
+`)
+
+	// A price given by flag wins over the namespace prices of the file
+	config, err := loadTestConfig(t, "-config", path, "-memoryPrice", "0.004")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if override := config.NamespaceOverrides["batch"]; override == nil || override.MemoryPrice == nil {
+		t.Fatalf("Expected the namespace price of batch in the file, got %v", config.NamespaceOverrides)
+	}
+	if prices := config.NamespacePrices(); len(prices) != 0 {
+		t.Errorf("Expected no namespace prices, got %v", prices)
+	}
+}
+
+func TestLoadFromArgs_FileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "Unknown profile",
+			content:  testConfigFile,
+			args:     []string{"-profile", "qa"},
+			expected: `profile "qa" not found (available: broken, prod, staging)`,
+		},
+		{
+			name:     "Unknown setting",
+			content:  "defaults:\n  prometheusURL: x\n",
+			expected: `:2: unknown setting "prometheusURL"`,
+		},
+		{
+			name:     "Unknown top-level key",
+			content:  "prometheusUrl: x\n",
+			expected: `:1: unknown key "prometheusUrl"`,
+		},
+		{
+			name:     "Invalid number",
+			content:  "defaults:\n  countDays: seven\n",
+			expected: `:2: countDays: invalid value "seven"`,
+		},
+		{
+			name:     "Setting not overridable per namespace",
+			content:  "defaults:\n  namespaces:\n    batch:\n      prometheusUrl: x\n",
+			expected: `:4: setting "prometheusUrl" cannot be overridden per namespace`,
+		},
+		{
+			name:     "Prices not a mapping",
+			content:  "defaults:\n  clusterPrices: 0.004\n",
+			expected: `:2: clusterPrices must be a mapping of prices`,
+		},
+		{
+			name:     "Invalid price",
+			content:  "defaults:\n  nodePoolPrices:\n    highmem: cheap\n",
+			expected: `:3: nodePoolPrices.highmem: invalid value "cheap"`,
+		},
+		{
+			name:     "Invalid YAML",
+			content:  "defaults: [\n",
+			expected: "failed to parse config file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, tt.content)
+			_, err := loadTestConfig(t, append([]string{"-config", path}, tt.args...)...)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing '%s', got '%v'", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_ReportsFileLocation(t *testing.T) {
+	path := writeConfigFile(t, testConfigFile)
+
+	config, err := loadTestConfig(t, "-config", path, "-profile", "broken")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err = config.Validate()
+	if !errors.Is(err, ErrMissingNamespace) {
+		t.Fatalf("Expected ErrMissingNamespace, got %v", err)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("Expected a FieldError, got %T", err)
+	}
+	if fieldErr.Field != "checkNamespace" || fieldErr.Source != path+":22" {
+		t.Errorf("Expected checkNamespace at %s:22, got %s at %s", path, fieldErr.Field, fieldErr.Source)
+	}
+}